@@ -6,11 +6,16 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/example/rss-server/internal/config"
 	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/rss"
+	"github.com/example/rss-server/internal/scheduler"
 	"github.com/example/rss-server/internal/storage"
+	"github.com/example/rss-server/internal/websub"
 )
 
 // loggingMiddleware logs all HTTP requests
@@ -41,68 +46,203 @@ func (lw *loggingResponseWriter) WriteHeader(code int) {
 	lw.ResponseWriter.WriteHeader(code)
 }
 
-func main() {
-	// T009: Load configuration at startup
-	cfg, err := config.Load("./config.yaml")
+// openSQLiteStore opens the SQLite metadata store. When the database is
+// created for the first time, an existing file-based feed is imported once.
+func openSQLiteStore(dbPath string, rssFile string, baseURL string) (*storage.SQLiteStore, error) {
+	_, statErr := os.Stat(dbPath)
+	firstRun := os.IsNotExist(statErr)
+
+	store, err := storage.OpenSQLiteStore(dbPath, baseURL)
 	if err != nil {
-		log.Fatalf("Configuration error: %v", err)
+		return nil, err
 	}
 
-	// T010: Fail-fast validation
-	if err := cfg.Validate(); err != nil {
-		log.Fatalf("Configuration validation failed: %v", err)
+	if firstRun {
+		if _, err := os.Stat(rssFile); err == nil {
+			imported, err := store.ImportFeed(rssFile)
+			if err != nil {
+				store.Close()
+				return nil, fmt.Errorf("failed to import %s: %w", rssFile, err)
+			}
+			log.Printf("Imported %d episodes from %s", imported, rssFile)
+		}
 	}
 
-	// T011: Log successful startup with base URL
-	log.Println("RSS Server starting...")
-	log.Printf("Base URL: %s", cfg.GetBaseURL())
+	return store, nil
+}
 
-	// Use configuration values
-	audioDir := cfg.Paths.AudioDir
-	artworkDir := cfg.Paths.ArtworkDir
-	rssFile := cfg.Paths.RSSFile
-	templatesDir := "./web/templates"
-	maxUploadMB := int64(cfg.Upload.MaxFileSizeMB)
-	baseURL := cfg.GetBaseURL()
+// showPaths is where a show keeps its metadata and files
+type showPaths struct {
+	rssFile    string
+	database   string
+	audioDir   string
+	artworkDir string
+	trashDir   string
+	uploadsDir string
+	keyPrefix  string // S3 key prefix of the show's blobs
+	urlPrefix  string // URL path prefix the show's files are served under
+}
+
+// defaultShowPaths returns the configured paths of the default show
+func defaultShowPaths(cfg *config.Config) showPaths {
+	paths := showPaths{
+		rssFile:    cfg.Paths.RSSFile,
+		database:   cfg.Paths.Database,
+		audioDir:   cfg.Paths.AudioDir,
+		artworkDir: cfg.Paths.ArtworkDir,
+		trashDir:   cfg.Paths.TrashDir,
+		uploadsDir: cfg.Paths.UploadsDir,
+	}
+	if paths.trashDir == "" {
+		paths.trashDir = filepath.Join(cfg.Paths.DataDir, "trash")
+	}
+	if paths.uploadsDir == "" {
+		paths.uploadsDir = filepath.Join(cfg.Paths.DataDir, "uploads")
+	}
+	return paths
+}
+
+// showDirPaths returns the paths of a show kept in its own directory
+func showDirPaths(dir string, slug string) showPaths {
+	return showPaths{
+		rssFile:    filepath.Join(dir, "podcast.xml"),
+		database:   filepath.Join(dir, "podcast.db"),
+		audioDir:   filepath.Join(dir, "audio"),
+		artworkDir: filepath.Join(dir, "artwork"),
+		trashDir:   filepath.Join(dir, "trash"),
+		uploadsDir: filepath.Join(dir, "uploads"),
+		keyPrefix:  "shows/" + slug + "/",
+		urlPrefix:  "/shows/" + slug,
+	}
+}
+
+// openStore opens a show's metadata store for the configured backend
+func openStore(cfg *config.Config, paths showPaths, baseURL string) (storage.Store, error) {
+	if cfg.Storage.Backend == config.StorageBackendSQLite {
+		store, err := openSQLiteStore(paths.database, paths.rssFile, baseURL)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
 
 	// Load RSS store with base URL
-	store, err := storage.LoadRSSStore(rssFile, baseURL)
-	if err != nil {
-		log.Fatalf("Failed to load RSS store: %v", err)
+	return storage.LoadRSSStore(paths.rssFile, baseURL)
+}
+
+// openBlobStores creates a show's audio, artwork and trash blob stores for
+// the configured backend
+func openBlobStores(cfg *config.Config, paths showPaths) (storage.BlobStore, storage.BlobStore, storage.BlobStore, error) {
+	if cfg.Storage.BlobBackend != config.BlobBackendS3 {
+		return storage.NewFSBlobStore(paths.audioDir, paths.urlPrefix+"/audio/"),
+			storage.NewFSBlobStore(paths.artworkDir, paths.urlPrefix+"/static/artwork/"),
+			storage.NewFSBlobStore(paths.trashDir, ""), nil
 	}
 
-	log.Println("Loaded podcast feed successfully")
+	s3 := cfg.Storage.S3
+	opts := storage.S3Options{
+		Endpoint:        s3.Endpoint,
+		Region:          s3.Region,
+		Bucket:          s3.Bucket,
+		AccessKeyID:     s3.AccessKeyID,
+		SecretAccessKey: s3.SecretAccessKey,
+		PathStyle:       s3.PathStyle,
+		PublicURL:       s3.PublicURL,
+		PresignExpiry:   time.Duration(s3.PresignExpiryMinutes) * time.Minute,
+	}
 
-	// Load templates
-	tmpl, err := template.ParseGlob(templatesDir + "/*.html")
+	audio, err := storage.NewS3BlobStore(opts, paths.keyPrefix+"audio/", paths.urlPrefix+"/audio/")
 	if err != nil {
-		log.Fatalf("Failed to parse templates: %v", err)
+		return nil, nil, nil, err
 	}
-	tmpl, err = tmpl.ParseGlob(templatesDir + "/components/*.html")
+	artwork, err := storage.NewS3BlobStore(opts, paths.keyPrefix+"artwork/", paths.urlPrefix+"/static/artwork/")
 	if err != nil {
-		log.Fatalf("Failed to parse component templates: %v", err)
+		return nil, nil, nil, err
+	}
+	trash, err := storage.NewS3BlobStore(opts, paths.keyPrefix+"trash/", "")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return audio, artwork, trash, nil
+}
+
+// showHandler builds the routes of one show, as served at the root for
+// the default show, and starts the show's background jobs
+func showHandler(cfg *config.Config, slug string, store storage.Store, paths showPaths, baseURL string, tmpl *template.Template, shows *handlers.ShowsHandler, hubs *websub.Publisher) (http.Handler, error) {
+	// The podcast:guid is derived from the feed URL once and then kept, so
+	// moving the show to another base URL doesn't change its identity
+	if _, err := storage.EnsureGUID(store, baseURL+"/feed.xml"); err != nil {
+		return nil, err
+	}
+
+	// Ping the WebSub hubs about every feed that announces them whenever
+	// the show's public feeds change, including when scheduled episodes
+	// are published
+	if hubs != nil {
+		store = storage.NotifyOnChange(store, func() {
+			hubs.Notify(baseURL+"/feed.xml", baseURL+"/feed.atom", baseURL+"/feed.json", baseURL+rss.ArchivePath)
+		})
+	}
+
+	maxUploadMB := int64(cfg.Upload.MaxFileSizeMB)
+	templatesDir := "./web/templates"
+
+	// Blob stores for audio and artwork files, and files of deleted episodes
+	audioBlobs, artworkBlobs, trashBlobs, err := openBlobStores(cfg, paths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure blob storage: %w", err)
 	}
 
 	// Create handlers
-	episodesHandler := handlers.NewEpisodesHandler(store, audioDir, artworkDir, maxUploadMB, tmpl)
-	feedHandler := handlers.NewFeedHandler(store)
-	staticHandler := handlers.NewStaticHandler(audioDir)
+	episodesHandler := handlers.NewEpisodesHandler(store, audioBlobs, artworkBlobs, maxUploadMB, cfg.Upload.AllowedExtensions, tmpl)
+	episodesHandler.SetShow(slug)
+	// Resumable (tus) uploads share the episode creation logic
+	uploadExpiry := time.Duration(cfg.Upload.ResumableExpiryHours) * time.Hour
+	if uploadExpiry <= 0 {
+		uploadExpiry = 24 * time.Hour
+	}
+	tusHandler, err := handlers.NewTusHandler(episodesHandler, paths.uploadsDir, uploadExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload handler: %w", err)
+	}
+	tusHandler.StartJanitor(time.Hour, nil)
+
+	// Publish scheduled episodes when their publication date passes
+	publisher := scheduler.New(store)
+	publisher.Start(time.Minute, nil)
+
+	// Deleted episodes stay in the trash for the retention period
+	retention := time.Duration(cfg.Trash.RetentionDays) * 24 * time.Hour
+	if retention <= 0 {
+		retention = 30 * 24 * time.Hour
+	}
+	trashHandler := handlers.NewTrashHandler(store, audioBlobs, artworkBlobs, trashBlobs, retention, tmpl)
+	trashHandler.SetShow(slug)
+	trashHandler.StartPurger(time.Hour, nil)
+
+	feedHandler := handlers.NewFeedHandler(store, baseURL)
+	feedHandler.SetPaging(cfg.Feed.PageSize, cfg.Feed.MaxEpisodes)
+	feedHandler.SetHubs(cfg.Feed.WebSubHubs)
+	previewFeedHandler := handlers.NewPreviewFeedHandler(store, baseURL, cfg.Feed.PreviewToken)
+	// Files of unreleased episodes are only served to preview subscribers
+	chaptersHandler := handlers.NewChaptersHandler(store, baseURL)
+	chaptersHandler.SetPreviewToken(cfg.Feed.PreviewToken)
+	transcriptsHandler := handlers.NewTranscriptsHandler(store, audioBlobs)
+	transcriptsHandler.SetPreviewToken(cfg.Feed.PreviewToken)
+	staticHandler := handlers.NewStaticHandler(store, audioBlobs, artworkBlobs)
+	staticHandler.SetPreviewToken(cfg.Feed.PreviewToken)
 	// T049: Updated to pass baseURL to NewWebHandler
 	webHandler, err := handlers.NewWebHandler(store, templatesDir, baseURL)
 	if err != nil {
-		log.Fatalf("Failed to create web handler: %v", err)
+		return nil, fmt.Errorf("failed to create web handler: %w", err)
 	}
+	webHandler.SetShows(shows, slug)
 
-	// Create HTTP server
 	mux := http.NewServeMux()
 
-	// Serve static files (CSS, images)
-	fs := http.FileServer(http.Dir("./web/static"))
-	mux.Handle("/static/", http.StripPrefix("/static/", fs))
-
 	// Serve artwork files
-	artworkFS := http.FileServer(http.Dir("./data/artwork"))
-	mux.Handle("/static/artwork/", http.StripPrefix("/static/artwork/", artworkFS))
+	mux.HandleFunc("/static/artwork/", staticHandler.HandleArtwork)
 
 	// Web UI routes
 	mux.HandleFunc("/", webHandler.HandleDashboard)
@@ -118,15 +258,96 @@ func main() {
 		}
 	})
 
-	// DELETE /api/episodes/{episodeId}
+	// GET, PATCH, PUT, DELETE /api/episodes/{episodeId}
+	// GET, PUT /api/episodes/{episodeId}/chapters
+	// PUT, DELETE /api/episodes/{episodeId}/transcript
+	// PUT /api/episodes/{episodeId}/audio
+	// POST /api/episodes/{episodeId}/publish
 	mux.HandleFunc("/api/episodes/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodDelete {
-			episodesHandler.HandleDelete(w, r)
+		if strings.HasSuffix(r.URL.Path, "/publish") {
+			episodesHandler.HandlePublish(w, r)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/audio") {
+			episodesHandler.HandleReplaceAudio(w, r)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/transcript") {
+			switch r.Method {
+			case http.MethodPut, http.MethodPost:
+				episodesHandler.HandleUploadTranscript(w, r)
+			case http.MethodDelete:
+				episodesHandler.HandleDeleteTranscript(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/chapters") {
+			switch r.Method {
+			case http.MethodGet:
+				episodesHandler.HandleGetChapters(w, r)
+			case http.MethodPut:
+				episodesHandler.HandleUpdateChapters(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			episodesHandler.HandleGet(w, r)
+		case http.MethodPatch, http.MethodPut:
+			episodesHandler.HandleUpdate(w, r)
+		case http.MethodDelete:
+			trashHandler.HandleTrash(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// GET /api/trash
+	// POST /api/trash/{episodeId}/restore
+	// DELETE /api/trash/{episodeId}
+	mux.HandleFunc("/api/trash", trashHandler.HandleList)
+	mux.HandleFunc("/api/trash/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/restore") {
+			trashHandler.HandleRestore(w, r)
+		} else {
+			trashHandler.HandlePurge(w, r)
+		}
+	})
+
+	// Resumable uploads (tus protocol)
+	mux.HandleFunc("/api/uploads", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			tusHandler.HandleCreate(w, r)
+		} else if r.Method == http.MethodOptions {
+			tusHandler.HandleOptions(w, r)
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
 
+	mux.HandleFunc("/api/uploads/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			tusHandler.HandleHead(w, r)
+		case http.MethodPatch:
+			tusHandler.HandlePatch(w, r)
+		case http.MethodDelete:
+			tusHandler.HandleDelete(w, r)
+		case http.MethodOptions:
+			tusHandler.HandleOptions(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	mux.HandleFunc("/api/podcast/settings", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 			episodesHandler.HandleGetSettings(w, r)
@@ -137,12 +358,130 @@ func main() {
 		}
 	})
 
-	// RSS feed route
+	// RSS feed route, paged or capped as configured, and the complete archive
 	mux.HandleFunc("/feed.xml", feedHandler.HandleFeed)
+	mux.HandleFunc("/feed-archive.xml", feedHandler.HandleArchive)
+
+	// The same episodes for feed readers: Atom and JSON Feed 1.1
+	mux.HandleFunc("/feed.atom", feedHandler.HandleAtom)
+	mux.HandleFunc("/feed.json", feedHandler.HandleJSONFeed)
+
+	// Token-protected feed including drafts and scheduled episodes
+	mux.HandleFunc("/feed-preview.xml", previewFeedHandler.HandlePreviewFeed)
+
+	// Podcasting 2.0 chapters documents and transcripts
+	mux.HandleFunc("/episodes/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/chapters.json") {
+			chaptersHandler.HandleChapters(w, r)
+		} else {
+			transcriptsHandler.HandleTranscript(w, r)
+		}
+	})
 
 	// Audio file serving route
 	mux.HandleFunc("/audio/", staticHandler.HandleAudio)
 
+	return mux, nil
+}
+
+func main() {
+	// T009: Load configuration at startup
+	cfg, err := config.Load("./config.yaml")
+	if err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+
+	// T010: Fail-fast validation
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Configuration validation failed: %v", err)
+	}
+
+	// T011: Log successful startup with base URL
+	log.Println("RSS Server starting...")
+	log.Printf("Base URL: %s", cfg.GetBaseURL())
+
+	// Use configuration values
+	templatesDir := "./web/templates"
+	baseURL := cfg.GetBaseURL()
+	defaultPaths := defaultShowPaths(cfg)
+	showsDir := cfg.Paths.ShowsDir
+	if showsDir == "" {
+		showsDir = filepath.Join(cfg.Paths.DataDir, "shows")
+	}
+
+	// Open the default show's metadata store for the configured backend
+	store, err := openStore(cfg, defaultPaths, baseURL)
+	if err != nil {
+		log.Fatalf("Failed to load metadata store: %v", err)
+	}
+
+	log.Println("Loaded podcast feed successfully")
+
+	// Further shows each live in their own directory under showsDir
+	shows, err := storage.OpenShows(showsDir, func(slug string) (storage.Store, error) {
+		return openStore(cfg, showDirPaths(filepath.Join(showsDir, slug), slug), handlers.ShowBaseURL(baseURL, slug))
+	})
+	if err != nil {
+		log.Fatalf("Failed to load shows: %v", err)
+	}
+	if err := shows.Add(storage.DefaultShow, store); err != nil {
+		log.Fatalf("Failed to load shows: %v", err)
+	}
+
+	if cfg.Storage.BlobBackend == config.BlobBackendS3 {
+		log.Printf("Using S3 blob storage: bucket %s at %s", cfg.Storage.S3.Bucket, cfg.Storage.S3.Endpoint)
+	}
+
+	// Load templates
+	tmpl, err := template.ParseGlob(templatesDir + "/*.html")
+	if err != nil {
+		log.Fatalf("Failed to parse templates: %v", err)
+	}
+	tmpl, err = tmpl.ParseGlob(templatesDir + "/components/*.html")
+	if err != nil {
+		log.Fatalf("Failed to parse component templates: %v", err)
+	}
+
+	// WebSub hubs are notified from one background queue for all shows
+	var hubs *websub.Publisher
+	if len(cfg.Feed.WebSubHubs) > 0 {
+		hubs = websub.New(cfg.Feed.WebSubHubs)
+		hubs.Start(nil)
+		log.Printf("Notifying %d WebSub hubs of feed changes", len(cfg.Feed.WebSubHubs))
+	}
+
+	// Every show gets its own handlers and background jobs
+	var showsHandler *handlers.ShowsHandler
+	showsHandler = handlers.NewShowsHandler(shows, baseURL, func(slug string, store storage.Store) (http.Handler, error) {
+		paths := defaultPaths
+		if slug != storage.DefaultShow {
+			paths = showDirPaths(shows.Dir(slug), slug)
+		}
+		return showHandler(cfg, slug, store, paths, handlers.ShowBaseURL(baseURL, slug), tmpl, showsHandler, hubs)
+	})
+	if err := showsHandler.MountShows(); err != nil {
+		log.Fatalf("Failed to start shows: %v", err)
+	}
+	log.Printf("Serving %d shows", len(shows.Slugs()))
+
+	// Create HTTP server
+	mux := http.NewServeMux()
+
+	// Serve static files (CSS, images)
+	fs := http.FileServer(http.Dir("./web/static"))
+	mux.Handle("/static/", http.StripPrefix("/static/", fs))
+
+	// The default show's artwork, feed, API and dashboard are served at the root
+	mux.HandleFunc("/static/artwork/", showsHandler.ServeDefault)
+	mux.HandleFunc("/", showsHandler.ServeDefault)
+
+	// GET, POST /api/shows
+	// /shows/{slug}/feed.xml, /shows/{slug}/audio/..., /shows/{slug}/ (dashboard)
+	// /api/shows/{slug}/episodes, /api/shows/{slug}/podcast/settings, ...
+	mux.HandleFunc("/api/shows", showsHandler.HandleShows)
+	mux.HandleFunc("/api/shows/", showsHandler.HandleShow)
+	mux.HandleFunc("/shows/", showsHandler.HandleShow)
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
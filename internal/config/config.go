@@ -6,9 +6,25 @@ import (
 	"os"
 	"strings"
 
+	"github.com/example/rss-server/internal/media"
 	"gopkg.in/yaml.v3"
 )
 
+// Supported metadata storage backends
+const (
+	StorageBackendFile   = "file"
+	StorageBackendSQLite = "sqlite"
+)
+
+// Supported blob storage backends for audio and artwork
+const (
+	BlobBackendFile = "file"
+	BlobBackendS3   = "s3"
+)
+
+// minPreviewTokenLen is the shortest accepted preview feed token
+const minPreviewTokenLen = 16
+
 // Config represents the application configuration
 type Config struct {
 	BaseURL string `yaml:"base_url"`
@@ -17,15 +33,43 @@ type Config struct {
 		Host string `yaml:"host"`
 	} `yaml:"server"`
 	Upload struct {
-		MaxFileSizeMB     int      `yaml:"max_file_size_mb"`
-		AllowedExtensions []string `yaml:"allowed_extensions"`
+		MaxFileSizeMB        int      `yaml:"max_file_size_mb"`
+		AllowedExtensions    []string `yaml:"allowed_extensions"`
+		ResumableExpiryHours int      `yaml:"resumable_expiry_hours"` // partial tus uploads (default 24)
 	} `yaml:"upload"`
 	Paths struct {
 		DataDir    string `yaml:"data_dir"`
 		AudioDir   string `yaml:"audio_dir"`
 		ArtworkDir string `yaml:"artwork_dir"`
 		RSSFile    string `yaml:"rss_file"`
+		Database   string `yaml:"database"`
+		UploadsDir string `yaml:"uploads_dir"` // partial resumable uploads (default data_dir/uploads)
+		TrashDir   string `yaml:"trash_dir"`   // files of deleted episodes (default data_dir/trash)
+		ShowsDir   string `yaml:"shows_dir"`   // additional shows, one directory each (default data_dir/shows)
 	} `yaml:"paths"`
+	Storage struct {
+		Backend     string `yaml:"backend"`      // "file" (default) or "sqlite"
+		BlobBackend string `yaml:"blob_backend"` // "file" (default) or "s3"
+		S3          struct {
+			Endpoint             string `yaml:"endpoint"`
+			Region               string `yaml:"region"`
+			Bucket               string `yaml:"bucket"`
+			AccessKeyID          string `yaml:"access_key_id"`
+			SecretAccessKey      string `yaml:"secret_access_key"`
+			PathStyle            bool   `yaml:"path_style"`
+			PublicURL            string `yaml:"public_url"`
+			PresignExpiryMinutes int    `yaml:"presign_expiry_minutes"`
+		} `yaml:"s3"`
+	} `yaml:"storage"`
+	Trash struct {
+		RetentionDays int `yaml:"retention_days"` // deleted episodes are purged after this (default 30)
+	} `yaml:"trash"`
+	Feed struct {
+		PreviewToken string   `yaml:"preview_token"` // enables /feed-preview.xml (or PREVIEW_TOKEN)
+		PageSize     int      `yaml:"page_size"`     // splits /feed.xml into pages of this many episodes (0 = off)
+		MaxEpisodes  int      `yaml:"max_episodes"`  // caps /feed.xml to the newest episodes (0 = off)
+		WebSubHubs   []string `yaml:"websub_hubs"`   // hubs pinged when the feed changes
+	} `yaml:"feed"`
 	Podcast struct {
 		DefaultTitle       string `yaml:"default_title"`
 		DefaultAuthor      string `yaml:"default_author"`
@@ -81,6 +125,101 @@ func (c *Config) Validate() error {
 	// Normalize base_url by removing trailing slash
 	c.BaseURL = strings.TrimRight(c.BaseURL, "/")
 
+	// Normalize allowed upload extensions to lowercase ".ext" and make sure
+	// each one maps to a supported audio format
+	for i, ext := range c.Upload.AllowedExtensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if media.FormatForExtension(ext) == nil {
+			return fmt.Errorf("upload.allowed_extensions contains unsupported audio type: %s", ext)
+		}
+		c.Upload.AllowedExtensions[i] = ext
+	}
+
+	// Validate storage backend
+	switch c.Storage.Backend {
+	case "", StorageBackendFile, StorageBackendSQLite:
+	default:
+		return fmt.Errorf("storage.backend must be %q or %q, got: %s", StorageBackendFile, StorageBackendSQLite, c.Storage.Backend)
+	}
+
+	if c.Storage.Backend == StorageBackendSQLite && c.Paths.Database == "" {
+		return fmt.Errorf("paths.database is required when storage.backend is %q", StorageBackendSQLite)
+	}
+
+	// The preview feed exposes unreleased episodes, so its token must not
+	// be guessable
+	if c.Feed.PreviewToken == "" {
+		c.Feed.PreviewToken = os.Getenv("PREVIEW_TOKEN")
+	}
+	if c.Feed.PreviewToken != "" && len(c.Feed.PreviewToken) < minPreviewTokenLen {
+		return fmt.Errorf("feed.preview_token must be at least %d characters", minPreviewTokenLen)
+	}
+
+	// Paging already limits /feed.xml to its first page, so a cap on top
+	// of it would be ambiguous
+	if c.Feed.PageSize < 0 || c.Feed.MaxEpisodes < 0 {
+		return fmt.Errorf("feed.page_size and feed.max_episodes must not be negative")
+	}
+	if c.Feed.PageSize > 0 && c.Feed.MaxEpisodes > 0 {
+		return fmt.Errorf("feed.page_size and feed.max_episodes cannot both be set")
+	}
+
+	// WebSub hubs are pinged over HTTP
+	for _, hub := range c.Feed.WebSubHubs {
+		u, err := url.Parse(hub)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("feed.websub_hubs must contain http or https URLs, got: %s", hub)
+		}
+	}
+
+	// Validate blob storage backend
+	switch c.Storage.BlobBackend {
+	case "", BlobBackendFile:
+	case BlobBackendS3:
+		if err := c.validateS3(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("storage.blob_backend must be %q or %q, got: %s", BlobBackendFile, BlobBackendS3, c.Storage.BlobBackend)
+	}
+
+	return nil
+}
+
+// validateS3 checks the S3 settings, filling credentials from the standard
+// AWS environment variables when they are not set in the file
+func (c *Config) validateS3() error {
+	s3 := &c.Storage.S3
+
+	if s3.AccessKeyID == "" {
+		s3.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if s3.SecretAccessKey == "" {
+		s3.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+
+	if s3.Endpoint == "" || s3.Region == "" || s3.Bucket == "" {
+		return fmt.Errorf("storage.s3 endpoint, region and bucket are required when storage.blob_backend is %q", BlobBackendS3)
+	}
+	if s3.AccessKeyID == "" || s3.SecretAccessKey == "" {
+		return fmt.Errorf("storage.s3 credentials are required (access_key_id/secret_access_key or AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY)")
+	}
+
+	endpoint, err := url.Parse(s3.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("storage.s3.endpoint must be an http(s) URL, got: %s", s3.Endpoint)
+	}
+
+	if s3.PublicURL != "" {
+		publicURL, err := url.Parse(s3.PublicURL)
+		if err != nil || (publicURL.Scheme != "http" && publicURL.Scheme != "https") || publicURL.Host == "" {
+			return fmt.Errorf("storage.s3.public_url must be an http(s) URL, got: %s", s3.PublicURL)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/example/rss-server/internal/media"
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// maxChaptersBytes bounds the size of a chapters update request
+const maxChaptersBytes = 1 << 20
+
+// ChaptersHandler serves episodes' public JSON chapters documents
+type ChaptersHandler struct {
+	store        storage.Store
+	baseURL      string
+	previewToken string
+}
+
+// NewChaptersHandler creates a new chapters handler
+func NewChaptersHandler(store storage.Store, baseURL string) *ChaptersHandler {
+	return &ChaptersHandler{store: store, baseURL: baseURL}
+}
+
+// SetPreviewToken serves the chapters of unreleased episodes to requests
+// carrying the preview feed's token
+func (h *ChaptersHandler) SetPreviewToken(token string) {
+	h.previewToken = token
+}
+
+// HandleChapters handles GET /episodes/{id}/chapters.json
+func (h *ChaptersHandler) HandleChapters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/episodes/", "/chapters.json")
+	if !ok {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	ep := findVisibleEpisode(h.store, episodeID, r, h.previewToken)
+	if ep == nil || len(ep.Chapters) == 0 {
+		http.Error(w, "Chapters not found", http.StatusNotFound)
+		return
+	}
+
+	data, err := rss.GenerateChapters(ep, h.baseURL)
+	if err != nil {
+		http.Error(w, "Failed to generate chapters", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", rss.ChaptersType)
+	w.Write(data)
+}
+
+// chaptersBody is the request and response body of the chapters API
+type chaptersBody struct {
+	Chapters []models.Chapter `json:"chapters"`
+}
+
+// HandleGetChapters handles GET /api/episodes/{id}/chapters
+func (h *EpisodesHandler) HandleGetChapters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/api/episodes/", "/chapters")
+	if !ok {
+		http.Error(w, "Episode ID required", http.StatusBadRequest)
+		return
+	}
+
+	ep := findEpisode(h.store, episodeID)
+	if ep == nil {
+		http.Error(w, "Episode not found", http.StatusNotFound)
+		return
+	}
+
+	body := chaptersBody{Chapters: ep.Chapters}
+	if body.Chapters == nil {
+		body.Chapters = []models.Chapter{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// HandleUpdateChapters handles PUT /api/episodes/{id}/chapters, replacing
+// the episode's chapters. An empty list removes them.
+func (h *EpisodesHandler) HandleUpdateChapters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/api/episodes/", "/chapters")
+	if !ok {
+		http.Error(w, "Episode ID required", http.StatusBadRequest)
+		return
+	}
+
+	var body chaptersBody
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxChaptersBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid chapters: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	chapters, err := normalizeChapters(body.Chapters)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid chapters: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ep := findEpisode(h.store, episodeID)
+	if ep == nil {
+		http.Error(w, "Episode not found", http.StatusNotFound)
+		return
+	}
+
+	ep.Chapters = chapters
+	if err := h.store.UpdateEpisode(*ep); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update episode: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chaptersBody{Chapters: chapters})
+}
+
+// normalizeChapters validates chapters and sorts them by start time
+func normalizeChapters(in []models.Chapter) ([]models.Chapter, error) {
+	out := make([]models.Chapter, 0, len(in))
+	for i, ch := range in {
+		ch.Title = strings.TrimSpace(ch.Title)
+		ch.URL = strings.TrimSpace(ch.URL)
+		ch.Img = strings.TrimSpace(ch.Img)
+
+		if ch.Title == "" {
+			return nil, fmt.Errorf("chapter %d: title is required", i+1)
+		}
+		if ch.StartTime < 0 || math.IsNaN(ch.StartTime) || math.IsInf(ch.StartTime, 0) {
+			return nil, fmt.Errorf("chapter %d: start time must be a non-negative number of seconds", i+1)
+		}
+		if ch.EndTime != 0 && ch.EndTime <= ch.StartTime {
+			return nil, fmt.Errorf("chapter %d: end time must be after the start time", i+1)
+		}
+		for _, u := range []string{ch.URL, ch.Img} {
+			if u != "" && !isHTTPURL(u) && !strings.HasPrefix(u, "/") {
+				return nil, fmt.Errorf("chapter %d: %q must be an HTTP(S) URL or an absolute path", i+1, u)
+			}
+		}
+		out = append(out, ch)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].StartTime < out[j].StartTime
+	})
+	return out, nil
+}
+
+// importChapters converts ID3 chapters, saving any embedded chapter images
+// as artwork. Images that fail to save are dropped with a warning.
+func (h *EpisodesHandler) importChapters(tagChapters []media.Chapter, baseName string) []models.Chapter {
+	var chapters []models.Chapter
+	for i, tc := range tagChapters {
+		ch := models.Chapter{
+			StartTime: tc.Start.Seconds(),
+			EndTime:   tc.End.Seconds(),
+			Title:     tc.Title,
+			URL:       tc.URL,
+		}
+		if ch.Title == "" {
+			ch.Title = fmt.Sprintf("Chapter %d", i+1)
+		}
+		if ch.EndTime <= ch.StartTime {
+			ch.EndTime = 0
+		}
+		if ch.URL != "" && !isHTTPURL(ch.URL) {
+			ch.URL = ""
+		}
+
+		if tc.Image != nil {
+			name := fmt.Sprintf("%s-chapter-%d%s", baseName, i+1, tc.Image.Ext())
+			if filename, err := storage.SaveArtworkFile(name, tc.Image.Data, h.artwork); err != nil {
+				log.Printf("Warning: Failed to save chapter image %s: %v", name, err)
+			} else {
+				ch.Img = h.artwork.URL(filename)
+			}
+		}
+
+		chapters = append(chapters, ch)
+	}
+	return chapters
+}
+
+// episodePathID extracts the episode ID from prefix{id}suffix
+func episodePathID(path, prefix, suffix string) (string, bool) {
+	if !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) {
+		return "", false
+	}
+	id := strings.TrimSuffix(strings.TrimPrefix(path, prefix), suffix)
+	if id == "" || strings.Contains(id, "/") {
+		return "", false
+	}
+	return id, true
+}
+
+// findEpisode returns a copy of the episode with the given ID, or nil
+func findEpisode(store storage.Store, episodeID string) *models.Episode {
+	for _, ep := range store.GetPodcast().Episodes {
+		if ep.ID == episodeID {
+			return &ep
+		}
+	}
+	return nil
+}
+
+// findVisibleEpisode returns a copy of the episode with the given ID if r
+// may see it, or nil. Public routes use it so that scheduled and draft
+// episodes stay hidden until they are released, except from the preview
+// feed's subscribers.
+func findVisibleEpisode(store storage.Store, episodeID string, r *http.Request, previewToken string) *models.Episode {
+	ep := findEpisode(store, episodeID)
+	if ep == nil || !visible(ep, r, previewToken) {
+		return nil
+	}
+	return ep
+}
+
+// visible reports whether r may see the episode: published episodes are
+// public, while drafts and scheduled episodes need the preview token
+func visible(ep *models.Episode, r *http.Request, previewToken string) bool {
+	return ep.Published() || hasPreviewToken(r, previewToken)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// HandleReplaceAudio handles PUT /api/episodes/{id}/audio: a multipart
+// upload with an "audio" file that replaces the episode's enclosure. The
+// episode keeps its ID and GUID so podcast apps treat it as the same
+// episode. The old file is deleted only once the store update commits.
+func (h *EpisodesHandler) HandleReplaceAudio(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/api/episodes/", "/audio")
+	if !ok {
+		http.Error(w, "Episode ID required", http.StatusBadRequest)
+		return
+	}
+
+	if findEpisode(h.store, episodeID) == nil {
+		http.Error(w, "Episode not found", http.StatusNotFound)
+		return
+	}
+
+	upload, ok := h.readUploadForm(w, r)
+	if !ok {
+		return
+	}
+	defer upload.discard()
+
+	format, ok := h.detectFormat(w, upload)
+	if !ok {
+		return
+	}
+
+	audioFile, err := storage.SaveAudioFile(upload.audioName, upload.audio, format, h.audio)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save audio file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Swap the file in one store step so edits made during the upload are
+	// kept and can't bring back the old file
+	var oldFilename string
+	ep, err := h.store.UpdateEpisodeFunc(episodeID, func(ep *models.Episode) error {
+		oldFilename = ep.Filename
+		ep.AudioURL = audioFile.URL
+		ep.AudioLength = audioFile.Size
+		ep.AudioType = audioFile.MimeType
+		ep.Duration = audioFile.Duration
+		ep.Bitrate = audioFile.Bitrate
+		ep.Filename = audioFile.Filename
+		ep.UploadDate = audioFile.UploadDate
+		return nil
+	})
+	if err != nil {
+		// The episode still points at the old file; drop the new one
+		h.audio.Delete(audioFile.Filename)
+		if errors.Is(err, storage.ErrEpisodeNotFound) {
+			http.Error(w, "Episode not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to update episode: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if oldFilename != "" && oldFilename != audioFile.Filename {
+		if err := storage.DeleteAudioFile(oldFilename, h.audio); err != nil {
+			log.Printf("Warning: Failed to delete replaced audio file %s: %v", oldFilename, err)
+		}
+	}
+
+	h.writeEpisode(w, r, ep)
+}
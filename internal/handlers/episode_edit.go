@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// episodePatch holds the editable episode fields; nil fields are unchanged.
+// An empty persons list, or a location or license without a name, removes
+// it. ID and GUID are deliberately absent: they must stay stable.
+type episodePatch struct {
+	Title       *string          `json:"title"`
+	Description *string          `json:"description"`
+	PubDate     *time.Time       `json:"pubDate"`
+	Explicit    *string          `json:"explicit"`
+	EpisodeNum  *int             `json:"episodeNum"`
+	SeasonNum   *int             `json:"seasonNum"`
+	EpisodeType *string          `json:"episodeType"`
+	Persons     *[]models.Person `json:"persons"`
+	Location    *models.Location `json:"location"`
+	License     *models.License  `json:"license"`
+}
+
+// HandleGet handles GET /api/episodes/{id}
+func (h *EpisodesHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/api/episodes/", "")
+	if !ok {
+		http.Error(w, "Episode ID required", http.StatusBadRequest)
+		return
+	}
+
+	ep := findEpisode(h.store, episodeID)
+	if ep == nil {
+		http.Error(w, "Episode not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ep)
+}
+
+// HandleUpdate handles PATCH and PUT /api/episodes/{id}. The body is either
+// JSON or form fields (from the dashboard's edit panel); only the fields
+// present are changed. HTMX requests get the re-rendered episode row back.
+func (h *EpisodesHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/api/episodes/", "")
+	if !ok {
+		http.Error(w, "Episode ID required", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxFormFieldBytes)
+	var patch episodePatch
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&patch)
+	} else if err = r.ParseForm(); err == nil {
+		patch, err = readEpisodePatch(r.PostForm)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid episode: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Apply the patch to the stored episode in one step so a concurrent
+	// audio replacement is not undone
+	var invalid error
+	ep, err := h.store.UpdateEpisodeFunc(episodeID, func(ep *models.Episode) error {
+		invalid = patch.apply(ep)
+		return invalid
+	})
+	switch {
+	case errors.Is(err, storage.ErrEpisodeNotFound):
+		http.Error(w, "Episode not found", http.StatusNotFound)
+		return
+	case invalid != nil:
+		http.Error(w, fmt.Sprintf("Invalid episode: %v", invalid), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Failed to update episode: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	h.writeEpisode(w, r, ep)
+}
+
+// HandlePublish handles POST /api/episodes/{id}/publish, releasing a
+// draft. A draft dated in the future is scheduled for that date; otherwise
+// it is published now, dated now so apps list it as the newest episode.
+func (h *EpisodesHandler) HandlePublish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/api/episodes/", "/publish")
+	if !ok {
+		http.Error(w, "Episode ID required", http.StatusBadRequest)
+		return
+	}
+
+	ep := findEpisode(h.store, episodeID)
+	if ep == nil {
+		http.Error(w, "Episode not found", http.StatusNotFound)
+		return
+	}
+	if ep.Status != models.StatusDraft {
+		http.Error(w, "Episode is not a draft", http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	if !ep.PubDate.After(now) {
+		ep.PubDate = now
+	}
+	ep.Status = models.ScheduleStatus(ep.PubDate, now)
+
+	if err := h.store.UpdateEpisode(*ep); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update episode: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	h.writeEpisode(w, r, findEpisode(h.store, episodeID))
+}
+
+// episodeRow is the template data of an episode's dashboard row
+type episodeRow struct {
+	models.Episode
+	showRoutes
+}
+
+// writeEpisode responds with an updated episode: its dashboard row for
+// HTMX requests, JSON otherwise
+func (h *EpisodesHandler) writeEpisode(w http.ResponseWriter, r *http.Request, ep *models.Episode) {
+	if r.Header.Get("HX-Request") == "true" && h.templates != nil {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := h.templates.ExecuteTemplate(w, "episode_row", episodeRow{Episode: *ep, showRoutes: h.routes}); err != nil {
+			log.Printf("Template error: %v", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ep)
+}
+
+// readEpisodePatch reads the edit panel's form fields
+func readEpisodePatch(form url.Values) (episodePatch, error) {
+	var patch episodePatch
+
+	text := func(key string) *string {
+		if _, ok := form[key]; !ok {
+			return nil
+		}
+		v := strings.TrimSpace(form.Get(key))
+		return &v
+	}
+	number := func(key string) (*int, error) {
+		s := text(key)
+		if s == nil {
+			return nil, nil
+		}
+		n := 0
+		if *s != "" {
+			var err error
+			if n, err = strconv.Atoi(*s); err != nil {
+				return nil, fmt.Errorf("%s %q must be a number", key, *s)
+			}
+		}
+		return &n, nil
+	}
+
+	patch.Title = text("title")
+	patch.Description = text("description")
+	patch.Explicit = text("explicit")
+	patch.EpisodeType = text("episodeType")
+
+	if s := text("pubDate"); s != nil {
+		pubDate, err := parseFormTime(*s)
+		if err != nil {
+			return patch, fmt.Errorf("publication date %q is invalid", *s)
+		}
+		patch.PubDate = &pubDate
+	}
+
+	// Podcasting 2.0 fields are validated when the patch is applied
+	if _, ok := form["personName"]; ok {
+		persons := personRows(form)
+		patch.Persons = &persons
+	}
+	if _, ok := form["locationName"]; ok {
+		patch.Location = &models.Location{Name: form.Get("locationName"), Geo: form.Get("locationGeo"), OSM: form.Get("locationOSM")}
+	}
+	if _, ok := form["licenseName"]; ok {
+		patch.License = &models.License{Name: form.Get("licenseName"), URL: form.Get("licenseURL")}
+	}
+
+	var err error
+	if patch.EpisodeNum, err = number("episodeNumber"); err != nil {
+		return patch, err
+	}
+	patch.SeasonNum, err = number("seasonNumber")
+	return patch, err
+}
+
+// apply validates the patch and writes it to ep
+func (p episodePatch) apply(ep *models.Episode) error {
+	if p.Title != nil {
+		if *p.Title == "" {
+			return fmt.Errorf("title is required")
+		}
+		ep.Title = *p.Title
+	}
+	if p.Description != nil {
+		if *p.Description == "" {
+			return fmt.Errorf("description is required")
+		}
+		ep.Description = *p.Description
+	}
+	if p.PubDate != nil {
+		if p.PubDate.IsZero() {
+			return fmt.Errorf("publication date is required")
+		}
+		ep.PubDate = *p.PubDate
+
+		// Moving the date reschedules the episode; drafts stay drafts
+		if ep.Status != models.StatusDraft {
+			ep.Status = models.ScheduleStatus(ep.PubDate, time.Now())
+		}
+	}
+	if p.Explicit != nil {
+		switch *p.Explicit {
+		case "", "yes", "no", "clean":
+			ep.Explicit = *p.Explicit
+		default:
+			return fmt.Errorf("explicit must be 'yes', 'no' or 'clean'")
+		}
+	}
+	if p.EpisodeType != nil {
+		switch *p.EpisodeType {
+		case "", "full", "trailer", "bonus":
+			ep.EpisodeType = *p.EpisodeType
+		default:
+			return fmt.Errorf("episode type must be 'full', 'trailer' or 'bonus'")
+		}
+	}
+	if p.EpisodeNum != nil {
+		if *p.EpisodeNum < 0 {
+			return fmt.Errorf("episode number must not be negative")
+		}
+		ep.EpisodeNum = *p.EpisodeNum
+	}
+	if p.SeasonNum != nil {
+		if *p.SeasonNum < 0 {
+			return fmt.Errorf("season number must not be negative")
+		}
+		ep.SeasonNum = *p.SeasonNum
+	}
+
+	var err error
+	if p.Persons != nil {
+		if ep.Persons, err = checkPersons(*p.Persons); err != nil {
+			return err
+		}
+	}
+	if p.Location != nil {
+		if ep.Location, err = checkLocation(*p.Location); err != nil {
+			return err
+		}
+	}
+	if p.License != nil {
+		if ep.License, err = checkLicense(*p.License); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,39 +2,81 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
-	"os"
+	"net/mail"
+	"net/url"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/example/rss-server/internal/media"
 	"github.com/example/rss-server/internal/models"
 	"github.com/example/rss-server/internal/storage"
 )
 
 // EpisodesHandler handles episode-related requests
 type EpisodesHandler struct {
-	store        *storage.RSSStore
-	audioDir     string
-	artworkDir   string
+	store        storage.Store
+	audio        storage.BlobStore
+	artwork      storage.BlobStore
 	maxSizeMB    int64
 	maxArtworkMB int64
+	allowedExts  []string
 	templates    *template.Template
+	routes       showRoutes
 }
 
-// NewEpisodesHandler creates a new episodes handler
-func NewEpisodesHandler(store *storage.RSSStore, audioDir string, artworkDir string, maxSizeMB int64, templates *template.Template) *EpisodesHandler {
+// NewEpisodesHandler creates a new episodes handler. Uploads are limited to
+// allowedExts (e.g. ".mp3"); an empty list allows every supported format.
+func NewEpisodesHandler(store storage.Store, audio storage.BlobStore, artwork storage.BlobStore, maxSizeMB int64, allowedExts []string, templates *template.Template) *EpisodesHandler {
+	if len(allowedExts) == 0 {
+		for _, f := range media.Formats {
+			allowedExts = append(allowedExts, f.Extensions...)
+		}
+	}
+
 	return &EpisodesHandler{
 		store:        store,
-		audioDir:     audioDir,
-		artworkDir:   artworkDir,
+		audio:        audio,
+		artwork:      artwork,
 		maxSizeMB:    maxSizeMB,
 		maxArtworkMB: 5, // 5MB limit for artwork
+		allowedExts:  allowedExts,
 		templates:    templates,
+		routes:       routesFor(storage.DefaultShow),
+	}
+}
+
+// SetShow makes the dashboard fragments link to the routes of the show with
+// the given slug rather than the default show's
+func (h *EpisodesHandler) SetShow(slug string) {
+	h.routes = routesFor(slug)
+}
+
+// maxFormFieldBytes bounds each non-file form field of an upload
+const maxFormFieldBytes = 1 << 20
+
+// episodeUpload is a parsed episode upload: form fields, the audio staged
+// on disk and optional artwork
+type episodeUpload struct {
+	fields      url.Values
+	audioName   string
+	audio       *storage.StagedFile
+	artworkName string
+	artworkData []byte
+}
+
+// discard removes the staged audio if it was not committed
+func (u *episodeUpload) discard() {
+	if u.audio != nil {
+		u.audio.Discard()
 	}
 }
 
@@ -45,70 +87,197 @@ func (h *EpisodesHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse multipart form (limit: maxSizeMB)
+	upload, ok := h.readUploadForm(w, r)
+	if !ok {
+		return
+	}
+	defer upload.discard()
+
+	h.createEpisode(w, upload)
+}
+
+// readUploadForm streams the multipart upload: the audio part goes straight
+// to a staged temp file with its size enforced as it is read, so uploads are
+// never held in memory. It writes an error response and returns false when
+// the form is invalid.
+func (h *EpisodesHandler) readUploadForm(w http.ResponseWriter, r *http.Request) (*episodeUpload, bool) {
 	maxSize := h.maxSizeMB * 1024 * 1024
-	if err := r.ParseMultipartForm(maxSize); err != nil {
+	maxArtwork := h.maxArtworkMB * 1024 * 1024
+
+	// Bound the whole body: audio, artwork and room for the text fields
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+maxArtwork+16*maxFormFieldBytes)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to parse form: %v", err), http.StatusBadRequest)
-		return
+		return nil, false
+	}
+
+	upload := &episodeUpload{fields: url.Values{}}
+	fail := func(msg string, status int) (*episodeUpload, bool) {
+		upload.discard()
+		http.Error(w, msg, status)
+		return nil, false
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fail(fmt.Sprintf("Failed to parse form: %v", err), http.StatusBadRequest)
+		}
+
+		switch name := part.FormName(); {
+		case name == "audio" && part.FileName() != "":
+			if upload.audio != nil {
+				return fail("Only one audio file may be uploaded", http.StatusBadRequest)
+			}
+
+			// Validate file extension before reading the body
+			if !h.extensionAllowed(filepath.Ext(part.FileName())) {
+				return fail(fmt.Sprintf("Unsupported file type (allowed: %s)", strings.Join(h.allowedExts, ", ")), http.StatusUnsupportedMediaType)
+			}
+
+			staged, err := storage.StageUpload(h.audio, part, maxSize)
+			var maxBytesErr *http.MaxBytesError
+			if err == storage.ErrTooLarge || errors.As(err, &maxBytesErr) {
+				return fail(fmt.Sprintf("File too large (max %d MB)", h.maxSizeMB), http.StatusRequestEntityTooLarge)
+			}
+			if err != nil {
+				return fail(fmt.Sprintf("Failed to read audio file: %v", err), http.StatusInternalServerError)
+			}
+			upload.audioName = part.FileName()
+			upload.audio = staged
+
+		case name == "artwork" && part.FileName() != "":
+			data, err := readArtworkPart(part, maxArtwork, h.maxArtworkMB)
+			if err != nil {
+				return fail(fmt.Sprintf("Invalid artwork: %v", err), http.StatusBadRequest)
+			}
+			upload.artworkName = part.FileName()
+			upload.artworkData = data
+
+		case part.FileName() == "":
+			value, err := io.ReadAll(io.LimitReader(part, maxFormFieldBytes+1))
+			if err != nil {
+				return fail(fmt.Sprintf("Failed to parse form: %v", err), http.StatusBadRequest)
+			}
+			if len(value) > maxFormFieldBytes {
+				return fail(fmt.Sprintf("Form field %q too large", name), http.StatusBadRequest)
+			}
+			upload.fields.Add(name, string(value))
+		}
+
+		part.Close()
+	}
+
+	if upload.audio == nil {
+		return fail("Audio file required", http.StatusBadRequest)
+	}
+
+	return upload, true
+}
+
+// readArtworkPart reads an uploaded JPG or PNG of at most maxSize bytes
+func readArtworkPart(part *multipart.Part, maxSize int64, maxSizeMB int64) ([]byte, error) {
+	// Validate image format (basic extension check)
+	ext := strings.ToLower(filepath.Ext(part.FileName()))
+	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
+		return nil, fmt.Errorf("artwork must be JPG or PNG")
 	}
 
-	// Get audio file
-	file, header, err := r.FormFile("audio")
+	data, err := io.ReadAll(io.LimitReader(part, maxSize+1))
 	if err != nil {
-		http.Error(w, "Audio file required", http.StatusBadRequest)
-		return
+		return nil, fmt.Errorf("failed to read artwork: %w", err)
 	}
-	defer file.Close()
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("artwork too large (max %d MB)", maxSizeMB)
+	}
+
+	return data, nil
+}
+
+// createEpisode stores a staged upload and adds the episode described by
+// its fields, falling back to the audio's tags for blank fields
+func (h *EpisodesHandler) createEpisode(w http.ResponseWriter, upload *episodeUpload) {
+	audio := upload.audio.Reader()
+	size := upload.audio.Size()
 
-	// Validate file extension (.mp3 only)
-	if !strings.HasSuffix(strings.ToLower(header.Filename), ".mp3") {
-		http.Error(w, "Only MP3 files are supported", http.StatusUnsupportedMediaType)
+	format, ok := h.detectFormat(w, upload)
+	if !ok {
 		return
 	}
 
-	// Validate file size
-	if header.Size > maxSize {
-		http.Error(w, fmt.Sprintf("File too large (max %d MB)", h.maxSizeMB), http.StatusRequestEntityTooLarge)
-		return
+	// Read ID3 tags to prefill fields the form leaves blank
+	tags, err := media.ReadTags(audio, size)
+	if err != nil {
+		log.Printf("Warning: Failed to read ID3 tags from %s: %v", upload.audioName, err)
+		tags = &media.Tags{}
 	}
 
-	// Get form fields
-	title := r.FormValue("title")
-	description := r.FormValue("description")
+	// Get form fields, falling back to the tags
+	title := upload.fields.Get("title")
+	if title == "" {
+		title = tags.Title
+	}
+	description := upload.fields.Get("description")
+	if description == "" {
+		description = tags.Comment
+	}
 
 	if title == "" || description == "" {
-		http.Error(w, "Title and description required", http.StatusBadRequest)
+		http.Error(w, "Title and description required (in the form or the file's ID3 tags)", http.StatusBadRequest)
 		return
 	}
 
-	// Read audio file data
-	audioData, err := io.ReadAll(file)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to read audio file: %v", err), http.StatusInternalServerError)
+	// Podcasting 2.0 metadata is validated before anything is stored
+	var extra models.Episode
+	if err := readEpisodeMetadata(upload.fields, &extra); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid episode metadata: %v", err), http.StatusBadRequest)
 		return
 	}
 
+	// Parse publication date (optional); a future date schedules the episode
+	pubDate := time.Now()
+	if pubDateStr := upload.fields.Get("pubDate"); pubDateStr != "" {
+		parsed, err := parseFormTime(pubDateStr)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid publication date %q", pubDateStr), http.StatusBadRequest)
+			return
+		}
+		pubDate = parsed
+	}
+
+	// Per-episode artwork: an uploaded image wins over embedded cover art
+	artworkName, artworkData := upload.artworkName, upload.artworkData
+	if artworkData == nil && tags.Picture != nil {
+		base := strings.TrimSuffix(upload.audioName, filepath.Ext(upload.audioName))
+		artworkName, artworkData = base+"-cover"+tags.Picture.Ext(), tags.Picture.Data
+	}
+
 	// Save audio file
-	audioFile, err := storage.SaveAudioFile(header.Filename, audioData, h.audioDir)
+	audioFile, err := storage.SaveAudioFile(upload.audioName, upload.audio, format, h.audio)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to save audio file: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Parse publication date (optional)
-	pubDate := time.Now()
-	if pubDateStr := r.FormValue("pubDate"); pubDateStr != "" {
-		if parsed, err := time.Parse(time.RFC3339, pubDateStr); err == nil {
-			pubDate = parsed
+	var imageURL string
+	if artworkData != nil {
+		artworkFilename, err := storage.SaveArtworkFile(artworkName, artworkData, h.artwork)
+		if err != nil {
+			h.audio.Delete(audioFile.Filename)
+			http.Error(w, fmt.Sprintf("Failed to save artwork: %v", err), http.StatusInternalServerError)
+			return
 		}
+		imageURL = h.artwork.URL(artworkFilename)
 	}
 
 	// Generate episode ID
 	episodeID := GenerateEpisodeID(title, pubDate)
 
-	// Build audio URL (relative to server)
-	audioURL := fmt.Sprintf("/audio/%s", audioFile.Filename)
-
 	// Create episode
 	episode := models.Episode{
 		ID:          episodeID,
@@ -116,30 +285,50 @@ func (h *EpisodesHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
 		Description: description,
 		PubDate:     pubDate,
 		GUID:        episodeID,
-		AudioURL:    audioURL,
+		Status:      models.ScheduleStatus(pubDate, time.Now()),
+		AudioURL:    audioFile.URL,
 		AudioLength: audioFile.Size,
-		AudioType:   "audio/mpeg",
+		AudioType:   audioFile.MimeType,
 		Duration:    audioFile.Duration,
-		Explicit:    r.FormValue("explicit"),
+		Explicit:    upload.fields.Get("explicit"),
+		ImageURL:    imageURL,
 		Filename:    audioFile.Filename,
+		Bitrate:     audioFile.Bitrate,
 		UploadDate:  audioFile.UploadDate,
+		Persons:     extra.Persons,
+		Location:    extra.Location,
+		License:     extra.License,
+	}
+
+	// Drafts stay out of the feed until published from the dashboard
+	if upload.fields.Get("draft") != "" {
+		episode.Status = models.StatusDraft
 	}
 
 	// Parse optional fields
-	if epNumStr := r.FormValue("episodeNumber"); epNumStr != "" {
+	if epNumStr := upload.fields.Get("episodeNumber"); epNumStr != "" {
 		fmt.Sscanf(epNumStr, "%d", &episode.EpisodeNum)
 	}
-	if seasonNumStr := r.FormValue("seasonNumber"); seasonNumStr != "" {
+	if seasonNumStr := upload.fields.Get("seasonNumber"); seasonNumStr != "" {
 		fmt.Sscanf(seasonNumStr, "%d", &episode.SeasonNum)
 	}
-	if epType := r.FormValue("episodeType"); epType != "" {
+	if epType := upload.fields.Get("episodeType"); epType != "" {
 		episode.EpisodeType = epType
 	}
+	if episode.EpisodeNum == 0 {
+		episode.EpisodeNum = tags.Track
+	}
+	if len(tags.Chapters) > 0 {
+		episode.Chapters = h.importChapters(tags.Chapters, strings.TrimSuffix(upload.audioName, filepath.Ext(upload.audioName)))
+	}
 
 	// Add episode to store
 	if err := h.store.AddEpisode(episode); err != nil {
-		// Cleanup: delete audio file if episode creation fails
-		os.Remove(filepath.Join(h.audioDir, audioFile.Filename))
+		// Cleanup: delete the audio and artwork if episode creation fails
+		h.audio.Delete(audioFile.Filename)
+		for _, name := range episodeArtwork(&episode, h.artwork) {
+			h.artwork.Delete(name)
+		}
 		http.Error(w, fmt.Sprintf("Failed to add episode: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -150,6 +339,61 @@ func (h *EpisodesHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(episode)
 }
 
+// episodeArtwork returns the names of the artwork blobs holding an
+// episode's image and chapter images. Images hosted elsewhere are skipped.
+func episodeArtwork(ep *models.Episode, artwork storage.BlobStore) []string {
+	var names []string
+	if name, ok := storage.BlobName(artwork, ep.ImageURL); ok {
+		names = append(names, name)
+	}
+	for _, ch := range ep.Chapters {
+		if name, ok := storage.BlobName(artwork, ch.Img); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// detectFormat identifies the uploaded audio by its magic bytes; the file
+// name is not trusted. It writes an error response and returns false for
+// streams that are not recognised or formats that are not allowed.
+func (h *EpisodesHandler) detectFormat(w http.ResponseWriter, upload *episodeUpload) (*media.Format, bool) {
+	format, err := media.Detect(upload.audio.Reader())
+	if err == media.ErrUnknownFormat {
+		http.Error(w, fmt.Sprintf("Unrecognised audio format (allowed: %s)", strings.Join(h.allowedExts, ", ")), http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read audio file: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+	if !h.formatAllowed(format) {
+		http.Error(w, fmt.Sprintf("Unsupported audio format (allowed: %s)", strings.Join(h.allowedExts, ", ")), http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+	return format, true
+}
+
+// extensionAllowed reports whether uploads with the extension are accepted
+func (h *EpisodesHandler) extensionAllowed(ext string) bool {
+	for _, allowed := range h.allowedExts {
+		if strings.EqualFold(ext, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatAllowed reports whether any of the format's extensions is accepted
+func (h *EpisodesHandler) formatAllowed(format *media.Format) bool {
+	for _, ext := range format.Extensions {
+		if h.extensionAllowed(ext) {
+			return true
+		}
+	}
+	return false
+}
+
 // HandleList handles GET /api/episodes
 func (h *EpisodesHandler) HandleList(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -182,57 +426,11 @@ func GenerateEpisodeID(title string, pubDate time.Time) string {
 	return id
 }
 
-// HandleDelete handles DELETE /api/episodes/{id}
-func (h *EpisodesHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract episode ID from URL path
-	// Expected format: /api/episodes/{episodeId}
-	path := r.URL.Path
-	episodeID := strings.TrimPrefix(path, "/api/episodes/")
-
-	if episodeID == "" || episodeID == path {
-		http.Error(w, "Episode ID required", http.StatusBadRequest)
-		return
-	}
-
-	// Get episode to find the audio filename before deleting
-	podcast := h.store.GetPodcast()
-	var audioFilename string
-	found := false
-
-	for _, ep := range podcast.Episodes {
-		if ep.ID == episodeID {
-			audioFilename = ep.Filename
-			found = true
-			break
-		}
-	}
-
-	if !found {
-		http.Error(w, "Episode not found", http.StatusNotFound)
-		return
-	}
-
-	// Delete episode from RSS store first
-	if err := h.store.DeleteEpisode(episodeID); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to delete episode: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	// Delete audio file from filesystem
-	if audioFilename != "" {
-		if err := storage.DeleteAudioFile(audioFilename, h.audioDir); err != nil {
-			// Log error but don't fail the request since episode is already removed from RSS
-			fmt.Printf("Warning: Failed to delete audio file %s: %v\n", audioFilename, err)
-		}
-	}
-
-	// Return success (for HTMX - empty response to remove element)
-	w.WriteHeader(http.StatusOK)
+// settingsView is the settings form's template data
+type settingsView struct {
+	*models.Podcast
+	showRoutes
+	Categories []models.ITunesCategory
 }
 
 // HandleGetSettings handles GET /api/podcast/settings
@@ -242,11 +440,15 @@ func (h *EpisodesHandler) HandleGetSettings(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	podcast := h.store.GetPodcast()
+	view := settingsView{
+		Podcast:    h.store.GetPodcast(),
+		showRoutes: h.routes,
+		Categories: models.ITunesCategories,
+	}
 
 	// Render settings form template
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := h.templates.ExecuteTemplate(w, "settings_form.html", podcast); err != nil {
+	if err := h.templates.ExecuteTemplate(w, "settings_form.html", view); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to render template: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -280,6 +482,15 @@ func (h *EpisodesHandler) HandleUpdateSettings(w http.ResponseWriter, r *http.Re
 		Summary:     r.FormValue("summary"),
 		Explicit:    r.FormValue("explicit"),
 		Category:    r.FormValue("category"),
+		Subcategory: r.FormValue("subcategory"),
+		OwnerName:   strings.TrimSpace(r.FormValue("ownerName")),
+		OwnerEmail:  strings.TrimSpace(r.FormValue("ownerEmail")),
+		Type:        r.FormValue("type"),
+		Copyright:   r.FormValue("copyright"),
+		Block:       r.FormValue("block") != "",
+		Complete:    r.FormValue("complete") != "",
+		NewFeedURL:  strings.TrimSpace(r.FormValue("newFeedURL")),
+		GUID:        currentPodcast.GUID,
 		PubDate:     currentPodcast.PubDate,
 		ImageURL:    currentPodcast.ImageURL, // Keep existing unless new artwork uploaded
 		Episodes:    currentPodcast.Episodes, // Preserve episodes
@@ -303,6 +514,34 @@ func (h *EpisodesHandler) HandleUpdateSettings(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if !models.ValidSubcategory(podcast.Category, podcast.Subcategory) {
+		http.Error(w, fmt.Sprintf("Subcategory %q does not belong to category %q", podcast.Subcategory, podcast.Category), http.StatusBadRequest)
+		return
+	}
+
+	if podcast.Type != "" && podcast.Type != "episodic" && podcast.Type != "serial" {
+		http.Error(w, "Type must be 'episodic' or 'serial'", http.StatusBadRequest)
+		return
+	}
+
+	// Directories email the owner address to verify the show
+	if podcast.OwnerEmail != "" {
+		if addr, err := mail.ParseAddress(podcast.OwnerEmail); err != nil || addr.Address != podcast.OwnerEmail {
+			http.Error(w, "Owner email must be a valid email address", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if podcast.NewFeedURL != "" && !strings.HasPrefix(podcast.NewFeedURL, "http://") && !strings.HasPrefix(podcast.NewFeedURL, "https://") {
+		http.Error(w, "New feed URL must be a valid HTTP(S) URL", http.StatusBadRequest)
+		return
+	}
+
+	if err := readPodcastMetadata(r.Form, podcast); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid podcast metadata: %v", err), http.StatusBadRequest)
+		return
+	}
+
 	// Handle artwork upload if provided
 	if file, header, err := r.FormFile("artwork"); err == nil {
 		defer file.Close()
@@ -328,14 +567,14 @@ func (h *EpisodesHandler) HandleUpdateSettings(w http.ResponseWriter, r *http.Re
 		}
 
 		// Save artwork file
-		artworkFilename, err := storage.SaveArtworkFile(header.Filename, artworkData, h.artworkDir)
+		artworkFilename, err := storage.SaveArtworkFile(header.Filename, artworkData, h.artwork)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to save artwork: %v", err), http.StatusInternalServerError)
 			return
 		}
 
 		// Update image URL
-		podcast.ImageURL = fmt.Sprintf("/static/artwork/%s", artworkFilename)
+		podcast.ImageURL = h.artwork.URL(artworkFilename)
 	}
 
 	// Update podcast settings
@@ -346,5 +585,5 @@ func (h *EpisodesHandler) HandleUpdateSettings(w http.ResponseWriter, r *http.Re
 
 	// Return success message (for HTMX)
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Write([]byte(`<div class="success-message">Settings saved successfully! <a href="/">Back to Dashboard</a></div>`))
+	fmt.Fprintf(w, `<div class="success-message">Settings saved successfully! <a href="%s">Back to Dashboard</a></div>`, template.HTMLEscapeString(h.routes.HomePath))
 }
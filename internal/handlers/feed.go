@@ -1,34 +1,188 @@
 package handlers
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/example/rss-server/internal/rss"
 	"github.com/example/rss-server/internal/storage"
 )
 
-// FeedHandler handles RSS feed requests
+// rssType is the Content-Type of the RSS feeds
+const rssType = "application/rss+xml; charset=utf-8"
+
+// FeedHandler handles RSS, Atom and JSON feed requests. Each feed is
+// rendered once per store revision and served from memory until the store
+// changes.
 type FeedHandler struct {
-	store *storage.RSSStore
+	store   storage.Store
+	baseURL string
+
+	// pageSize splits /feed.xml into RFC 5005 pages; maxEpisodes caps it
+	// to the newest episodes. The archive feed always lists every episode.
+	pageSize    int
+	maxEpisodes int
+
+	// hubs are the WebSub hubs announced by the feeds
+	hubs []string
+
+	rss     feedCache
+	archive feedCache
+	atom    feedCache
+	json    feedCache
 }
 
 // NewFeedHandler creates a new feed handler
-func NewFeedHandler(store *storage.RSSStore) *FeedHandler {
-	return &FeedHandler{store: store}
+func NewFeedHandler(store storage.Store, baseURL string) *FeedHandler {
+	return &FeedHandler{store: store, baseURL: baseURL}
+}
+
+// SetPaging splits /feed.xml into pages of pageSize episodes, or caps it to
+// the newest maxEpisodes episodes. Zero disables either. A paged feed
+// already reaches every episode, so maxEpisodes is ignored when pageSize is
+// set; the configuration rejects setting both.
+func (h *FeedHandler) SetPaging(pageSize, maxEpisodes int) {
+	h.pageSize = pageSize
+	h.maxEpisodes = maxEpisodes
 }
 
-// HandleFeed handles GET /feed.xml
+// SetHubs announces hubs as the WebSub hubs of the RSS, Atom, JSON and
+// archive feeds
+func (h *FeedHandler) SetHubs(hubs []string) {
+	h.hubs = hubs
+}
+
+// HandleFeed handles GET /feed.xml and, when paging is enabled, GET
+// /feed.xml?page={n}
 func (h *FeedHandler) HandleFeed(w http.ResponseWriter, r *http.Request) {
+	page := 1
+	if v := r.URL.Query().Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid page number", http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+
+	var render func() ([]byte, error)
+	switch {
+	case h.pageSize > 0:
+		render = func() ([]byte, error) {
+			return rss.GeneratePagedFeed(h.store.GetPodcast(), h.baseURL, page, h.pageSize, h.hubs...)
+		}
+	case page > 1:
+		http.Error(w, "Page not found", http.StatusNotFound)
+		return
+	default:
+		render = func() ([]byte, error) {
+			return rss.GenerateLatestFeed(h.store.GetPodcast(), h.baseURL, h.maxEpisodes, h.hubs...)
+		}
+	}
+
+	h.serveFeed(w, r, &h.rss, strconv.Itoa(page), rssType, "Failed to generate RSS feed", render)
+}
+
+// HandleArchive handles GET /feed-archive.xml, the complete feed of every
+// published episode
+func (h *FeedHandler) HandleArchive(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, &h.archive, "", rssType, "Failed to generate RSS feed", func() ([]byte, error) {
+		return rss.GenerateArchiveFeed(h.store.GetPodcast(), h.baseURL, h.hubs...)
+	})
+}
+
+// HandleAtom handles GET /feed.atom
+func (h *FeedHandler) HandleAtom(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, &h.atom, "", rss.AtomType+"; charset=utf-8", "Failed to generate Atom feed", func() ([]byte, error) {
+		return rss.GenerateAtom(h.store.GetPodcast(), h.baseURL, h.hubs...)
+	})
+}
+
+// HandleJSONFeed handles GET /feed.json
+func (h *FeedHandler) HandleJSONFeed(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, &h.json, "", rss.JSONFeedType+"; charset=utf-8", "Failed to generate JSON feed", func() ([]byte, error) {
+		return rss.GenerateJSONFeed(h.store.GetPodcast(), h.baseURL, h.hubs...)
+	})
+}
+
+// serveFeed serves a feed from its cache, rendering it first if the store
+// changed since it was cached
+func (h *FeedHandler) serveFeed(w http.ResponseWriter, r *http.Request, cache *feedCache, key string, contentType string, failure string, render func() ([]byte, error)) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	feed, err := cache.get(h.store, key, render)
+	if errors.Is(err, rss.ErrPageNotFound) {
+		http.Error(w, "Page not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+
+	feed.serve(w, r, contentType)
+}
+
+// PreviewFeedHandler serves the token-protected preview feed, which
+// includes draft and scheduled episodes
+type PreviewFeedHandler struct {
+	store   storage.Store
+	baseURL string
+	token   string
+}
+
+// NewPreviewFeedHandler creates a preview feed handler. An empty token
+// disables the preview feed.
+func NewPreviewFeedHandler(store storage.Store, baseURL string, token string) *PreviewFeedHandler {
+	return &PreviewFeedHandler{store: store, baseURL: baseURL, token: token}
+}
+
+// HandlePreviewFeed handles GET /feed-preview.xml?token={token}. The token
+// may also be sent as a bearer token.
+func (h *PreviewFeedHandler) HandlePreviewFeed(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	xmlData, err := h.store.ServeXML()
+	if h.token == "" {
+		http.Error(w, "Preview feed is not enabled", http.StatusNotFound)
+		return
+	}
+
+	if !hasPreviewToken(r, h.token) {
+		http.Error(w, "Invalid preview token", http.StatusUnauthorized)
+		return
+	}
+
+	xmlData, err := rss.GeneratePreviewFeed(h.store.GetPodcast(), h.baseURL, h.token)
 	if err != nil {
 		http.Error(w, "Failed to generate RSS feed", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	// Unreleased episodes must not end up in shared caches or search engines
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.Header().Set("Content-Type", rssType)
 	w.Write(xmlData)
 }
+
+// hasPreviewToken reports whether r carries the preview token, as the token
+// query parameter or a bearer token. No request matches an empty token.
+func hasPreviewToken(r *http.Request, previewToken string) bool {
+	if previewToken == "" {
+		return false
+	}
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(previewToken)) == 1
+}
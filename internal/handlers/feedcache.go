@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/example/rss-server/internal/storage"
+)
+
+// feedCacheControl lets podcast apps and shared caches reuse a feed for a
+// few minutes before revalidating it with its ETag
+const feedCacheControl = "public, max-age=300"
+
+// renderedFeed is a feed rendered at one store revision, together with its
+// compressed variants. It is never modified once cached.
+type renderedFeed struct {
+	revision uint64
+	etag     string
+	modTime  time.Time
+	body     []byte
+	gzip     []byte
+	brotli   []byte
+}
+
+// feedCache keeps the latest rendering of one feed, or of each page of a
+// paged feed, so polling podcast apps are answered without regenerating
+// and recompressing it
+type feedCache struct {
+	mu      sync.Mutex
+	feeds   map[string]*renderedFeed
+	pending map[string]*pendingFeed
+}
+
+// pendingFeed is a rendering in progress. Requests for the same key and
+// revision wait for it instead of rendering the feed again.
+type pendingFeed struct {
+	revision uint64
+	done     chan struct{}
+	feed     *renderedFeed
+	err      error
+}
+
+// get returns the feed (or page) cached under key rendered at the store's
+// current revision, rendering it if the store changed since it was cached.
+// A rendering identical to the cached one keeps its ETag and modification
+// time. Rendering and compression run outside the lock, so a slow feed
+// never holds up requests for the other feeds and pages.
+func (c *feedCache) get(store storage.Store, key string, render func() ([]byte, error)) (*renderedFeed, error) {
+	revision := store.Revision()
+
+	c.mu.Lock()
+	cached := c.feeds[key]
+	if cached != nil && cached.revision == revision {
+		c.mu.Unlock()
+		return cached, nil
+	}
+	if p := c.pending[key]; p != nil && p.revision == revision {
+		c.mu.Unlock()
+		<-p.done
+		return p.feed, p.err
+	}
+	p := &pendingFeed{revision: revision, done: make(chan struct{})}
+	if c.pending == nil {
+		c.pending = make(map[string]*pendingFeed)
+	}
+	c.pending[key] = p
+	c.mu.Unlock()
+
+	p.feed, p.err = renderFeed(revision, cached, render)
+
+	c.mu.Lock()
+	if c.pending[key] == p {
+		delete(c.pending, key)
+	}
+	if p.err == nil {
+		c.store(key, p.feed)
+	}
+	c.mu.Unlock()
+	close(p.done)
+	return p.feed, p.err
+}
+
+// store caches feed under key unless a newer revision was cached while it
+// was being rendered. The caller must hold c.mu.
+func (c *feedCache) store(key string, feed *renderedFeed) {
+	if current := c.feeds[key]; current != nil && current.revision > feed.revision {
+		return
+	}
+
+	// Pages of older revisions are dropped rather than kept until requested
+	for k, f := range c.feeds {
+		if f.revision != feed.revision && k != key {
+			delete(c.feeds, k)
+		}
+	}
+	if c.feeds == nil {
+		c.feeds = make(map[string]*renderedFeed)
+	}
+	c.feeds[key] = feed
+}
+
+// renderFeed renders and compresses a feed at revision, reusing the
+// compressed variants of the previous rendering when nothing changed
+func renderFeed(revision uint64, previous *renderedFeed, render func() ([]byte, error)) (*renderedFeed, error) {
+	body, err := render()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(body)
+	etag := hex.EncodeToString(sum[:16])
+	if previous != nil && previous.etag == etag {
+		unchanged := *previous
+		unchanged.revision = revision
+		return &unchanged, nil
+	}
+
+	feed := &renderedFeed{
+		revision: revision,
+		etag:     etag,
+		modTime:  time.Now().UTC().Truncate(time.Second),
+		body:     body,
+	}
+	if feed.gzip, err = compressGzip(body); err != nil {
+		return nil, err
+	}
+	if feed.brotli, err = compressBrotli(body); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// serve writes the feed in the encoding preferred by the client. Requests
+// carrying If-None-Match or If-Modified-Since are answered with 304 Not
+// Modified when the feed has not changed.
+func (f *renderedFeed) serve(w http.ResponseWriter, r *http.Request, contentType string) {
+	body, etag := f.body, f.etag
+	switch encoding := negotiateEncoding(r.Header.Get("Accept-Encoding")); encoding {
+	case "br":
+		body, etag = f.brotli, etag+"-br"
+		w.Header().Set("Content-Encoding", encoding)
+	case "gzip":
+		body, etag = f.gzip, etag+"-gz"
+		w.Header().Set("Content-Encoding", encoding)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", feedCacheControl)
+	w.Header().Set("Vary", "Accept-Encoding")
+	w.Header().Set("ETag", `"`+etag+`"`)
+	http.ServeContent(w, r, "", f.modTime, bytes.NewReader(body))
+}
+
+// negotiateEncoding picks the feed encoding for an Accept-Encoding header:
+// "br" or "gzip", whichever the client weighs higher (brotli on a tie), or
+// "" to send the feed uncompressed
+func negotiateEncoding(header string) string {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "x-gzip" {
+			name = "gzip"
+		}
+
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil {
+				weight = v
+			}
+		}
+		weights[name] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, encoding := range []string{"br", "gzip"} {
+		weight, ok := weights[encoding]
+		if !ok {
+			weight = weights["*"]
+		}
+		if weight > bestWeight {
+			best, bestWeight = encoding, weight
+		}
+	}
+	return best
+}
+
+// compressGzip returns the gzip-compressed body
+func compressGzip(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to gzip feed: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to gzip feed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// compressBrotli returns the brotli-compressed body
+func compressBrotli(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	bw := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+	if _, err := bw.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to brotli-compress feed: %w", err)
+	}
+	if err := bw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to brotli-compress feed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
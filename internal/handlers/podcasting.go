@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/rss-server/internal/media"
+	"github.com/example/rss-server/internal/models"
+)
+
+// Podcasting 2.0 metadata is edited as form fields. Repeatable elements
+// (funding, people, trailers) are sent as parallel repeated fields, one
+// value per row; rows with an empty key field are ignored.
+
+// maxPodcastTextLen is the spec's limit on podcast: element text
+const maxPodcastTextLen = 128
+
+var (
+	geoPattern  = regexp.MustCompile(`^geo:-?\d+(\.\d+)?,-?\d+(\.\d+)?(,-?\d+(\.\d+)?)?(;u=\d+(\.\d+)?)?$`)
+	osmPattern  = regexp.MustCompile(`^[NWR]\d+(#\d+)?$`)
+	uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+)
+
+// readPodcastMetadata reads the show-level Podcasting 2.0 fields into p
+func readPodcastMetadata(form url.Values, p *models.Podcast) error {
+	// The GUID must never change once published, so an empty field keeps it
+	if guid := strings.ToLower(strings.TrimSpace(form.Get("guid"))); guid != "" {
+		if !uuidPattern.MatchString(guid) {
+			return fmt.Errorf("GUID %q must be a UUID", guid)
+		}
+		p.GUID = guid
+	}
+	p.Locked = form.Get("locked") != ""
+
+	var err error
+	if p.Funding, err = readFunding(form); err != nil {
+		return err
+	}
+	if p.Persons, err = readPersons(form); err != nil {
+		return err
+	}
+	if p.Location, err = readLocation(form); err != nil {
+		return err
+	}
+	if p.Trailers, err = readTrailers(form); err != nil {
+		return err
+	}
+	p.License, err = readLicense(form)
+	return err
+}
+
+// readEpisodeMetadata reads the episode-level Podcasting 2.0 fields into ep
+func readEpisodeMetadata(form url.Values, ep *models.Episode) error {
+	var err error
+	if ep.Persons, err = readPersons(form); err != nil {
+		return err
+	}
+	if ep.Location, err = readLocation(form); err != nil {
+		return err
+	}
+	ep.License, err = readLicense(form)
+	return err
+}
+
+// formRows returns the i-th value of each key, padding missing values
+func formRows(form url.Values, keys ...string) [][]string {
+	n := 0
+	for _, k := range keys {
+		n = max(n, len(form[k]))
+	}
+
+	rows := make([][]string, n)
+	for i := range rows {
+		rows[i] = make([]string, len(keys))
+		for j, k := range keys {
+			if i < len(form[k]) {
+				rows[i][j] = strings.TrimSpace(form[k][i])
+			}
+		}
+	}
+	return rows
+}
+
+// isHTTPURL reports whether s is an absolute HTTP(S) URL
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// checkText enforces the spec's length limit on element text
+func checkText(field, s string) error {
+	if len(s) > maxPodcastTextLen {
+		return fmt.Errorf("%s must be at most %d characters", field, maxPodcastTextLen)
+	}
+	return nil
+}
+
+// readFunding reads fundingURL/fundingLabel rows
+func readFunding(form url.Values) ([]models.Funding, error) {
+	var funding []models.Funding
+	for _, row := range formRows(form, "fundingURL", "fundingLabel") {
+		if row[0] == "" {
+			continue
+		}
+		if !isHTTPURL(row[0]) {
+			return nil, fmt.Errorf("funding URL %q must be a valid HTTP(S) URL", row[0])
+		}
+		if err := checkText("funding label", row[1]); err != nil {
+			return nil, err
+		}
+		funding = append(funding, models.Funding{URL: row[0], Label: row[1]})
+	}
+	return funding, nil
+}
+
+// readPersons reads personName/personRole/personGroup/personImg/personHref rows
+func readPersons(form url.Values) ([]models.Person, error) {
+	return checkPersons(personRows(form))
+}
+
+// personRows returns the person rows of a form as sent
+func personRows(form url.Values) []models.Person {
+	persons := []models.Person{}
+	for _, row := range formRows(form, "personName", "personRole", "personGroup", "personImg", "personHref") {
+		persons = append(persons, models.Person{Name: row[0], Role: row[1], Group: row[2], Img: row[3], Href: row[4]})
+	}
+	return persons
+}
+
+// checkPersons validates and normalizes people, dropping those without a name
+func checkPersons(in []models.Person) ([]models.Person, error) {
+	var persons []models.Person
+	for _, p := range in {
+		p.Name = strings.TrimSpace(p.Name)
+		if p.Name == "" {
+			continue
+		}
+		if err := checkText("person name", p.Name); err != nil {
+			return nil, err
+		}
+		for _, u := range []string{p.Img, p.Href} {
+			if u != "" && !isHTTPURL(u) {
+				return nil, fmt.Errorf("person URL %q must be a valid HTTP(S) URL", u)
+			}
+		}
+		p.Role = strings.ToLower(strings.TrimSpace(p.Role))
+		p.Group = strings.ToLower(strings.TrimSpace(p.Group))
+		persons = append(persons, p)
+	}
+	return persons, nil
+}
+
+// readLocation reads locationName/locationGeo/locationOSM
+func readLocation(form url.Values) (*models.Location, error) {
+	return checkLocation(models.Location{
+		Name: form.Get("locationName"),
+		Geo:  form.Get("locationGeo"),
+		OSM:  form.Get("locationOSM"),
+	})
+}
+
+// checkLocation validates a location, returning nil for one without a name
+func checkLocation(loc models.Location) (*models.Location, error) {
+	loc.Name = strings.TrimSpace(loc.Name)
+	loc.Geo = strings.TrimSpace(loc.Geo)
+	loc.OSM = strings.TrimSpace(loc.OSM)
+	if loc.Name == "" {
+		if loc.Geo != "" || loc.OSM != "" {
+			return nil, fmt.Errorf("location name is required")
+		}
+		return nil, nil
+	}
+	if err := checkText("location name", loc.Name); err != nil {
+		return nil, err
+	}
+	if loc.Geo != "" && !geoPattern.MatchString(loc.Geo) {
+		return nil, fmt.Errorf("location geo %q must be a geo URI (e.g. 'geo:30.2672,97.7431')", loc.Geo)
+	}
+	if loc.OSM != "" && !osmPattern.MatchString(loc.OSM) {
+		return nil, fmt.Errorf("location OSM %q must be an OpenStreetMap object (e.g. 'R113314')", loc.OSM)
+	}
+	return &loc, nil
+}
+
+// readLicense reads licenseName/licenseURL
+func readLicense(form url.Values) (*models.License, error) {
+	return checkLicense(models.License{
+		Name: form.Get("licenseName"),
+		URL:  form.Get("licenseURL"),
+	})
+}
+
+// checkLicense validates a license, returning nil for one without a name
+func checkLicense(lic models.License) (*models.License, error) {
+	lic.Name = strings.TrimSpace(lic.Name)
+	lic.URL = strings.TrimSpace(lic.URL)
+	if lic.Name == "" {
+		if lic.URL != "" {
+			return nil, fmt.Errorf("license name is required")
+		}
+		return nil, nil
+	}
+	if err := checkText("license name", lic.Name); err != nil {
+		return nil, err
+	}
+	if lic.URL != "" && !isHTTPURL(lic.URL) {
+		return nil, fmt.Errorf("license URL %q must be a valid HTTP(S) URL", lic.URL)
+	}
+	return &lic, nil
+}
+
+// readTrailers reads trailerTitle/trailerURL/trailerPubDate/trailerLength/
+// trailerType/trailerSeason rows. The type defaults to the one implied by
+// the URL's extension.
+func readTrailers(form url.Values) ([]models.Trailer, error) {
+	var trailers []models.Trailer
+	for _, row := range formRows(form, "trailerTitle", "trailerURL", "trailerPubDate", "trailerLength", "trailerType", "trailerSeason") {
+		if row[1] == "" {
+			continue
+		}
+		t := models.Trailer{Title: row[0], URL: row[1], Type: row[4]}
+		if t.Title == "" {
+			return nil, fmt.Errorf("trailer title is required")
+		}
+		if err := checkText("trailer title", t.Title); err != nil {
+			return nil, err
+		}
+		if !isHTTPURL(t.URL) && !strings.HasPrefix(t.URL, "/") {
+			return nil, fmt.Errorf("trailer URL %q must be a valid HTTP(S) URL or an absolute path", t.URL)
+		}
+
+		pubDate, err := parseFormTime(row[2])
+		if err != nil {
+			return nil, fmt.Errorf("trailer publication date %q is invalid", row[2])
+		}
+		t.PubDate = pubDate
+
+		if row[3] != "" {
+			if t.Length, err = strconv.ParseInt(row[3], 10, 64); err != nil || t.Length < 0 {
+				return nil, fmt.Errorf("trailer length %q must be a byte count", row[3])
+			}
+		}
+		if row[5] != "" {
+			if t.Season, err = strconv.Atoi(row[5]); err != nil || t.Season < 0 {
+				return nil, fmt.Errorf("trailer season %q must be a number", row[5])
+			}
+		}
+		if t.Type == "" {
+			if u, err := url.Parse(t.URL); err == nil {
+				t.Type = media.ContentType(u.Path)
+			}
+		}
+
+		trailers = append(trailers, t)
+	}
+	return trailers, nil
+}
+
+// parseFormTime accepts RFC 3339 or the datetime-local input format
+func parseFormTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04", s, time.Local)
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/example/rss-server/internal/storage"
+)
+
+// ShowMounter builds the handler serving one show's routes, laid out as
+// they are for the default show at the root (/feed.xml, /api/episodes, ...)
+type ShowMounter func(slug string, store storage.Store) (http.Handler, error)
+
+// ShowsHandler serves every show hosted by the server. The default show is
+// served at the root; every show, including the default one, is also
+// served under /shows/{slug}/ and /api/shows/{slug}/, which are mapped
+// onto the show's root routes.
+type ShowsHandler struct {
+	shows   *storage.Shows
+	baseURL string
+	mount   ShowMounter
+
+	mu      sync.RWMutex
+	mounted map[string]http.Handler
+}
+
+// NewShowsHandler creates a shows handler. Call MountShows to mount the
+// existing shows; shows created through the API are mounted as they are
+// created.
+func NewShowsHandler(shows *storage.Shows, baseURL string, mount ShowMounter) *ShowsHandler {
+	return &ShowsHandler{
+		shows:   shows,
+		baseURL: baseURL,
+		mount:   mount,
+		mounted: make(map[string]http.Handler),
+	}
+}
+
+// MountShows mounts every show known to the store manager
+func (h *ShowsHandler) MountShows() error {
+	for _, slug := range h.shows.Slugs() {
+		store, _ := h.shows.Get(slug)
+		if err := h.mountShow(slug, store); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ShowBaseURL returns the base URL a show's routes are served under
+func ShowBaseURL(baseURL, slug string) string {
+	if slug == storage.DefaultShow {
+		return baseURL
+	}
+	return baseURL + "/shows/" + slug
+}
+
+// showPath returns the path of a show's dashboard
+func showPath(slug string) string {
+	if slug == storage.DefaultShow {
+		return "/"
+	}
+	return "/shows/" + slug + "/"
+}
+
+// showRoutes holds the paths a show's dashboard pages link to
+type showRoutes struct {
+	HomePath string // the show's dashboard
+	APIBase  string // prefix of the show's API routes, ending in a slash
+}
+
+// routesFor returns the dashboard and API paths of a show
+func routesFor(slug string) showRoutes {
+	if slug == storage.DefaultShow {
+		return showRoutes{HomePath: "/", APIBase: "/api/"}
+	}
+	return showRoutes{HomePath: showPath(slug), APIBase: "/api/shows/" + slug + "/"}
+}
+
+// showInfo describes a show in the shows API
+type showInfo struct {
+	Slug      string `json:"slug"`
+	Title     string `json:"title"`
+	FeedURL   string `json:"feedURL"`
+	Dashboard string `json:"dashboard"`
+	Episodes  int    `json:"episodes"`
+}
+
+// info describes the named show
+func (h *ShowsHandler) info(slug string, store storage.Store) showInfo {
+	p := store.GetPodcast()
+	return showInfo{
+		Slug:      slug,
+		Title:     p.Title,
+		FeedURL:   ShowBaseURL(h.baseURL, slug) + "/feed.xml",
+		Dashboard: showPath(slug),
+		Episodes:  len(p.Episodes),
+	}
+}
+
+// list describes every show, the default show first
+func (h *ShowsHandler) list() []showInfo {
+	shows := []showInfo{}
+	for _, slug := range h.shows.Slugs() {
+		if store, ok := h.shows.Get(slug); ok {
+			shows = append(shows, h.info(slug, store))
+		}
+	}
+	return shows
+}
+
+// HandleShows handles GET /api/shows, listing the shows, and POST
+// /api/shows, creating one from the slug and title fields
+func (h *ShowsHandler) HandleShows(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(h.list())
+	case http.MethodPost:
+		h.handleCreate(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCreate creates and mounts a new show
+func (h *ShowsHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Slug  string `json:"slug"`
+		Title string `json:"title"`
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid show: %v", err), http.StatusBadRequest)
+			return
+		}
+	} else {
+		body.Slug = r.FormValue("slug")
+		body.Title = r.FormValue("title")
+	}
+	slug := strings.ToLower(strings.TrimSpace(body.Slug))
+	title := strings.TrimSpace(body.Title)
+
+	if !storage.ValidShowSlug(slug) {
+		http.Error(w, "Show slug must be 1-63 lowercase letters, digits or dashes", http.StatusBadRequest)
+		return
+	}
+
+	store, err := h.shows.Create(slug)
+	if errors.Is(err, storage.ErrShowExists) {
+		http.Error(w, "A show with this slug already exists", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create show: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if title != "" {
+		p := store.GetPodcast()
+		p.Title = title
+		if err := store.UpdatePodcast(p); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save show settings: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := h.mountShow(slug, store); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to start show: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Send the dashboard to the new show
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", showPath(slug))
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(h.info(slug, store))
+}
+
+// HandleShow handles /shows/{slug}/... and /api/shows/{slug}/..., serving
+// the show's /... and /api/... routes
+func (h *ShowsHandler) HandleShow(w http.ResponseWriter, r *http.Request) {
+	prefix := "/shows/"
+	api := strings.HasPrefix(r.URL.Path, "/api/shows/")
+	if api {
+		prefix = "/api/shows/"
+	}
+
+	slug, rest, found := strings.Cut(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	handler, ok := h.handler(slug)
+	if !ok {
+		http.Error(w, "Show not found", http.StatusNotFound)
+		return
+	}
+	if !found {
+		if api {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		http.Redirect(w, r, prefix+slug+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	path := "/" + rest
+	ctx := r.Context()
+	if api {
+		path = "/api/" + rest
+		ctx = context.WithValue(ctx, showAPIKey{}, prefix+slug+"/")
+	}
+
+	inner := r.Clone(ctx)
+	inner.URL.Path = path
+	inner.URL.RawPath = ""
+	handler.ServeHTTP(w, inner)
+}
+
+// ServeDefault serves the default show's routes at the root
+func (h *ShowsHandler) ServeDefault(w http.ResponseWriter, r *http.Request) {
+	handler, ok := h.handler(storage.DefaultShow)
+	if !ok {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
+
+// handler returns the mounted handler of the named show
+func (h *ShowsHandler) handler(slug string) (http.Handler, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	handler, ok := h.mounted[slug]
+	return handler, ok
+}
+
+// mountShow builds and registers the handler of a show
+func (h *ShowsHandler) mountShow(slug string, store storage.Store) error {
+	handler, err := h.mount(slug, store)
+	if err != nil {
+		return fmt.Errorf("failed to mount show %s: %w", slug, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.mounted[slug] = handler
+	return nil
+}
+
+// showAPIKey carries the API prefix of a request made under /api/shows/{slug}/
+type showAPIKey struct{}
+
+// apiPath maps a root API path such as "/api/uploads/{id}" onto the route
+// the request came in through, so URLs handed back to clients of a show
+// under /api/shows/{slug}/ stay within that show
+func apiPath(r *http.Request, path string) string {
+	if prefix, ok := r.Context().Value(showAPIKey{}).(string); ok {
+		return prefix + strings.TrimPrefix(path, "/api/")
+	}
+	return path
+}
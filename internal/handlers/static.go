@@ -1,20 +1,33 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
+
+	"github.com/example/rss-server/internal/media"
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/storage"
 )
 
-// StaticHandler handles serving audio files
+// StaticHandler handles serving audio and artwork files
 type StaticHandler struct {
-	audioDir string
+	store        storage.Store
+	audio        storage.BlobStore
+	artwork      storage.BlobStore
+	previewToken string
+}
+
+// NewStaticHandler creates a new static file handler. The audio and
+// transcript files of the store's unreleased episodes are not served.
+func NewStaticHandler(store storage.Store, audio storage.BlobStore, artwork storage.BlobStore) *StaticHandler {
+	return &StaticHandler{store: store, audio: audio, artwork: artwork}
 }
 
-// NewStaticHandler creates a new static file handler
-func NewStaticHandler(audioDir string) *StaticHandler {
-	return &StaticHandler{audioDir: audioDir}
+// SetPreviewToken serves the files of unreleased episodes to requests
+// carrying the preview feed's token
+func (h *StaticHandler) SetPreviewToken(token string) {
+	h.previewToken = token
 }
 
 // HandleAudio handles GET /audio/{filename}
@@ -31,22 +44,84 @@ func (h *StaticHandler) HandleAudio(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Files of unreleased episodes stay hidden like the episodes themselves,
+	// and must not end up in shared caches when previewed
+	if ep := episodeOwning(h.store, filename); ep != nil && !ep.Published() {
+		if !visible(ep, r, h.previewToken) {
+			http.Error(w, "Audio file not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
+
+	// Serve file with correct content type
+	contentType := media.ContentType(filename)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	serveBlob(w, r, h.audio, filename, "Audio file not found")
+}
+
+// episodeOwning returns a copy of the episode whose audio or transcript is
+// stored as filename, or nil
+func episodeOwning(store storage.Store, filename string) *models.Episode {
+	for _, ep := range store.GetPodcast().Episodes {
+		if ep.Filename == filename || (ep.Transcript != nil && ep.Transcript.Filename == filename) {
+			return &ep
+		}
+	}
+	return nil
+}
+
+// HandleArtwork handles GET /static/artwork/{filename}
+func (h *StaticHandler) HandleArtwork(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filename := strings.TrimPrefix(r.URL.Path, "/static/artwork/")
+	if filename == "" {
+		http.Error(w, "Filename required", http.StatusBadRequest)
+		return
+	}
+
+	serveBlob(w, r, h.artwork, filename, "Artwork not found")
+}
+
+// serveBlob streams a blob with support for range and conditional requests,
+// or redirects to a signed URL when the blob store supports it
+func serveBlob(w http.ResponseWriter, r *http.Request, blobs storage.BlobStore, filename string, notFound string) {
 	// Prevent directory traversal
-	if strings.Contains(filename, "..") || strings.Contains(filename, "/") {
+	if !storage.ValidBlobName(filename) {
 		http.Error(w, "Invalid filename", http.StatusBadRequest)
 		return
 	}
 
-	// Build file path
-	filePath := filepath.Join(h.audioDir, filename)
+	// Stores that support direct downloads are served via a signed redirect
+	if signer, ok := blobs.(storage.SignedURLProvider); ok {
+		signedURL, err := signer.SignedURL(filename)
+		if err != nil {
+			log.Printf("Failed to sign URL for %s: %v", filename, err)
+			http.Error(w, "Failed to open file", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, signedURL, http.StatusFound)
+		return
+	}
 
-	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		http.Error(w, "Audio file not found", http.StatusNotFound)
+	blob, err := blobs.Open(filename)
+	if err == storage.ErrBlobNotFound {
+		http.Error(w, notFound, http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		log.Printf("Failed to open %s: %v", filename, err)
+		http.Error(w, "Failed to open file", http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
 
-	// Serve file with correct content type
-	w.Header().Set("Content-Type", "audio/mpeg")
-	http.ServeFile(w, r, filePath)
+	http.ServeContent(w, r, filename, blob.ModTime(), blob)
 }
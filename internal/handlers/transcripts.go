@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/example/rss-server/internal/media"
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// maxTranscriptBytes bounds the size of an uploaded transcript
+const maxTranscriptBytes = 5 << 20
+
+// TranscriptsHandler serves episodes' transcripts, converting between
+// timed formats on request
+type TranscriptsHandler struct {
+	store        storage.Store
+	audio        storage.BlobStore
+	previewToken string
+}
+
+// NewTranscriptsHandler creates a new transcripts handler
+func NewTranscriptsHandler(store storage.Store, audio storage.BlobStore) *TranscriptsHandler {
+	return &TranscriptsHandler{store: store, audio: audio}
+}
+
+// SetPreviewToken serves the transcripts of unreleased episodes to requests
+// carrying the preview feed's token
+func (h *TranscriptsHandler) SetPreviewToken(token string) {
+	h.previewToken = token
+}
+
+// HandleTranscript handles GET /episodes/{id}/transcript.{srt,vtt,txt}
+func (h *TranscriptsHandler) HandleTranscript(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := strings.TrimPrefix(path.Ext(r.URL.Path), ".")
+	episodeID, ok := episodePathID(r.URL.Path, "/episodes/", "/transcript."+format)
+	if !ok || media.TranscriptContentType(format) == "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	ep := findVisibleEpisode(h.store, episodeID, r, h.previewToken)
+	if ep == nil || ep.Transcript == nil {
+		http.Error(w, "Transcript not found", http.StatusNotFound)
+		return
+	}
+
+	blob, err := h.audio.Open(ep.Transcript.Filename)
+	if err == storage.ErrBlobNotFound {
+		http.Error(w, "Transcript not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to open %s: %v", ep.Transcript.Filename, err)
+		http.Error(w, "Failed to open transcript", http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	data, err := io.ReadAll(blob)
+	if err != nil {
+		log.Printf("Failed to read %s: %v", ep.Transcript.Filename, err)
+		http.Error(w, "Failed to read transcript", http.StatusInternalServerError)
+		return
+	}
+
+	data, err = media.ConvertTranscript(data, ep.Transcript.Format, format)
+	if err != nil {
+		http.Error(w, "Transcript not available in this format", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", media.TranscriptContentType(format)+"; charset=utf-8")
+	http.ServeContent(w, r, "", blob.ModTime(), bytes.NewReader(data))
+}
+
+// HandleUploadTranscript handles PUT /api/episodes/{id}/transcript with a
+// multipart "transcript" file and an optional "language" field. The
+// transcript is validated, normalized and stored beside the audio,
+// replacing any previous transcript.
+func (h *EpisodesHandler) HandleUploadTranscript(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/api/episodes/", "/transcript")
+	if !ok {
+		http.Error(w, "Episode ID required", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxTranscriptBytes+maxFormFieldBytes)
+	if err := r.ParseMultipartForm(maxTranscriptBytes); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to parse form: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("transcript")
+	if err != nil {
+		http.Error(w, "Transcript file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxTranscriptBytes+1))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read transcript: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxTranscriptBytes {
+		http.Error(w, fmt.Sprintf("Transcript exceeds maximum size of %dMB", maxTranscriptBytes>>20), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	format := media.DetectTranscriptFormat(header.Filename, data)
+	if format == "" {
+		http.Error(w, "Unsupported transcript format. Use SRT, WebVTT or plain text", http.StatusBadRequest)
+		return
+	}
+	data, err = media.NormalizeTranscript(format, data)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Transcript rejected: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	language := strings.TrimSpace(r.FormValue("language"))
+	if err := checkText("language", language); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid transcript: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ep := findEpisode(h.store, episodeID)
+	if ep == nil {
+		http.Error(w, "Episode not found", http.StatusNotFound)
+		return
+	}
+	if ep.Filename == "" {
+		http.Error(w, "Episode has no audio file to attach a transcript to", http.StatusConflict)
+		return
+	}
+
+	filename, err := storage.SaveTranscriptFile(ep.Filename, format, data, h.audio)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save transcript: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	old := ep.Transcript
+	ep.Transcript = &models.Transcript{Filename: filename, Format: format, Language: language}
+	if err := h.store.UpdateEpisode(*ep); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update episode: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if old != nil && old.Filename != filename {
+		h.deleteTranscript(old.Filename)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ep.Transcript)
+}
+
+// HandleDeleteTranscript handles DELETE /api/episodes/{id}/transcript
+func (h *EpisodesHandler) HandleDeleteTranscript(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/api/episodes/", "/transcript")
+	if !ok {
+		http.Error(w, "Episode ID required", http.StatusBadRequest)
+		return
+	}
+
+	ep := findEpisode(h.store, episodeID)
+	if ep == nil {
+		http.Error(w, "Episode not found", http.StatusNotFound)
+		return
+	}
+	if ep.Transcript == nil {
+		http.Error(w, "Transcript not found", http.StatusNotFound)
+		return
+	}
+
+	filename := ep.Transcript.Filename
+	ep.Transcript = nil
+	if err := h.store.UpdateEpisode(*ep); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update episode: %v", err), http.StatusInternalServerError)
+		return
+	}
+	h.deleteTranscript(filename)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteTranscript removes a transcript blob, logging failures since the
+// episode no longer references it
+func (h *EpisodesHandler) deleteTranscript(filename string) {
+	if err := h.audio.Delete(filename); err != nil && err != storage.ErrBlobNotFound {
+		log.Printf("Warning: Failed to delete transcript file %s: %v", filename, err)
+	}
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// TrashHandler moves deleted episodes and their files into a trash area,
+// from which they can be restored until the retention period expires
+type TrashHandler struct {
+	store     storage.Store
+	audio     storage.BlobStore
+	artwork   storage.BlobStore
+	trash     storage.BlobStore
+	retention time.Duration
+	templates *template.Template
+	routes    showRoutes
+
+	// Now returns the current time; tests may replace it
+	Now func() time.Time
+}
+
+// NewTrashHandler creates a new trash handler. Files of trashed episodes
+// are moved from audio to trash and purged after retention. Episode and
+// chapter artwork stays in the artwork store until the episode is purged.
+func NewTrashHandler(store storage.Store, audio storage.BlobStore, artwork storage.BlobStore, trash storage.BlobStore, retention time.Duration, templates *template.Template) *TrashHandler {
+	return &TrashHandler{
+		store:     store,
+		audio:     audio,
+		artwork:   artwork,
+		trash:     trash,
+		retention: retention,
+		templates: templates,
+		routes:    routesFor(storage.DefaultShow),
+		Now:       time.Now,
+	}
+}
+
+// SetShow makes the trash view link to the routes of the show with the
+// given slug rather than the default show's
+func (h *TrashHandler) SetShow(slug string) {
+	h.routes = routesFor(slug)
+}
+
+// trashItem is a trashed episode with its purge time
+type trashItem struct {
+	models.TrashedEpisode
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// trashView is the trash view's template data
+type trashView struct {
+	showRoutes
+	Items []trashItem
+}
+
+// HandleTrash handles DELETE /api/episodes/{id}, moving the episode to the trash
+func (h *TrashHandler) HandleTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/api/episodes/", "")
+	if !ok {
+		http.Error(w, "Episode ID required", http.StatusBadRequest)
+		return
+	}
+
+	ep := findEpisode(h.store, episodeID)
+	if ep == nil {
+		http.Error(w, "Episode not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.store.TrashEpisode(episodeID, h.Now()); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete episode: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// The episode is already out of the feed, so a file left behind is
+	// logged rather than failing the request
+	for _, name := range episodeFiles(ep) {
+		if err := storage.MoveBlob(name, h.audio, h.trash); err != nil && err != storage.ErrBlobNotFound {
+			log.Printf("Warning: Failed to move %s to trash: %v", name, err)
+		}
+	}
+
+	// Return success (for HTMX - empty response to remove element)
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleList handles GET /api/trash
+func (h *TrashHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	items := []trashItem{}
+	for _, t := range h.store.GetTrash() {
+		items = append(items, trashItem{TrashedEpisode: t, ExpiresAt: t.DeletedAt.Add(h.retention)})
+	}
+
+	if r.Header.Get("HX-Request") == "true" && h.templates != nil {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := h.templates.ExecuteTemplate(w, "trash_list.html", trashView{showRoutes: h.routes, Items: items}); err != nil {
+			log.Printf("Template error: %v", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// HandleRestore handles POST /api/trash/{id}/restore
+func (h *TrashHandler) HandleRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/api/trash/", "/restore")
+	if !ok {
+		http.Error(w, "Episode ID required", http.StatusBadRequest)
+		return
+	}
+
+	t := h.findTrashed(episodeID)
+	if t == nil {
+		http.Error(w, "Episode not found in trash", http.StatusNotFound)
+		return
+	}
+	if findEpisode(h.store, episodeID) != nil {
+		http.Error(w, "An episode with this ID already exists", http.StatusConflict)
+		return
+	}
+
+	// Move the files back first so the restored episode never points at
+	// missing audio
+	for _, name := range episodeFiles(&t.Episode) {
+		if err := storage.MoveBlob(name, h.trash, h.audio); err != nil && err != storage.ErrBlobNotFound {
+			log.Printf("Warning: Failed to restore %s from trash: %v", name, err)
+		}
+	}
+
+	if err := h.store.RestoreEpisode(episodeID); err != nil {
+		for _, name := range episodeFiles(&t.Episode) {
+			storage.MoveBlob(name, h.audio, h.trash)
+		}
+		http.Error(w, fmt.Sprintf("Failed to restore episode: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t.Episode)
+}
+
+// HandlePurge handles DELETE /api/trash/{id}, deleting the episode forever
+func (h *TrashHandler) HandlePurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	episodeID, ok := episodePathID(r.URL.Path, "/api/trash/", "")
+	if !ok {
+		http.Error(w, "Episode ID required", http.StatusBadRequest)
+		return
+	}
+
+	t := h.findTrashed(episodeID)
+	if t == nil {
+		http.Error(w, "Episode not found in trash", http.StatusNotFound)
+		return
+	}
+
+	if err := h.purge(t); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to purge episode: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// PurgeExpired permanently deletes episodes that have been in the trash
+// longer than the retention period and returns how many were purged
+func (h *TrashHandler) PurgeExpired() int {
+	cutoff := h.Now().Add(-h.retention)
+
+	purged := 0
+	for _, t := range h.store.GetTrash() {
+		if t.DeletedAt.After(cutoff) {
+			continue
+		}
+		if err := h.purge(&t); err != nil {
+			log.Printf("Warning: Failed to purge trashed episode %s: %v", t.ID, err)
+			continue
+		}
+		purged++
+	}
+	return purged
+}
+
+// StartPurger purges expired trash every interval until stop is closed
+func (h *TrashHandler) StartPurger(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if n := h.PurgeExpired(); n > 0 {
+					log.Printf("Purged %d episodes from the trash", n)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// purge removes a trashed episode from the store, then its files and any
+// artwork no other episode or the podcast itself still uses
+func (h *TrashHandler) purge(t *models.TrashedEpisode) error {
+	if err := h.store.PurgeEpisode(t.ID); err != nil {
+		return err
+	}
+
+	for _, name := range episodeFiles(&t.Episode) {
+		if err := h.trash.Delete(name); err != nil && err != storage.ErrBlobNotFound {
+			log.Printf("Warning: Failed to delete %s from trash: %v", name, err)
+		}
+	}
+
+	inUse := h.artworkInUse()
+	for _, name := range episodeArtwork(&t.Episode, h.artwork) {
+		if inUse[name] {
+			continue
+		}
+		if err := h.artwork.Delete(name); err != nil && err != storage.ErrBlobNotFound {
+			log.Printf("Warning: Failed to delete artwork %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// artworkInUse returns the names of the artwork blobs used by the podcast
+// and by its live and trashed episodes
+func (h *TrashHandler) artworkInUse() map[string]bool {
+	podcast := h.store.GetPodcast()
+	episodes := podcast.Episodes
+	for _, t := range h.store.GetTrash() {
+		episodes = append(episodes, t.Episode)
+	}
+
+	inUse := map[string]bool{}
+	if name, ok := storage.BlobName(h.artwork, podcast.ImageURL); ok {
+		inUse[name] = true
+	}
+	for i := range episodes {
+		for _, name := range episodeArtwork(&episodes[i], h.artwork) {
+			inUse[name] = true
+		}
+	}
+	return inUse
+}
+
+// findTrashed returns a copy of the trashed episode with the given ID, or nil
+func (h *TrashHandler) findTrashed(episodeID string) *models.TrashedEpisode {
+	for _, t := range h.store.GetTrash() {
+		if t.ID == episodeID {
+			return &t
+		}
+	}
+	return nil
+}
+
+// episodeFiles returns the names of the blobs stored for an episode
+func episodeFiles(ep *models.Episode) []string {
+	var names []string
+	if ep.Filename != "" {
+		names = append(names, ep.Filename)
+	}
+	if ep.Transcript != nil {
+		names = append(names, ep.Transcript.Filename)
+	}
+	return names
+}
@@ -0,0 +1,479 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/example/rss-server/internal/storage"
+)
+
+// tusVersion is the supported tus protocol version
+const tusVersion = "1.0.0"
+
+// tusExtensions lists the supported tus protocol extensions
+const tusExtensions = "creation,expiration,termination"
+
+// tusUploadsPath is the URL prefix of resumable uploads
+const tusUploadsPath = "/api/uploads/"
+
+// TusHandler implements the tus resumable upload protocol
+// (https://tus.io/protocols/resumable-upload). Completed uploads are turned
+// into episodes exactly like a multipart POST to /api/episodes; episode
+// fields are passed in the Upload-Metadata header.
+type TusHandler struct {
+	episodes *EpisodesHandler
+	dir      string
+	expiry   time.Duration
+
+	mu     sync.Mutex
+	active map[string]bool // uploads with a request in flight
+}
+
+// tusUpload is the persisted state of a resumable upload. The offset is the
+// size of its data file.
+type tusUpload struct {
+	Length   int64             `json:"length"`
+	Metadata map[string]string `json:"metadata"`
+	Expires  time.Time         `json:"expires"`
+}
+
+// NewTusHandler creates a resumable upload handler storing partial uploads
+// in dir. Uploads not completed within expiry are removed by the janitor.
+func NewTusHandler(episodes *EpisodesHandler, dir string, expiry time.Duration) (*TusHandler, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create uploads directory: %w", err)
+	}
+
+	return &TusHandler{
+		episodes: episodes,
+		dir:      dir,
+		expiry:   expiry,
+		active:   make(map[string]bool),
+	}, nil
+}
+
+// HandleOptions handles OPTIONS /api/uploads, advertising server capabilities
+func (h *TusHandler) HandleOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(h.maxSize(), 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleCreate handles POST /api/uploads
+func (h *TusHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	if !h.checkVersion(w, r) {
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		http.Error(w, "Upload-Length header required", http.StatusBadRequest)
+		return
+	}
+	if length > h.maxSize() {
+		http.Error(w, fmt.Sprintf("File too large (max %d MB)", h.episodes.maxSizeMB), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	metadata, err := parseTusMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid Upload-Metadata: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Reject unsupported files before any data is sent
+	filename := metadata["filename"]
+	if filename == "" {
+		http.Error(w, "Upload-Metadata must include filename", http.StatusBadRequest)
+		return
+	}
+	if !h.episodes.extensionAllowed(filepath.Ext(filename)) {
+		http.Error(w, fmt.Sprintf("Unsupported file type (allowed: %s)", strings.Join(h.episodes.allowedExts, ", ")), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create upload: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	upload := &tusUpload{
+		Length:   length,
+		Metadata: metadata,
+		Expires:  time.Now().Add(h.expiry),
+	}
+	if err := os.WriteFile(h.dataPath(id), nil, 0644); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create upload: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := h.saveInfo(id, upload); err != nil {
+		os.Remove(h.dataPath(id))
+		http.Error(w, fmt.Sprintf("Failed to create upload: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Location", apiPath(r, tusUploadsPath+id))
+	w.Header().Set("Upload-Expires", upload.Expires.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// HandleHead handles HEAD /api/uploads/{id}, reporting the current offset
+func (h *TusHandler) HandleHead(w http.ResponseWriter, r *http.Request) {
+	if !h.checkVersion(w, r) {
+		return
+	}
+
+	_, upload, offset, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	w.Header().Set("Upload-Expires", upload.Expires.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandlePatch handles PATCH /api/uploads/{id}, appending a chunk. The chunk
+// that completes the upload also creates the episode and responds with it.
+func (h *TusHandler) HandlePatch(w http.ResponseWriter, r *http.Request) {
+	if !h.checkVersion(w, r) {
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "Content-Type must be application/offset+octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	id, upload, offset, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	// One request per upload at a time
+	if !h.acquire(id) {
+		http.Error(w, "Upload is busy", http.StatusLocked)
+		return
+	}
+	defer h.release(id)
+
+	// Re-read the offset now that the upload is locked
+	if info, err := os.Stat(h.dataPath(id)); err == nil {
+		offset = info.Size()
+	}
+
+	clientOffset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		http.Error(w, "Upload-Offset header required", http.StatusBadRequest)
+		return
+	}
+	if clientOffset != offset {
+		http.Error(w, fmt.Sprintf("Upload-Offset mismatch (current offset %d)", offset), http.StatusConflict)
+		return
+	}
+
+	f, err := os.OpenFile(h.dataPath(id), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to open upload: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Keep whatever arrives, even if the connection drops mid-chunk; the
+	// client resumes from the new offset
+	written, copyErr := io.Copy(f, io.LimitReader(r.Body, upload.Length-offset))
+	if err := f.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	offset += written
+
+	if copyErr != nil {
+		log.Printf("Warning: Upload %s interrupted at offset %d: %v", id, offset, copyErr)
+		http.Error(w, "Failed to write chunk", http.StatusInternalServerError)
+		return
+	}
+
+	// Activity extends the expiry
+	upload.Expires = time.Now().Add(h.expiry)
+	if err := h.saveInfo(id, upload); err != nil {
+		log.Printf("Warning: Failed to update upload %s: %v", id, err)
+	}
+
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	w.Header().Set("Upload-Expires", upload.Expires.UTC().Format(http.TimeFormat))
+
+	if offset < upload.Length {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.complete(w, id, upload)
+}
+
+// complete turns a finished upload into an episode. The upload is removed
+// whatever the outcome, since its metadata cannot be corrected afterwards.
+func (h *TusHandler) complete(w http.ResponseWriter, id string, upload *tusUpload) {
+	defer h.remove(id)
+
+	staged, err := storage.StageFile(h.episodes.audio, h.dataPath(id))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read audio file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	fields := url.Values{}
+	for key, value := range upload.Metadata {
+		if key != "filename" {
+			fields.Set(key, value)
+		}
+	}
+
+	episode := &episodeUpload{
+		fields:    fields,
+		audioName: upload.Metadata["filename"],
+		audio:     staged,
+	}
+	defer episode.discard()
+
+	h.episodes.createEpisode(w, episode)
+}
+
+// HandleDelete handles DELETE /api/uploads/{id}, abandoning an upload
+func (h *TusHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if !h.checkVersion(w, r) {
+		return
+	}
+
+	id, _, _, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.acquire(id) {
+		http.Error(w, "Upload is busy", http.StatusLocked)
+		return
+	}
+	defer h.release(id)
+
+	h.remove(id)
+
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PurgeExpired removes uploads past their expiry and returns how many were
+// removed. Uploads with a request in flight are skipped.
+func (h *TusHandler) PurgeExpired() int {
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		log.Printf("Warning: Failed to list uploads: %v", err)
+		return 0
+	}
+
+	now := time.Now()
+	purged := 0
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".info")
+		if !ok || !validUploadID(id) {
+			continue
+		}
+
+		upload, err := h.loadInfo(id)
+		if err != nil || !now.After(upload.Expires) {
+			continue
+		}
+		if !h.acquire(id) {
+			continue
+		}
+		h.remove(id)
+		h.release(id)
+		purged++
+	}
+
+	return purged
+}
+
+// StartJanitor purges expired uploads every interval until stop is closed
+func (h *TusHandler) StartJanitor(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if n := h.PurgeExpired(); n > 0 {
+					log.Printf("Removed %d expired partial uploads", n)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// lookup loads the upload named in the request path. It writes an error
+// response and returns false when the upload is missing or expired.
+func (h *TusHandler) lookup(w http.ResponseWriter, r *http.Request) (string, *tusUpload, int64, bool) {
+	id := strings.TrimPrefix(r.URL.Path, tusUploadsPath)
+	if !validUploadID(id) {
+		http.Error(w, "Upload not found", http.StatusNotFound)
+		return "", nil, 0, false
+	}
+
+	upload, err := h.loadInfo(id)
+	if err != nil {
+		http.Error(w, "Upload not found", http.StatusNotFound)
+		return "", nil, 0, false
+	}
+	if time.Now().After(upload.Expires) {
+		http.Error(w, "Upload expired", http.StatusGone)
+		return "", nil, 0, false
+	}
+
+	info, err := os.Stat(h.dataPath(id))
+	if err != nil {
+		http.Error(w, "Upload not found", http.StatusNotFound)
+		return "", nil, 0, false
+	}
+
+	return id, upload, info.Size(), true
+}
+
+// checkVersion rejects requests for an unsupported protocol version
+func (h *TusHandler) checkVersion(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		http.Error(w, "Unsupported tus version", http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// acquire marks an upload as in use, reporting false if it already is
+func (h *TusHandler) acquire(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.active[id] {
+		return false
+	}
+	h.active[id] = true
+	return true
+}
+
+// release clears the in-use mark set by acquire
+func (h *TusHandler) release(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.active, id)
+}
+
+// maxSize returns the largest accepted upload in bytes
+func (h *TusHandler) maxSize() int64 {
+	return h.episodes.maxSizeMB * 1024 * 1024
+}
+
+func (h *TusHandler) dataPath(id string) string {
+	return filepath.Join(h.dir, id+".part")
+}
+
+func (h *TusHandler) infoPath(id string) string {
+	return filepath.Join(h.dir, id+".info")
+}
+
+// loadInfo reads an upload's persisted state
+func (h *TusHandler) loadInfo(id string) (*tusUpload, error) {
+	data, err := os.ReadFile(h.infoPath(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var upload tusUpload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("failed to parse upload info: %w", err)
+	}
+	return &upload, nil
+}
+
+// saveInfo persists an upload's state atomically
+func (h *TusHandler) saveInfo(id string, upload *tusUpload) error {
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return err
+	}
+
+	tmp := h.infoPath(id) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.infoPath(id))
+}
+
+// remove deletes an upload's data and state
+func (h *TusHandler) remove(id string) {
+	os.Remove(h.dataPath(id))
+	os.Remove(h.infoPath(id))
+}
+
+// newUploadID returns a random upload ID
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validUploadID reports whether id has the shape produced by newUploadID
+func validUploadID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// parseTusMetadata decodes an Upload-Metadata header: comma-separated
+// pairs of a key and an optional base64-encoded value
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, fmt.Errorf("empty key")
+		}
+
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %w", key, err)
+		}
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
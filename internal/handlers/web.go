@@ -11,14 +11,18 @@ import (
 
 // WebHandler handles web UI requests
 type WebHandler struct {
-	store     *storage.RSSStore
+	store     storage.Store
 	templates *template.Template
 	baseURL   string // T046: Add baseURL field
+
+	// Set by SetShows when the server hosts several shows
+	shows *ShowsHandler
+	slug  string
 }
 
 // NewWebHandler creates a new web handler
 // T047: Updated to accept baseURL parameter
-func NewWebHandler(store *storage.RSSStore, templatesDir string, baseURL string) (*WebHandler, error) {
+func NewWebHandler(store storage.Store, templatesDir string, baseURL string) (*WebHandler, error) {
 	// Parse all templates including components
 	tmpl, err := template.ParseGlob(templatesDir + "/*.html")
 	if err != nil {
@@ -38,6 +42,19 @@ func NewWebHandler(store *storage.RSSStore, templatesDir string, baseURL string)
 	}, nil
 }
 
+// SetShows enables the dashboard's show switcher. slug is the show this
+// handler serves.
+func (h *WebHandler) SetShows(shows *ShowsHandler, slug string) {
+	h.shows = shows
+	h.slug = slug
+}
+
+// showOption is an entry of the dashboard's show switcher
+type showOption struct {
+	showInfo
+	Current bool
+}
+
 // HandleDashboard handles GET /
 // T048: Updated to use baseURL for FeedURL
 func (h *WebHandler) HandleDashboard(w http.ResponseWriter, r *http.Request) {
@@ -49,10 +66,34 @@ func (h *WebHandler) HandleDashboard(w http.ResponseWriter, r *http.Request) {
 	// Get podcast data
 	podcast := h.store.GetPodcast()
 
+	// Dashboards of other shows link to the show's routes
+	routes := routesFor(storage.DefaultShow)
+	if h.shows != nil {
+		routes = routesFor(h.slug)
+	}
+
+	episodes := make([]episodeRow, len(podcast.Episodes))
+	for i, ep := range podcast.Episodes {
+		episodes[i] = episodeRow{Episode: ep, showRoutes: routes}
+	}
+
 	// Prepare template data
 	data := map[string]interface{}{
-		"Podcast": podcast,
-		"FeedURL": fmt.Sprintf("%s/feed.xml", h.baseURL),
+		"Podcast":     podcast,
+		"Episodes":    episodes,
+		"FeedURL":     fmt.Sprintf("%s/feed.xml", h.baseURL),
+		"AtomURL":     fmt.Sprintf("%s/feed.atom", h.baseURL),
+		"JSONFeedURL": fmt.Sprintf("%s/feed.json", h.baseURL),
+		"HomePath":    routes.HomePath,
+		"APIBase":     routes.APIBase,
+	}
+
+	if h.shows != nil {
+		var options []showOption
+		for _, info := range h.shows.list() {
+			options = append(options, showOption{showInfo: info, Current: info.Slug == h.slug})
+		}
+		data["Shows"] = options
 	}
 
 	// Render template
@@ -0,0 +1,91 @@
+package media
+
+import (
+	"bufio"
+	"io"
+	"time"
+)
+
+// adtsSampleRates is indexed by the ADTS sampling frequency index
+var adtsSampleRates = [16]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// adtsFrame is a decoded ADTS frame header
+type adtsFrame struct {
+	sampleRate int
+	size       int // frame length including the header
+	blocks     int // raw data blocks, 1024 samples each
+}
+
+// parseADTSHeader decodes a 7-byte ADTS header. ADTS shares the MPEG sync
+// word but always has layer bits 00, which MP3 never uses.
+func parseADTSHeader(h []byte) (adtsFrame, bool) {
+	if len(h) < 7 || h[0] != 0xFF || h[1]&0xF6 != 0xF0 {
+		return adtsFrame{}, false
+	}
+
+	rate := adtsSampleRates[(h[2]>>2)&0x0F]
+	size := int(h[3]&0x03)<<11 | int(h[4])<<3 | int(h[5]>>5)
+	if rate == 0 || size < 7 {
+		return adtsFrame{}, false
+	}
+
+	return adtsFrame{sampleRate: rate, size: size, blocks: int(h[6]&0x03) + 1}, true
+}
+
+// isADTSStream reports whether buf starts with an ADTS frame followed by
+// another one (when the buffer is long enough to hold it)
+func isADTSStream(buf []byte) bool {
+	f, ok := parseADTSHeader(buf)
+	if !ok {
+		return false
+	}
+	if f.size+7 <= len(buf) {
+		_, ok = parseADTSHeader(buf[f.size:])
+	}
+	return ok
+}
+
+// ProbeADTS computes the duration and average bitrate of a raw AAC (ADTS)
+// stream by walking its frames
+func ProbeADTS(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	audioEnd := size
+	if hasID3v1(r, size) {
+		audioEnd -= 128
+	}
+
+	start, err := skipID3v2(r)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReaderSize(io.LimitReader(r, audioEnd-start), 64*1024)
+
+	var total time.Duration
+	var sampleRate int
+
+	for {
+		header, err := br.Peek(7)
+		if err != nil {
+			break
+		}
+
+		f, ok := parseADTSHeader(header)
+		if !ok {
+			// Resync one byte at a time
+			br.Discard(1)
+			continue
+		}
+
+		sampleRate = f.sampleRate
+		total += time.Duration(float64(f.blocks*1024) / float64(f.sampleRate) * float64(time.Second))
+
+		if _, err := br.Discard(f.size); err != nil {
+			break
+		}
+	}
+
+	if sampleRate == 0 {
+		return nil, ErrNoFrames
+	}
+	return newAudioInfo(total, audioEnd-start, sampleRate), nil
+}
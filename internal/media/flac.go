@@ -0,0 +1,36 @@
+package media
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ProbeFLAC computes the duration and average bitrate of a FLAC stream from
+// its STREAMINFO block
+func ProbeFLAC(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	start, err := skipID3v2(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// "fLaC", then the STREAMINFO block header and its 34-byte body
+	head := make([]byte, 4+4+34)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, fmt.Errorf("failed to read FLAC header: %w", err)
+	}
+	if string(head[:4]) != "fLaC" || head[4]&0x7F != 0 {
+		return nil, fmt.Errorf("missing FLAC STREAMINFO block")
+	}
+
+	info := head[8:]
+	sampleRate := int(info[10])<<12 | int(info[11])<<4 | int(info[12]>>4)
+	totalSamples := int64(info[13]&0x0F)<<32 | int64(binary.BigEndian.Uint32(info[14:18]))
+	if sampleRate == 0 || totalSamples == 0 {
+		return nil, ErrNoFrames
+	}
+
+	duration := time.Duration(float64(totalSamples) / float64(sampleRate) * float64(time.Second))
+	return newAudioInfo(duration, size-start, sampleRate), nil
+}
@@ -0,0 +1,131 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+// Format describes a supported audio container
+type Format struct {
+	Name       string   // display name
+	MIMEType   string   // enclosure MIME type
+	Extensions []string // file extensions, canonical first
+
+	probe func(r io.ReadSeeker, size int64) (*AudioInfo, error)
+}
+
+// Supported audio formats
+var (
+	MP3    = &Format{Name: "MP3", MIMEType: "audio/mpeg", Extensions: []string{".mp3"}, probe: ProbeMP3}
+	AAC    = &Format{Name: "AAC", MIMEType: "audio/aac", Extensions: []string{".aac"}, probe: ProbeADTS}
+	M4A    = &Format{Name: "M4A", MIMEType: "audio/x-m4a", Extensions: []string{".m4a", ".mp4"}, probe: ProbeMP4}
+	Opus   = &Format{Name: "Opus", MIMEType: "audio/ogg", Extensions: []string{".opus"}, probe: ProbeOgg}
+	Vorbis = &Format{Name: "Ogg Vorbis", MIMEType: "audio/ogg", Extensions: []string{".ogg", ".oga"}, probe: ProbeOgg}
+	FLAC   = &Format{Name: "FLAC", MIMEType: "audio/flac", Extensions: []string{".flac"}, probe: ProbeFLAC}
+)
+
+// Formats lists every supported format
+var Formats = []*Format{MP3, AAC, M4A, Opus, Vorbis, FLAC}
+
+// ErrUnknownFormat is returned when the stream is not a supported format
+var ErrUnknownFormat = errors.New("unrecognized audio format")
+
+// Probe computes the duration and bitrate of a stream in this format
+func (f *Format) Probe(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	return f.probe(r, size)
+}
+
+// HasExtension reports whether ext (e.g. ".m4a") belongs to the format
+func (f *Format) HasExtension(ext string) bool {
+	ext = strings.ToLower(ext)
+	for _, e := range f.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatForExtension returns the format for a file extension, or nil
+func FormatForExtension(ext string) *Format {
+	for _, f := range Formats {
+		if f.HasExtension(ext) {
+			return f
+		}
+	}
+	return nil
+}
+
+// Detect identifies the audio format from the stream's magic bytes
+func Detect(r io.ReadSeeker) (*Format, error) {
+	buf, err := readHead(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip a leading ID3v2 tag (used by MP3, AAC and some FLAC files)
+	if len(buf) >= 10 && string(buf[:3]) == "ID3" {
+		start := 10 + int64(syncsafe(buf[6:10]))
+		if buf[5]&0x10 != 0 {
+			start += 10 // footer present
+		}
+		if buf, err = readHead(r, start); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case bytes.HasPrefix(buf, []byte("fLaC")):
+		return FLAC, nil
+	case bytes.HasPrefix(buf, []byte("OggS")):
+		if _, codec, ok := oggFirstPacket(buf); ok {
+			switch {
+			case bytes.HasPrefix(codec, []byte("OpusHead")):
+				return Opus, nil
+			case bytes.HasPrefix(codec, []byte("\x01vorbis")):
+				return Vorbis, nil
+			}
+		}
+		return nil, ErrUnknownFormat
+	case len(buf) >= 8 && string(buf[4:8]) == "ftyp":
+		return M4A, nil
+	}
+
+	if _, ok := parseADTSHeader(buf); ok && isADTSStream(buf) {
+		return AAC, nil
+	}
+	if _, _, ok := findFrame(buf); ok {
+		return MP3, nil
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// readHead reads up to maxSyncSearch bytes starting at offset
+func readHead(r io.ReadSeeker, offset int64) ([]byte, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+
+	buf := make([]byte, maxSyncSearch)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read audio data: %w", err)
+	}
+	return buf[:n], nil
+}
+
+// ContentType returns the MIME type for a file name, preferring the audio
+// formats above over the system MIME table. It returns "" when unknown.
+func ContentType(name string) string {
+	ext := filepath.Ext(name)
+	if f := FormatForExtension(ext); f != nil {
+		return f.MIMEType
+	}
+	return mime.TypeByExtension(ext)
+}
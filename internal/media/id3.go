@@ -0,0 +1,551 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Tags holds the metadata read from ID3v2 and ID3v1 tags
+type Tags struct {
+	Title    string    // TIT2
+	Comment  string    // COMM
+	Year     string    // TYER/TDRC
+	Track    int       // TRCK
+	Picture  *Picture  // APIC, front cover preferred
+	Chapters []Chapter // CHAP, ordered by start time
+}
+
+// Chapter is a chapter marker from an ID3v2 CHAP frame
+type Chapter struct {
+	Start time.Duration
+	End   time.Duration
+	Title string   // embedded TIT2
+	URL   string   // embedded WXXX
+	Image *Picture // embedded APIC
+}
+
+// Picture is an embedded image
+type Picture struct {
+	MIMEType string
+	Data     []byte
+}
+
+// Ext returns the file extension for the picture's MIME type
+func (p *Picture) Ext() string {
+	if strings.Contains(strings.ToLower(p.MIMEType), "png") {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// maxTagSize bounds how much of an ID3v2 tag is read into memory
+const maxTagSize = 32 * 1024 * 1024
+
+// ReadTags reads ID3v2 tags from the start of r, filling any gaps from an
+// ID3v1 tag at the end. A stream without tags yields empty Tags.
+func ReadTags(r io.ReadSeeker, size int64) (*Tags, error) {
+	tags := &Tags{}
+
+	if err := readID3v2(r, tags); err != nil {
+		return nil, err
+	}
+
+	if err := readID3v1(r, size, tags); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// readID3v2 parses an ID3v2.2, v2.3 or v2.4 tag
+func readID3v2(r io.ReadSeeker, tags *Tags) error {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		return fmt.Errorf("failed to read tag header: %w", err)
+	}
+	if string(header[:3]) != "ID3" {
+		return nil
+	}
+
+	version := header[3]
+	flags := header[5]
+	tagSize := syncsafe(header[6:10])
+	if version < 2 || version > 4 || tagSize > maxTagSize {
+		return nil
+	}
+
+	body := make([]byte, tagSize)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return fmt.Errorf("failed to read tag: %w", err)
+	}
+
+	// v2.2/v2.3 unsynchronisation applies to the whole tag
+	if flags&0x80 != 0 && version < 4 {
+		body = unsynchronise(body)
+	}
+
+	// Skip the extended header
+	if flags&0x40 != 0 && version > 2 && len(body) >= 4 {
+		extSize := int(binary.BigEndian.Uint32(body))
+		if version == 3 {
+			extSize += 4 // v2.3 size excludes itself
+		} else {
+			extSize = syncsafe(body[:4])
+		}
+		if extSize > len(body) {
+			return nil
+		}
+		body = body[extSize:]
+	}
+
+	ids := id3FrameIDs
+	if version == 2 {
+		ids = id3v22FrameIDs
+	}
+
+	chapters := map[string]Chapter{}
+	var tocs []tableOfContents
+
+	for len(body) > 0 {
+		id, data, rest, ok := nextFrame(body, version, flags&0x80 != 0)
+		if !ok {
+			break
+		}
+		body = rest
+
+		switch ids[id] {
+		case "title":
+			tags.Title = decodeText(data)
+		case "year":
+			if tags.Year == "" {
+				tags.Year = firstN(decodeText(data), 4)
+			}
+		case "track":
+			tags.Track = parseTrack(decodeText(data))
+		case "comment":
+			// Prefer the comment without a description (the "main" one)
+			if desc, text := decodeComment(data); tags.Comment == "" || desc == "" {
+				tags.Comment = text
+			}
+		case "picture":
+			if pic, front := decodePicture(data, version); pic != nil && (tags.Picture == nil || front) {
+				tags.Picture = pic
+			}
+		case "chapter":
+			if id, ch, ok := decodeChapter(data, version); ok {
+				chapters[id] = ch
+			}
+		case "toc":
+			if toc, ok := decodeTOC(data); ok {
+				tocs = append(tocs, toc)
+			}
+		}
+	}
+
+	tags.Chapters = orderChapters(chapters, tocs)
+	return nil
+}
+
+// id3FrameIDs maps v2.3/v2.4 frame IDs to the fields we read
+var id3FrameIDs = map[string]string{
+	"TIT2": "title",
+	"TYER": "year",
+	"TDRC": "year",
+	"TRCK": "track",
+	"COMM": "comment",
+	"APIC": "picture",
+	"CHAP": "chapter",
+	"CTOC": "toc",
+}
+
+// id3v22FrameIDs maps v2.2 three-character frame IDs to the fields we read
+var id3v22FrameIDs = map[string]string{
+	"TT2": "title",
+	"TYE": "year",
+	"TRK": "track",
+	"COM": "comment",
+	"PIC": "picture",
+}
+
+// tableOfContents is a decoded CTOC frame
+type tableOfContents struct {
+	id       string
+	topLevel bool
+	children []string
+}
+
+// decodeChapter decodes a CHAP frame: element ID, start/end times in
+// milliseconds, start/end byte offsets, then embedded frames
+func decodeChapter(data []byte, version byte) (string, Chapter, bool) {
+	end := bytes.IndexByte(data, 0)
+	if end < 0 || len(data) < end+17 {
+		return "", Chapter{}, false
+	}
+	id := string(data[:end])
+	times := data[end+1:]
+
+	ch := Chapter{
+		Start: time.Duration(binary.BigEndian.Uint32(times[0:4])) * time.Millisecond,
+		End:   time.Duration(binary.BigEndian.Uint32(times[4:8])) * time.Millisecond,
+	}
+
+	sub := times[16:]
+	for len(sub) > 0 {
+		frameID, frame, rest, ok := nextFrame(sub, version, false)
+		if !ok {
+			break
+		}
+		sub = rest
+
+		switch frameID {
+		case "TIT2":
+			ch.Title = decodeText(frame)
+		case "WXXX":
+			ch.URL = decodeUserURL(frame)
+		case "APIC":
+			if pic, _ := decodePicture(frame, version); pic != nil {
+				ch.Image = pic
+			}
+		}
+	}
+
+	return id, ch, true
+}
+
+// decodeTOC decodes a CTOC frame: element ID, flags, entry count and the
+// child element IDs. Embedded frames are ignored.
+func decodeTOC(data []byte) (tableOfContents, bool) {
+	end := bytes.IndexByte(data, 0)
+	if end < 0 || len(data) < end+3 {
+		return tableOfContents{}, false
+	}
+
+	toc := tableOfContents{
+		id:       string(data[:end]),
+		topLevel: data[end+1]&0x02 != 0,
+	}
+	count := int(data[end+2])
+	rest := data[end+3:]
+	for i := 0; i < count && len(rest) > 0; i++ {
+		var child string
+		child, rest = splitString(rest, 0)
+		toc.children = append(toc.children, child)
+	}
+
+	return toc, true
+}
+
+// decodeUserURL decodes a WXXX frame's URL, skipping its description
+func decodeUserURL(data []byte) string {
+	if len(data) < 1 {
+		return ""
+	}
+	_, rest := splitString(data[1:], data[0])
+	text, _ := splitString(rest, 0)
+	return strings.TrimSpace(text)
+}
+
+// orderChapters returns the chapters reachable from the top-level table
+// of contents (or all of them when there is none), sorted by start time
+func orderChapters(chapters map[string]Chapter, tocs []tableOfContents) []Chapter {
+	if len(chapters) == 0 {
+		return nil
+	}
+
+	byID := map[string]tableOfContents{}
+	var root *tableOfContents
+	for i, toc := range tocs {
+		byID[toc.id] = toc
+		if toc.topLevel && root == nil {
+			root = &tocs[i]
+		}
+	}
+
+	var out []Chapter
+	if root == nil {
+		ids := make([]string, 0, len(chapters))
+		for id := range chapters {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			out = append(out, chapters[id])
+		}
+	} else {
+		// Walk nested tables of contents, guarding against cycles
+		seen := map[string]bool{}
+		var walk func(toc tableOfContents)
+		walk = func(toc tableOfContents) {
+			if seen[toc.id] {
+				return
+			}
+			seen[toc.id] = true
+			for _, child := range toc.children {
+				if ch, ok := chapters[child]; ok && !seen[child] {
+					seen[child] = true
+					out = append(out, ch)
+				} else if sub, ok := byID[child]; ok {
+					walk(sub)
+				}
+			}
+		}
+		walk(*root)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Start < out[j].Start
+	})
+	return out
+}
+
+// nextFrame splits the next frame off the tag body
+func nextFrame(body []byte, version byte, tagUnsync bool) (id string, data []byte, rest []byte, ok bool) {
+	headerSize := 10
+	if version == 2 {
+		headerSize = 6
+	}
+	if len(body) < headerSize || body[0] == 0 {
+		return "", nil, nil, false // padding
+	}
+
+	var size int
+	var formatFlags byte
+	switch version {
+	case 2:
+		id = string(body[:3])
+		size = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+	case 3:
+		id = string(body[:4])
+		size = int(binary.BigEndian.Uint32(body[4:8]))
+	default:
+		id = string(body[:4])
+		size = syncsafe(body[4:8])
+		formatFlags = body[9]
+	}
+
+	if size < 0 || headerSize+size > len(body) {
+		return "", nil, nil, false
+	}
+	data = body[headerSize : headerSize+size]
+	rest = body[headerSize+size:]
+
+	if version == 4 {
+		// Data length indicator precedes the frame data
+		if formatFlags&0x01 != 0 && len(data) >= 4 {
+			data = data[4:]
+		}
+		if formatFlags&0x02 != 0 || tagUnsync {
+			data = unsynchronise(data)
+		}
+	}
+
+	return id, data, rest, true
+}
+
+// unsynchronise reverses ID3 unsynchronisation (0xFF 0x00 -> 0xFF)
+func unsynchronise(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xFF, 0x00}, []byte{0xFF})
+}
+
+// decodeText decodes a text frame (encoding byte followed by text)
+func decodeText(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	text, _ := splitString(data[1:], data[0])
+	return strings.TrimSpace(text)
+}
+
+// decodeComment decodes a COMM frame into its description and text
+func decodeComment(data []byte) (string, string) {
+	if len(data) < 4 {
+		return "", ""
+	}
+	enc := data[0]
+	desc, rest := splitString(data[4:], enc) // skip 3-byte language
+	text, _ := splitString(rest, enc)
+	return desc, strings.TrimSpace(text)
+}
+
+// decodePicture decodes an APIC (or v2.2 PIC) frame. It reports whether
+// the picture is the front cover.
+func decodePicture(data []byte, version byte) (*Picture, bool) {
+	if len(data) < 2 {
+		return nil, false
+	}
+	enc := data[0]
+	rest := data[1:]
+
+	var mimeType string
+	if version == 2 {
+		if len(rest) < 3 {
+			return nil, false
+		}
+		mimeType = "image/" + strings.ToLower(string(rest[:3]))
+		if mimeType == "image/jpg" {
+			mimeType = "image/jpeg"
+		}
+		rest = rest[3:]
+	} else {
+		end := bytes.IndexByte(rest, 0)
+		if end < 0 {
+			return nil, false
+		}
+		mimeType = strings.ToLower(string(rest[:end]))
+		rest = rest[end+1:]
+	}
+
+	// "-->" marks a linked rather than embedded image
+	if mimeType == "-->" || len(rest) < 1 {
+		return nil, false
+	}
+
+	pictureType := rest[0]
+	_, imageData := splitString(rest[1:], enc)
+	if len(imageData) == 0 {
+		return nil, false
+	}
+
+	if mimeType == "" || mimeType == "image/" {
+		mimeType = "image/jpeg"
+	}
+
+	return &Picture{MIMEType: mimeType, Data: imageData}, pictureType == 3
+}
+
+// splitString decodes a terminated string in the given encoding and returns
+// it with the remaining bytes. Unterminated strings consume all of b.
+func splitString(b []byte, enc byte) (string, []byte) {
+	switch enc {
+	case 1, 2: // UTF-16 with BOM, UTF-16BE
+		end := len(b)
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] == 0 {
+				end = i
+				break
+			}
+		}
+		rest := b[min(end+2, len(b)):]
+		return decodeUTF16(b[:end], enc == 2), rest
+	default: // ISO-8859-1, UTF-8
+		end := bytes.IndexByte(b, 0)
+		if end < 0 {
+			end = len(b)
+		}
+		rest := b[min(end+1, len(b)):]
+		if enc == 3 {
+			return string(b[:end]), rest
+		}
+		return decodeLatin1(b[:end]), rest
+	}
+}
+
+// decodeUTF16 decodes UTF-16 text, honouring a byte order mark if present
+func decodeUTF16(b []byte, bigEndian bool) string {
+	if len(b) >= 2 {
+		switch {
+		case b[0] == 0xFF && b[1] == 0xFE:
+			bigEndian = false
+			b = b[2:]
+		case b[0] == 0xFE && b[1] == 0xFF:
+			bigEndian = true
+			b = b[2:]
+		}
+	}
+
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		if bigEndian {
+			units = append(units, binary.BigEndian.Uint16(b[i:]))
+		} else {
+			units = append(units, binary.LittleEndian.Uint16(b[i:]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodeLatin1 decodes ISO-8859-1 text
+func decodeLatin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// readID3v1 fills empty fields from a trailing ID3v1/v1.1 tag
+func readID3v1(r io.ReadSeeker, size int64, tags *Tags) error {
+	if size < 128 {
+		return nil
+	}
+	if _, err := r.Seek(size-128, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+
+	tag := make([]byte, 128)
+	if _, err := io.ReadFull(r, tag); err != nil {
+		return fmt.Errorf("failed to read ID3v1 tag: %w", err)
+	}
+	if string(tag[:3]) != "TAG" {
+		return nil
+	}
+
+	field := func(b []byte) string {
+		return strings.TrimSpace(decodeLatin1(bytes.TrimRight(b, "\x00")))
+	}
+
+	if tags.Title == "" {
+		tags.Title = field(tag[3:33])
+	}
+	if tags.Year == "" {
+		tags.Year = field(tag[93:97])
+	}
+
+	comment := tag[97:127]
+	// ID3v1.1 stores the track number in the last comment byte
+	if comment[28] == 0 && comment[29] != 0 {
+		if tags.Track == 0 {
+			tags.Track = int(comment[29])
+		}
+		comment = comment[:28]
+	}
+	if tags.Comment == "" {
+		tags.Comment = field(comment)
+	}
+
+	return nil
+}
+
+// parseTrack parses "3" or "3/12" into 3
+func parseTrack(s string) int {
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		s = s[:i]
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// firstN returns at most the first n bytes of s
+func firstN(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,324 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// AudioInfo describes the decoded properties of an audio stream
+type AudioInfo struct {
+	Duration   time.Duration // playback length
+	Bitrate    int           // average bitrate in kbps
+	SampleRate int           // samples per second
+}
+
+// ErrNoFrames is returned when no valid audio frames are found
+var ErrNoFrames = errors.New("no audio frames found")
+
+// maxSyncSearch bounds how far past the ID3 tag we look for the first frame
+const maxSyncSearch = 64 * 1024
+
+// mp3Bitrates holds bitrates in kbps indexed by [version row][layer][index].
+// Row 0 is MPEG-1, row 1 is MPEG-2 and MPEG-2.5; layer index 0 is Layer I.
+var mp3Bitrates = [2][3][16]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	},
+}
+
+// mp3SampleRates is indexed by [version bits][index]; version bits 1 is reserved
+var mp3SampleRates = [4][3]int{
+	{11025, 12000, 8000},  // MPEG-2.5
+	{0, 0, 0},             // reserved
+	{22050, 24000, 16000}, // MPEG-2
+	{44100, 48000, 32000}, // MPEG-1
+}
+
+// mp3Frame is a decoded MPEG audio frame header
+type mp3Frame struct {
+	mpeg1      bool
+	layer      int // 1, 2 or 3
+	bitrate    int // kbps
+	sampleRate int
+	padding    int
+	mono       bool
+}
+
+// parseMP3Header decodes a 4-byte frame header
+func parseMP3Header(h []byte) (mp3Frame, bool) {
+	if len(h) < 4 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+
+	version := int(h[1]>>3) & 0x03
+	layerBits := int(h[1]>>1) & 0x03
+	bitrateIdx := int(h[2] >> 4)
+	rateIdx := int(h[2]>>2) & 0x03
+
+	if version == 1 || layerBits == 0 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+		return mp3Frame{}, false
+	}
+
+	f := mp3Frame{
+		mpeg1:      version == 3,
+		layer:      4 - layerBits,
+		sampleRate: mp3SampleRates[version][rateIdx],
+		padding:    int(h[2]>>1) & 0x01,
+		mono:       h[3]>>6 == 3,
+	}
+
+	row := 1
+	if f.mpeg1 {
+		row = 0
+	}
+	f.bitrate = mp3Bitrates[row][f.layer-1][bitrateIdx]
+
+	return f, true
+}
+
+// samples returns the number of PCM samples per frame
+func (f mp3Frame) samples() int {
+	switch {
+	case f.layer == 1:
+		return 384
+	case f.layer == 3 && !f.mpeg1:
+		return 576
+	default:
+		return 1152
+	}
+}
+
+// size returns the frame length in bytes, including the header
+func (f mp3Frame) size() int {
+	if f.layer == 1 {
+		return (12*f.bitrate*1000/f.sampleRate + f.padding) * 4
+	}
+	return f.samples()/8*f.bitrate*1000/f.sampleRate + f.padding
+}
+
+// sideInfoSize returns the Layer III side information length, which is
+// where a Xing/Info header starts after the frame header
+func (f mp3Frame) sideInfoSize() int {
+	switch {
+	case f.mpeg1 && f.mono:
+		return 17
+	case f.mpeg1:
+		return 32
+	case f.mono:
+		return 9
+	default:
+		return 17
+	}
+}
+
+// ProbeMP3 computes the duration and average bitrate of an MP3 stream of
+// the given total size. VBR streams are measured from their Xing/Info or
+// VBRI header; streams without one are measured by counting frames.
+func ProbeMP3(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	audioEnd := size
+	if hasID3v1(r, size) {
+		audioEnd -= 128
+	}
+
+	start, err := skipID3v2(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Locate the first frame
+	buf := make([]byte, maxSyncSearch)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read audio data: %w", err)
+	}
+	buf = buf[:n]
+
+	offset, first, ok := findFrame(buf)
+	if !ok {
+		return nil, ErrNoFrames
+	}
+	audioStart := start + int64(offset)
+
+	// Prefer the VBR header written by the encoder
+	if frames, bytesTotal, ok := readVBRHeader(buf[offset:], first); ok && frames > 0 {
+		duration := frameDuration(first, frames)
+		if bytesTotal == 0 {
+			bytesTotal = audioEnd - audioStart
+		}
+		return newAudioInfo(duration, bytesTotal, first.sampleRate), nil
+	}
+
+	// Fall back to walking every frame
+	if _, err := r.Seek(audioStart, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to audio data: %w", err)
+	}
+	duration, err := countFrames(io.LimitReader(r, audioEnd-audioStart))
+	if err != nil {
+		return nil, err
+	}
+
+	return newAudioInfo(duration, audioEnd-audioStart, first.sampleRate), nil
+}
+
+// newAudioInfo derives the average bitrate from duration and payload size
+func newAudioInfo(duration time.Duration, bytesTotal int64, sampleRate int) *AudioInfo {
+	info := &AudioInfo{Duration: duration, SampleRate: sampleRate}
+	if duration > 0 {
+		info.Bitrate = int(float64(bytesTotal*8) / duration.Seconds() / 1000)
+	}
+	return info
+}
+
+// frameDuration returns the playback time of n frames shaped like f
+func frameDuration(f mp3Frame, n int64) time.Duration {
+	return time.Duration(float64(n) * float64(f.samples()) / float64(f.sampleRate) * float64(time.Second))
+}
+
+// skipID3v2 positions r after a leading ID3v2 tag and returns the new offset
+func skipID3v2(r io.ReadSeeker) (int64, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek: %w", err)
+	}
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	var start int64
+	if bytes.Equal(header[:3], []byte("ID3")) {
+		start = 10 + int64(syncsafe(header[6:10]))
+		if header[5]&0x10 != 0 {
+			start += 10 // footer present
+		}
+	}
+
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to skip ID3 tag: %w", err)
+	}
+	return start, nil
+}
+
+// hasID3v1 reports whether the stream ends with a 128-byte ID3v1 tag
+func hasID3v1(r io.ReadSeeker, size int64) bool {
+	if size < 128 {
+		return false
+	}
+	tag := make([]byte, 3)
+	if _, err := r.Seek(size-128, io.SeekStart); err != nil {
+		return false
+	}
+	if _, err := io.ReadFull(r, tag); err != nil {
+		return false
+	}
+	return string(tag) == "TAG"
+}
+
+// syncsafe decodes a 28-bit ID3 syncsafe integer
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// findFrame returns the offset of the first frame header in buf. When the
+// buffer holds the following frame too, it must also be a valid header so
+// stray 0xFF bytes are not mistaken for a sync word.
+func findFrame(buf []byte) (int, mp3Frame, bool) {
+	for i := 0; i+4 <= len(buf); i++ {
+		f, ok := parseMP3Header(buf[i:])
+		if !ok {
+			continue
+		}
+
+		next := i + f.size()
+		if next+4 <= len(buf) {
+			if _, ok := parseMP3Header(buf[next:]); !ok {
+				continue
+			}
+		}
+		return i, f, true
+	}
+	return 0, mp3Frame{}, false
+}
+
+// readVBRHeader extracts the frame and byte counts from a Xing/Info or VBRI
+// header in the first frame
+func readVBRHeader(frame []byte, f mp3Frame) (frames int64, bytesTotal int64, ok bool) {
+	// Xing/Info header follows the side information
+	if pos := 4 + f.sideInfoSize(); pos+16 <= len(frame) {
+		tag := string(frame[pos : pos+4])
+		if tag == "Xing" || tag == "Info" {
+			flags := binary.BigEndian.Uint32(frame[pos+4:])
+			p := pos + 8
+			if flags&0x1 != 0 {
+				frames = int64(binary.BigEndian.Uint32(frame[p:]))
+				p += 4
+			}
+			if flags&0x2 != 0 && p+4 <= len(frame) {
+				bytesTotal = int64(binary.BigEndian.Uint32(frame[p:]))
+			}
+			return frames, bytesTotal, flags&0x1 != 0
+		}
+	}
+
+	// VBRI header sits at a fixed 32 bytes after the frame header
+	if pos := 4 + 32; pos+18 <= len(frame) && string(frame[pos:pos+4]) == "VBRI" {
+		bytesTotal = int64(binary.BigEndian.Uint32(frame[pos+10:]))
+		frames = int64(binary.BigEndian.Uint32(frame[pos+14:]))
+		return frames, bytesTotal, true
+	}
+
+	return 0, 0, false
+}
+
+// countFrames walks the stream frame by frame and sums their durations
+func countFrames(r io.Reader) (time.Duration, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+
+	var total time.Duration
+	var frames int
+
+	for {
+		header, err := br.Peek(4)
+		if err != nil {
+			break
+		}
+
+		f, ok := parseMP3Header(header)
+		if !ok {
+			// Resync one byte at a time (handles junk between frames)
+			br.Discard(1)
+			continue
+		}
+
+		// A truncated final frame still counts as played audio
+		total += frameDuration(f, 1)
+		frames++
+
+		if _, err := br.Discard(f.size()); err != nil {
+			break
+		}
+	}
+
+	if frames == 0 {
+		return 0, ErrNoFrames
+	}
+	return total, nil
+}
+
+// FormatDuration formats a duration as HH:MM:SS for itunes:duration
+func FormatDuration(d time.Duration) string {
+	seconds := int64(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
@@ -0,0 +1,182 @@
+package media
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// mp4Box is an ISO base media box located by its body offsets
+type mp4Box struct {
+	kind  string
+	start int64 // first byte after the box header
+	end   int64
+}
+
+// mp4Boxes lists the boxes between start and end
+func mp4Boxes(r io.ReadSeeker, start, end int64) ([]mp4Box, error) {
+	var boxes []mp4Box
+	header := make([]byte, 16)
+
+	for pos := start; pos+8 <= end; {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek: %w", err)
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return nil, fmt.Errorf("failed to read box header: %w", err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header))
+		headerSize := int64(8)
+		switch size {
+		case 0: // box extends to the end
+			size = end - pos
+		case 1: // 64-bit size follows
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return nil, fmt.Errorf("failed to read box size: %w", err)
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if size < headerSize || pos+size > end {
+			break
+		}
+
+		boxes = append(boxes, mp4Box{kind: string(header[4:8]), start: pos + headerSize, end: pos + size})
+		pos += size
+	}
+
+	return boxes, nil
+}
+
+// findMP4Box returns the first box of the given kind
+func findMP4Box(boxes []mp4Box, kind string) (mp4Box, bool) {
+	for _, b := range boxes {
+		if b.kind == kind {
+			return b, true
+		}
+	}
+	return mp4Box{}, false
+}
+
+// readMP4Body reads up to n bytes of a box body
+func readMP4Body(r io.ReadSeeker, b mp4Box, n int) ([]byte, error) {
+	n = int(min(int64(n), b.end-b.start))
+	buf := make([]byte, n)
+	if _, err := r.Seek(b.start, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("failed to read %s box: %w", b.kind, err)
+	}
+	return buf, nil
+}
+
+// parseMP4Header decodes the timescale and duration of an mvhd or mdhd box
+func parseMP4Header(body []byte) (timescale uint32, duration uint64, ok bool) {
+	if len(body) < 4 {
+		return 0, 0, false
+	}
+	if body[0] == 1 {
+		// version, flags, 64-bit creation and modification times
+		if len(body) < 32 {
+			return 0, 0, false
+		}
+		return binary.BigEndian.Uint32(body[20:24]), binary.BigEndian.Uint64(body[24:32]), true
+	}
+	if len(body) < 20 {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint32(body[12:16]), uint64(binary.BigEndian.Uint32(body[16:20])), true
+}
+
+// ProbeMP4 computes the duration and average bitrate of an MP4/M4A file
+// from its first sound track, falling back to the movie header
+func ProbeMP4(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	top, err := mp4Boxes(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+
+	moov, ok := findMP4Box(top, "moov")
+	if !ok {
+		return nil, fmt.Errorf("missing moov box")
+	}
+	children, err := mp4Boxes(r, moov.start, moov.end)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, trak := range children {
+		if trak.kind != "trak" {
+			continue
+		}
+		timescale, duration, ok, err := soundTrackHeader(r, trak)
+		if err != nil {
+			return nil, err
+		}
+		if ok && timescale > 0 {
+			return mp4Info(timescale, duration, size, int(timescale)), nil
+		}
+	}
+
+	mvhd, ok := findMP4Box(children, "mvhd")
+	if !ok {
+		return nil, fmt.Errorf("missing mvhd box")
+	}
+	body, err := readMP4Body(r, mvhd, 32)
+	if err != nil {
+		return nil, err
+	}
+	timescale, duration, ok := parseMP4Header(body)
+	if !ok || timescale == 0 {
+		return nil, fmt.Errorf("invalid mvhd box")
+	}
+	return mp4Info(timescale, duration, size, 0), nil
+}
+
+// soundTrackHeader returns the media header of a trak whose handler is
+// "soun"; for audio the media timescale is the sample rate
+func soundTrackHeader(r io.ReadSeeker, trak mp4Box) (uint32, uint64, bool, error) {
+	boxes, err := mp4Boxes(r, trak.start, trak.end)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	mdia, ok := findMP4Box(boxes, "mdia")
+	if !ok {
+		return 0, 0, false, nil
+	}
+	if boxes, err = mp4Boxes(r, mdia.start, mdia.end); err != nil {
+		return 0, 0, false, err
+	}
+
+	hdlr, ok := findMP4Box(boxes, "hdlr")
+	if !ok {
+		return 0, 0, false, nil
+	}
+	body, err := readMP4Body(r, hdlr, 12)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	// version/flags, pre_defined, handler_type
+	if len(body) < 12 || string(body[8:12]) != "soun" {
+		return 0, 0, false, nil
+	}
+
+	mdhd, ok := findMP4Box(boxes, "mdhd")
+	if !ok {
+		return 0, 0, false, nil
+	}
+	if body, err = readMP4Body(r, mdhd, 32); err != nil {
+		return 0, 0, false, err
+	}
+	timescale, duration, ok := parseMP4Header(body)
+	return timescale, duration, ok, nil
+}
+
+// mp4Info converts a timescale-based duration into AudioInfo
+func mp4Info(timescale uint32, duration uint64, size int64, sampleRate int) *AudioInfo {
+	d := time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+	return newAudioInfo(d, size, sampleRate)
+}
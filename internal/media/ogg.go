@@ -0,0 +1,93 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// opusSampleRate is the rate Opus granule positions are counted in
+const opusSampleRate = 48000
+
+// oggFirstPacket returns the serial number and first packet of the Ogg page
+// at the start of buf
+func oggFirstPacket(buf []byte) (uint32, []byte, bool) {
+	if len(buf) < 27 || string(buf[:4]) != "OggS" {
+		return 0, nil, false
+	}
+
+	segments := int(buf[26])
+	if len(buf) < 27+segments {
+		return 0, nil, false
+	}
+
+	var packetSize int
+	for _, lacing := range buf[27 : 27+segments] {
+		packetSize += int(lacing)
+		if lacing < 255 {
+			break
+		}
+	}
+
+	start := 27 + segments
+	if len(buf) < start+packetSize {
+		return 0, nil, false
+	}
+	return binary.LittleEndian.Uint32(buf[14:18]), buf[start : start+packetSize], true
+}
+
+// ProbeOgg computes the duration and average bitrate of an Ogg Opus or Ogg
+// Vorbis stream from the granule position of its last page
+func ProbeOgg(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	head, err := readHead(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, packet, ok := oggFirstPacket(head)
+	if !ok {
+		return nil, fmt.Errorf("missing Ogg identification header")
+	}
+
+	var sampleRate int
+	var preSkip int64
+	switch {
+	case bytes.HasPrefix(packet, []byte("OpusHead")) && len(packet) >= 19:
+		sampleRate = opusSampleRate
+		preSkip = int64(binary.LittleEndian.Uint16(packet[10:12]))
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")) && len(packet) >= 16:
+		sampleRate = int(binary.LittleEndian.Uint32(packet[12:16]))
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if sampleRate == 0 {
+		return nil, ErrNoFrames
+	}
+
+	// The last page of the stream carries the total sample count
+	tailStart := max(size-maxSyncSearch, 0)
+	tail, err := readHead(r, tailStart)
+	if err != nil {
+		return nil, err
+	}
+
+	granule := int64(-1)
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		page := tail[i:]
+		if len(page) < 27 || binary.LittleEndian.Uint32(page[14:18]) != serial {
+			continue
+		}
+		if g := int64(binary.LittleEndian.Uint64(page[6:14])); g >= 0 {
+			granule = g
+			break
+		}
+	}
+	if granule <= preSkip {
+		return nil, ErrNoFrames
+	}
+
+	duration := time.Duration(float64(granule-preSkip) / float64(sampleRate) * float64(time.Second))
+	return newAudioInfo(duration, size, sampleRate), nil
+}
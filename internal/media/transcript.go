@@ -0,0 +1,269 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Transcript formats, named by their file extension
+const (
+	TranscriptSRT  = "srt"
+	TranscriptVTT  = "vtt"
+	TranscriptText = "txt"
+)
+
+// ErrInvalidTranscript is returned for transcripts that cannot be parsed
+var ErrInvalidTranscript = errors.New("invalid transcript")
+
+// Cue is one timed caption
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// TranscriptContentType returns the MIME type of a transcript format
+func TranscriptContentType(format string) string {
+	switch format {
+	case TranscriptSRT:
+		return "application/x-subrip"
+	case TranscriptVTT:
+		return "text/vtt"
+	case TranscriptText:
+		return "text/plain"
+	default:
+		return ""
+	}
+}
+
+// DetectTranscriptFormat identifies a transcript from its content, falling
+// back to the file extension. It returns "" when neither is recognized.
+func DetectTranscriptFormat(name string, data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	if bytes.HasPrefix(data, []byte("WEBVTT")) {
+		return TranscriptVTT
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".srt":
+		return TranscriptSRT
+	case ".vtt":
+		return TranscriptVTT
+	case ".txt", ".text":
+		return TranscriptText
+	}
+
+	if srtTiming.Match(data) {
+		return TranscriptSRT
+	}
+	return ""
+}
+
+// srtTiming matches an SRT cue timing line
+var srtTiming = regexp.MustCompile(`(?m)^\s*\d+:\d{2}:\d{2}[,.]\d{1,3}\s*-->\s*\d+:\d{2}:\d{2}[,.]\d{1,3}`)
+
+// NormalizeTranscript validates a transcript and rewrites it in canonical
+// form: UTF-8 without a byte order mark, LF line endings, and for timed
+// formats renumbered cues with zero-padded timestamps
+func NormalizeTranscript(format string, data []byte) ([]byte, error) {
+	text, err := cleanText(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case TranscriptSRT, TranscriptVTT:
+		cues, err := parseCues(format, text)
+		if err != nil {
+			return nil, err
+		}
+		return formatCues(format, cues), nil
+	case TranscriptText:
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, fmt.Errorf("%w: empty transcript", ErrInvalidTranscript)
+		}
+		return []byte(text + "\n"), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidTranscript, format)
+	}
+}
+
+// ConvertTranscript converts a normalized transcript between formats.
+// Timed formats convert to each other and to plain text; plain text
+// cannot be converted to a timed format.
+func ConvertTranscript(data []byte, from, to string) ([]byte, error) {
+	if from == to {
+		return data, nil
+	}
+	if from == TranscriptText {
+		return nil, fmt.Errorf("%w: plain text has no timings to convert to %s", ErrInvalidTranscript, to)
+	}
+
+	text, err := cleanText(data)
+	if err != nil {
+		return nil, err
+	}
+	cues, err := parseCues(from, text)
+	if err != nil {
+		return nil, err
+	}
+
+	switch to {
+	case TranscriptSRT, TranscriptVTT:
+		return formatCues(to, cues), nil
+	case TranscriptText:
+		var buf strings.Builder
+		for _, cue := range cues {
+			buf.WriteString(strings.TrimSpace(markupTag.ReplaceAllString(cue.Text, "")))
+			buf.WriteByte('\n')
+		}
+		return []byte(buf.String()), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidTranscript, to)
+	}
+}
+
+// markupTag matches WebVTT/SRT inline markup such as <v Speaker> or <i>
+var markupTag = regexp.MustCompile(`<[^>]*>`)
+
+// cleanText decodes a transcript as UTF-8 text with LF line endings
+func cleanText(data []byte) (string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("%w: not valid UTF-8", ErrInvalidTranscript)
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n"), nil
+}
+
+// parseCues parses SRT or WebVTT cues. Cue numbers and identifiers, cue
+// settings and WebVTT NOTE, STYLE and REGION blocks are dropped.
+func parseCues(format, text string) ([]Cue, error) {
+	blocks := strings.Split(strings.TrimSpace(text), "\n\n")
+
+	if format == TranscriptVTT {
+		if !strings.HasPrefix(blocks[0], "WEBVTT") {
+			return nil, fmt.Errorf("%w: missing WEBVTT header", ErrInvalidTranscript)
+		}
+		blocks = blocks[1:]
+	}
+
+	var cues []Cue
+	for _, block := range blocks {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		if len(lines) == 0 || lines[0] == "" {
+			continue
+		}
+		if format == TranscriptVTT && (strings.HasPrefix(lines[0], "NOTE") || lines[0] == "STYLE" || lines[0] == "REGION") {
+			continue
+		}
+
+		// The timing line is first, or second after a number or identifier
+		timing := 0
+		if !strings.Contains(lines[0], "-->") {
+			timing = 1
+		}
+		if timing >= len(lines) || !strings.Contains(lines[timing], "-->") {
+			return nil, fmt.Errorf("%w: cue %d has no timing line", ErrInvalidTranscript, len(cues)+1)
+		}
+
+		start, end, err := parseTiming(lines[timing])
+		if err != nil {
+			return nil, fmt.Errorf("%w: cue %d: %v", ErrInvalidTranscript, len(cues)+1, err)
+		}
+
+		cues = append(cues, Cue{
+			Start: start,
+			End:   end,
+			Text:  strings.TrimSpace(strings.Join(lines[timing+1:], "\n")),
+		})
+	}
+
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("%w: no cues", ErrInvalidTranscript)
+	}
+	return cues, nil
+}
+
+// parseTiming parses "start --> end [settings]"
+func parseTiming(line string) (time.Duration, time.Duration, error) {
+	startStr, rest, _ := strings.Cut(line, "-->")
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("missing end time")
+	}
+
+	start, err := parseTimestamp(strings.TrimSpace(startStr))
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseTimestamp(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("end time %s is before start time %s", fields[0], strings.TrimSpace(startStr))
+	}
+	return start, end, nil
+}
+
+// parseTimestamp parses [hh:]mm:ss.mmm, accepting "," or "." before the
+// milliseconds
+func parseTimestamp(s string) (time.Duration, error) {
+	clock, frac, ok := strings.Cut(strings.Replace(s, ",", ".", 1), ".")
+	if !ok || len(frac) == 0 || len(frac) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	parts := strings.Split(clock, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	var total time.Duration
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (i > 0 && (len(part) != 2 || n > 59)) {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		total = total*60 + time.Duration(n)
+	}
+
+	ms, err := strconv.Atoi(frac + strings.Repeat("0", 3-len(frac)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	return total*time.Second + time.Duration(ms)*time.Millisecond, nil
+}
+
+// formatCues writes cues as SRT or WebVTT
+func formatCues(format string, cues []Cue) []byte {
+	var buf bytes.Buffer
+	sep := ","
+	if format == TranscriptVTT {
+		sep = "."
+		buf.WriteString("WEBVTT\n\n")
+	}
+
+	for i, cue := range cues {
+		if format == TranscriptSRT {
+			fmt.Fprintf(&buf, "%d\n", i+1)
+		}
+		fmt.Fprintf(&buf, "%s --> %s\n%s\n\n", formatTimestamp(cue.Start, sep), formatTimestamp(cue.End, sep), cue.Text)
+	}
+	return buf.Bytes()
+}
+
+// formatTimestamp writes hh:mm:ss{sep}mmm
+func formatTimestamp(d time.Duration, sep string) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
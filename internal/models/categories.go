@@ -0,0 +1,49 @@
+package models
+
+// ITunesCategory is an Apple Podcasts category and its subcategories
+type ITunesCategory struct {
+	Name          string
+	Subcategories []string
+}
+
+// ITunesCategories is the Apple Podcasts category taxonomy
+var ITunesCategories = []ITunesCategory{
+	{Name: "Arts", Subcategories: []string{"Books", "Design", "Fashion & Beauty", "Food", "Performing Arts", "Visual Arts"}},
+	{Name: "Business", Subcategories: []string{"Careers", "Entrepreneurship", "Investing", "Management", "Marketing", "Non-Profit"}},
+	{Name: "Comedy", Subcategories: []string{"Comedy Interviews", "Improv", "Stand-Up"}},
+	{Name: "Education", Subcategories: []string{"Courses", "How To", "Language Learning", "Self-Improvement"}},
+	{Name: "Fiction", Subcategories: []string{"Comedy Fiction", "Drama", "Science Fiction"}},
+	{Name: "Government"},
+	{Name: "History"},
+	{Name: "Health & Fitness", Subcategories: []string{"Alternative Health", "Fitness", "Medicine", "Mental Health", "Nutrition", "Sexuality"}},
+	{Name: "Kids & Family", Subcategories: []string{"Education for Kids", "Parenting", "Pets & Animals", "Stories for Kids"}},
+	{Name: "Leisure", Subcategories: []string{"Animation & Manga", "Automotive", "Aviation", "Crafts", "Games", "Hobbies", "Home & Garden", "Video Games"}},
+	{Name: "Music", Subcategories: []string{"Music Commentary", "Music History", "Music Interviews"}},
+	{Name: "News", Subcategories: []string{"Business News", "Daily News", "Entertainment News", "News Commentary", "Politics", "Sports News", "Tech News"}},
+	{Name: "Religion & Spirituality", Subcategories: []string{"Buddhism", "Christianity", "Hinduism", "Islam", "Judaism", "Religion", "Spirituality"}},
+	{Name: "Science", Subcategories: []string{"Astronomy", "Chemistry", "Earth Sciences", "Life Sciences", "Mathematics", "Natural Sciences", "Nature", "Physics", "Social Sciences"}},
+	{Name: "Society & Culture", Subcategories: []string{"Documentary", "Personal Journals", "Philosophy", "Places & Travel", "Relationships"}},
+	{Name: "Sports", Subcategories: []string{"Baseball", "Basketball", "Cricket", "Fantasy Sports", "Football", "Golf", "Hockey", "Rugby", "Running", "Soccer", "Swimming", "Tennis", "Volleyball", "Wilderness", "Wrestling"}},
+	{Name: "Technology"},
+	{Name: "True Crime"},
+	{Name: "TV & Film", Subcategories: []string{"After Shows", "Film History", "Film Interviews", "Film Reviews", "TV Reviews"}},
+}
+
+// ValidSubcategory reports whether sub is a subcategory of category.
+// An empty subcategory is always valid.
+func ValidSubcategory(category, sub string) bool {
+	if sub == "" {
+		return true
+	}
+	for _, c := range ITunesCategories {
+		if c.Name != category {
+			continue
+		}
+		for _, s := range c.Subcategories {
+			if s == sub {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -13,6 +13,9 @@ type Episode struct {
 	PubDate     time.Time `json:"pubDate"`
 	GUID        string    `json:"guid"`
 
+	// Publication status; only published episodes appear in the feed
+	Status string `json:"status,omitempty"`
+
 	// Enclosure (audio file)
 	AudioURL    string `json:"audioURL"`
 	AudioLength int64  `json:"audioLength"` // bytes
@@ -24,10 +27,61 @@ type Episode struct {
 	EpisodeNum  int    `json:"episodeNum,omitempty"`  // episode number
 	SeasonNum   int    `json:"seasonNum,omitempty"`   // season number
 	EpisodeType string `json:"episodeType,omitempty"` // "full", "trailer", "bonus"
+	ImageURL    string `json:"imageURL,omitempty"`    // per-episode artwork
+
+	// Podcasting 2.0 fields
+	Persons  []Person  `json:"persons,omitempty"`
+	Location *Location `json:"location,omitempty"`
+	License  *License  `json:"license,omitempty"`
+
+	// Chapter markers; ChaptersURL points at an external chapters document
+	// (e.g. from an imported feed) and is only used when Chapters is empty
+	Chapters    []Chapter `json:"chapters,omitempty"`
+	ChaptersURL string    `json:"chaptersURL,omitempty"`
+
+	// Transcript is the uploaded transcript; TranscriptLinks reference
+	// external transcripts and are only used when Transcript is nil
+	Transcript      *Transcript      `json:"transcript,omitempty"`
+	TranscriptLinks []TranscriptLink `json:"transcriptLinks,omitempty"`
 
 	// Metadata for internal use
-	Filename   string    `json:"filename"`   // Audio filename on disk
-	UploadDate time.Time `json:"uploadDate"` // When episode was added
+	Filename   string    `json:"filename"`          // Audio filename on disk
+	Bitrate    int       `json:"bitrate,omitempty"` // Average bitrate in kbps
+	UploadDate time.Time `json:"uploadDate"`        // When episode was added
+}
+
+// Episode publication statuses. Episodes stored before statuses existed
+// have an empty status and count as published.
+const (
+	StatusDraft     = "draft"     // not in the feed until published by hand
+	StatusScheduled = "scheduled" // published automatically at PubDate
+	StatusPublished = "published"
+)
+
+// Published reports whether the episode belongs in the public feed
+func (e Episode) Published() bool {
+	return e.Status == "" || e.Status == StatusPublished
+}
+
+// Scheduled reports whether the episode is waiting for its publish time
+func (e Episode) Scheduled() bool {
+	return e.Status == StatusScheduled
+}
+
+// ScheduleStatus returns the status for a non-draft episode published at
+// pubDate: scheduled while pubDate is in the future, published after
+func ScheduleStatus(pubDate, now time.Time) string {
+	if pubDate.After(now) {
+		return StatusScheduled
+	}
+	return StatusPublished
+}
+
+// TrashedEpisode is a deleted episode kept in the trash until it is
+// restored or purged
+type TrashedEpisode struct {
+	Episode
+	DeletedAt time.Time `json:"deletedAt"`
 }
 
 // AudioFile represents the actual audio file stored by the system
@@ -35,12 +89,13 @@ type AudioFile struct {
 	// Storage information
 	Filename     string // Stored filename (unique, URL-safe)
 	OriginalName string // Original uploaded filename
-	FilePath     string // Full path on disk
+	URL          string // URL the file is served from
 
 	// File properties
 	Size     int64  // File size in bytes
 	MimeType string // MIME type (e.g., "audio/mpeg")
 	Duration string // Calculated duration "HH:MM:SS"
+	Bitrate  int    // Average bitrate in kbps
 
 	// Metadata
 	UploadDate time.Time // When file was uploaded
@@ -12,12 +12,31 @@ type Podcast struct {
 	PubDate     time.Time `json:"pubDate"`
 
 	// iTunes-specific fields
-	Author   string `json:"author"`
-	Subtitle string `json:"subtitle,omitempty"`
-	Summary  string `json:"summary,omitempty"`
-	ImageURL string `json:"imageURL,omitempty"`
-	Explicit string `json:"explicit,omitempty"` // "yes", "no", "clean"
-	Category string `json:"category,omitempty"`
+	Author      string `json:"author"`
+	Subtitle    string `json:"subtitle,omitempty"`
+	Summary     string `json:"summary,omitempty"`
+	ImageURL    string `json:"imageURL,omitempty"`
+	Explicit    string `json:"explicit,omitempty"` // "yes", "no", "clean"
+	Category    string `json:"category,omitempty"`
+	Subcategory string `json:"subcategory,omitempty"` // nested under Category
+
+	// Directory ownership and listing controls
+	OwnerName  string `json:"ownerName,omitempty"`
+	OwnerEmail string `json:"ownerEmail,omitempty"` // used by directories to verify ownership
+	Type       string `json:"type,omitempty"`       // "episodic", "serial"
+	Copyright  string `json:"copyright,omitempty"`
+	Block      bool   `json:"block,omitempty"`      // hide the show from directories
+	Complete   bool   `json:"complete,omitempty"`   // no more episodes will be published
+	NewFeedURL string `json:"newFeedURL,omitempty"` // tells directories the feed has moved
+
+	// Podcasting 2.0 fields
+	GUID     string    `json:"guid,omitempty"`   // podcast:guid; derived from the feed URL when empty
+	Locked   bool      `json:"locked,omitempty"` // other platforms may not import the feed
+	Funding  []Funding `json:"funding,omitempty"`
+	Persons  []Person  `json:"persons,omitempty"`
+	Location *Location `json:"location,omitempty"`
+	Trailers []Trailer `json:"trailers,omitempty"`
+	License  *License  `json:"license,omitempty"`
 
 	// Episode list
 	Episodes []Episode `json:"episodes"`
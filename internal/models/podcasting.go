@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Types for the Podcasting 2.0 namespace (https://podcastindex.org/namespace/1.0)
+
+// Funding is a donation or membership link shown by supporting apps
+type Funding struct {
+	URL   string `json:"url"`
+	Label string `json:"label,omitempty"` // at most 128 characters
+}
+
+// Person is someone involved in the show or an episode
+type Person struct {
+	Name  string `json:"name"`
+	Role  string `json:"role,omitempty"`  // e.g. "host", "guest"; defaults to "host"
+	Group string `json:"group,omitempty"` // e.g. "cast", "writing"; defaults to "cast"
+	Img   string `json:"img,omitempty"`   // picture URL
+	Href  string `json:"href,omitempty"`  // homepage or profile URL
+}
+
+// Location is what the show or episode is about, not where it was recorded
+type Location struct {
+	Name string `json:"name"`
+	Geo  string `json:"geo,omitempty"` // RFC 5870 URI, e.g. "geo:30.2672,97.7431"
+	OSM  string `json:"osm,omitempty"` // OpenStreetMap object, e.g. "R113314"
+}
+
+// License is the content license, an SPDX identifier or a name with a URL
+type License struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// Trailer is a trailer or teaser for the show
+type Trailer struct {
+	Title   string    `json:"title"`
+	URL     string    `json:"url"`
+	PubDate time.Time `json:"pubDate"`
+	Length  int64     `json:"length,omitempty"` // bytes
+	Type    string    `json:"type,omitempty"`   // MIME type
+	Season  int       `json:"season,omitempty"`
+}
+
+// Chapter is a chapter marker, served as a JSON chapters document
+type Chapter struct {
+	StartTime float64 `json:"startTime"`         // seconds from the start of the audio
+	EndTime   float64 `json:"endTime,omitempty"` // seconds; optional
+	Title     string  `json:"title"`
+	URL       string  `json:"url,omitempty"`
+	Img       string  `json:"img,omitempty"`
+}
+
+// Transcript is a transcript file stored beside the episode's audio
+type Transcript struct {
+	Filename string `json:"filename"`           // blob name in the audio store
+	Format   string `json:"format"`             // "srt", "vtt" or "txt"
+	Language string `json:"language,omitempty"` // e.g. "en"
+}
+
+// TranscriptLink references an externally hosted transcript
+type TranscriptLink struct {
+	URL      string `json:"url"`
+	Type     string `json:"type"`
+	Language string `json:"language,omitempty"`
+	Rel      string `json:"rel,omitempty"` // "captions" for timed transcripts
+}
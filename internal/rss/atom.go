@@ -0,0 +1,118 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+)
+
+// AtomType is the MIME type of the Atom feed
+const AtomType = "application/atom+xml"
+
+// atomNS is the Atom (RFC 4287) namespace URI
+const atomNS = "http://www.w3.org/2005/Atom"
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"feed"`
+	NS        string      `xml:"xmlns,attr"`
+	Lang      string      `xml:"xml:lang,attr,omitempty"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle,omitempty"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Author    atomPerson  `xml:"author"`
+	Logo      string      `xml:"logo,omitempty"`
+	Rights    string      `xml:"rights,omitempty"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   string     `xml:"summary"`
+	Links     []atomLink `xml:"link"`
+}
+
+// GenerateAtom creates an Atom (RFC 4287) feed of the podcast's published
+// episodes for feed readers. Each entry links its audio both as the
+// alternate representation RFC 4287 requires of entries without content and
+// as an enclosure.
+// The feed announces hubs as its WebSub hubs.
+func GenerateAtom(p *models.Podcast, baseURL string, hubs ...string) ([]byte, error) {
+	p = publishedOnly(p)
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	pubDate := p.PubDate
+	if pubDate.IsZero() {
+		pubDate = time.Now()
+	}
+	guid := podcastGUID(p, baseURL)
+
+	feed := atomFeed{
+		NS:        atomNS,
+		Lang:      p.Language,
+		ID:        "urn:uuid:" + guid,
+		Title:     p.Title,
+		Subtitle:  p.Description,
+		Updated:   lastBuildDate(p, pubDate).UTC().Format(time.RFC3339),
+		Author:    atomPerson{Name: p.Author, Email: p.OwnerEmail},
+		Rights:    p.Copyright,
+		Generator: generator,
+		Links: []atomLink{
+			{Rel: "self", Type: AtomType, Href: baseURL + "/feed.atom"},
+		},
+	}
+	for _, hub := range hubs {
+		feed.Links = append(feed.Links, atomLink{Rel: "hub", Href: hub})
+	}
+	feed.Links = append(feed.Links, atomLink{Rel: "alternate", Type: "application/rss+xml", Href: baseURL + "/feed.xml"})
+	if feed.Author.Name == "" {
+		feed.Author.Name = p.Title
+	}
+	if p.Link != "" {
+		feed.Links = append(feed.Links, atomLink{Rel: "alternate", Type: "text/html", Href: p.Link})
+	}
+	if p.ImageURL != "" {
+		feed.Logo, _ = convertToAbsoluteURL(baseURL, p.ImageURL)
+	}
+
+	for _, e := range feedEntries(p, baseURL) {
+		date := e.PubDate.UTC().Format(time.RFC3339)
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:        entryID(guid, e.GUID),
+			Title:     e.Title,
+			Published: date,
+			Updated:   date,
+			Summary:   e.Description,
+			Links: []atomLink{
+				{Rel: "alternate", Type: enclosureType(e.Episode), Href: e.audio},
+				{Rel: "enclosure", Type: enclosureType(e.Episode), Href: e.audio, Length: max(e.AudioLength, 0)},
+			},
+		})
+	}
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Atom feed: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
@@ -0,0 +1,59 @@
+package rss
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/example/rss-server/internal/models"
+)
+
+// ChaptersType is the MIME type of a JSON chapters document
+const ChaptersType = "application/json+chapters"
+
+// chaptersVersion is the JSON chapters format version we write
+const chaptersVersion = "1.2.0"
+
+// chaptersDoc is a Podcasting 2.0 JSON chapters document
+type chaptersDoc struct {
+	Version  string           `json:"version"`
+	Chapters []models.Chapter `json:"chapters"`
+}
+
+// ChaptersURL returns the URL an episode's chapters document is served from
+func ChaptersURL(baseURL, episodeID string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/episodes/" + url.PathEscape(episodeID) + "/chapters.json"
+}
+
+// GenerateChapters creates the JSON chapters document for an episode, with
+// chapter links and images made absolute
+func GenerateChapters(ep *models.Episode, baseURL string) ([]byte, error) {
+	doc := chaptersDoc{
+		Version:  chaptersVersion,
+		Chapters: make([]models.Chapter, 0, len(ep.Chapters)),
+	}
+
+	for _, ch := range ep.Chapters {
+		for _, u := range []*string{&ch.URL, &ch.Img} {
+			if *u == "" {
+				continue
+			}
+			absolute, err := convertToAbsoluteURL(baseURL, *u)
+			if err != nil {
+				log.Printf("Warning: Dropping invalid chapter URL '%s': %v", *u, err)
+				*u = ""
+				continue
+			}
+			*u = absolute
+		}
+		doc.Chapters = append(doc.Chapters, ch)
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode chapters: %w", err)
+	}
+	return out, nil
+}
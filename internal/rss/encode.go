@@ -0,0 +1,164 @@
+package rss
+
+import "encoding/xml"
+
+// itunesNS is the Apple Podcasts namespace URI
+const itunesNS = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+// podcastNS is the Podcasting 2.0 namespace URI
+const podcastNS = "https://podcastindex.org/namespace/1.0"
+
+// fhNS is the RFC 5005 feed history namespace URI
+const fhNS = "http://purl.org/syndication/history/1.0"
+
+// generator identifies this server in the feed's generator element
+const generator = "rss-server"
+
+// The types below are the feed as written. Element names carry the
+// namespace prefix literally; the parser uses namespace-qualified names.
+
+type rssDoc struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	ITunesNS  string     `xml:"xmlns:itunes,attr"`
+	PodcastNS string     `xml:"xmlns:podcast,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr,omitempty"`
+	FHNS      string     `xml:"xmlns:fh,attr,omitempty"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title           string           `xml:"title"`
+	Link            string           `xml:"link"`
+	AtomLinks       []rssAtomLink    `xml:"atom:link"`
+	FHComplete      *struct{}        `xml:"fh:complete,omitempty"`
+	Description     string           `xml:"description"`
+	Category        string           `xml:"category,omitempty"`
+	Generator       string           `xml:"generator"`
+	Language        string           `xml:"language,omitempty"`
+	Copyright       string           `xml:"copyright,omitempty"`
+	LastBuildDate   string           `xml:"lastBuildDate,omitempty"`
+	PubDate         string           `xml:"pubDate,omitempty"`
+	ITunesAuthor    string           `xml:"itunes:author,omitempty"`
+	ITunesSubtitle  string           `xml:"itunes:subtitle,omitempty"`
+	ITunesSummary   *cdata           `xml:"itunes:summary,omitempty"`
+	ITunesImage     *itunesImage     `xml:"itunes:image,omitempty"`
+	ITunesExplicit  string           `xml:"itunes:explicit,omitempty"`
+	ITunesCategory  *itunesCategory  `xml:"itunes:category,omitempty"`
+	ITunesType      string           `xml:"itunes:type,omitempty"`
+	ITunesOwner     *itunesOwner     `xml:"itunes:owner,omitempty"`
+	ITunesBlock     string           `xml:"itunes:block,omitempty"`
+	ITunesComplete  string           `xml:"itunes:complete,omitempty"`
+	ITunesNewFeed   string           `xml:"itunes:new-feed-url,omitempty"`
+	PodcastGUID     string           `xml:"podcast:guid,omitempty"`
+	PodcastLocked   *podcastLocked   `xml:"podcast:locked,omitempty"`
+	PodcastFunding  []podcastFunding `xml:"podcast:funding"`
+	PodcastPersons  []podcastPerson  `xml:"podcast:person"`
+	PodcastLocation *podcastLocation `xml:"podcast:location,omitempty"`
+	PodcastTrailers []podcastTrailer `xml:"podcast:trailer"`
+	PodcastLicense  *podcastLicense  `xml:"podcast:license,omitempty"`
+	Items           []rssItem        `xml:"item"`
+}
+
+// rssAtomLink is an atom:link in an RSS channel, such as the RFC 5005
+// paging links
+type rssAtomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	GUID               string              `xml:"guid"`
+	Title              string              `xml:"title"`
+	Link               string              `xml:"link,omitempty"`
+	Description        string              `xml:"description"`
+	PubDate            string              `xml:"pubDate,omitempty"`
+	Enclosure          *rssEnclosure       `xml:"enclosure,omitempty"`
+	ITunesAuthor       string              `xml:"itunes:author,omitempty"`
+	ITunesImage        *itunesImage        `xml:"itunes:image,omitempty"`
+	ITunesDuration     string              `xml:"itunes:duration,omitempty"`
+	ITunesExplicit     string              `xml:"itunes:explicit,omitempty"`
+	ITunesEpisode      int                 `xml:"itunes:episode,omitempty"`
+	ITunesSeason       int                 `xml:"itunes:season,omitempty"`
+	ITunesEpisodeType  string              `xml:"itunes:episodeType,omitempty"`
+	PodcastPersons     []podcastPerson     `xml:"podcast:person"`
+	PodcastLocation    *podcastLocation    `xml:"podcast:location,omitempty"`
+	PodcastLicense     *podcastLicense     `xml:"podcast:license,omitempty"`
+	PodcastChapters    *podcastChapters    `xml:"podcast:chapters,omitempty"`
+	PodcastTranscripts []podcastTranscript `xml:"podcast:transcript"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type itunesImage struct {
+	HREF string `xml:"href,attr"`
+}
+
+type itunesCategory struct {
+	Text string          `xml:"text,attr"`
+	Sub  *itunesCategory `xml:"itunes:category,omitempty"`
+}
+
+type itunesOwner struct {
+	Name  string `xml:"itunes:name,omitempty"`
+	Email string `xml:"itunes:email,omitempty"`
+}
+
+type podcastLocked struct {
+	Owner string `xml:"owner,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type podcastFunding struct {
+	URL   string `xml:"url,attr"`
+	Label string `xml:",chardata"`
+}
+
+type podcastPerson struct {
+	Role  string `xml:"role,attr,omitempty"`
+	Group string `xml:"group,attr,omitempty"`
+	Img   string `xml:"img,attr,omitempty"`
+	Href  string `xml:"href,attr,omitempty"`
+	Name  string `xml:",chardata"`
+}
+
+type podcastLocation struct {
+	Geo  string `xml:"geo,attr,omitempty"`
+	OSM  string `xml:"osm,attr,omitempty"`
+	Name string `xml:",chardata"`
+}
+
+type podcastTrailer struct {
+	PubDate string `xml:"pubdate,attr"`
+	URL     string `xml:"url,attr"`
+	Length  int64  `xml:"length,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Season  int    `xml:"season,attr,omitempty"`
+	Title   string `xml:",chardata"`
+}
+
+type podcastLicense struct {
+	URL  string `xml:"url,attr,omitempty"`
+	Name string `xml:",chardata"`
+}
+
+type podcastChapters struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type podcastTranscript struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Language string `xml:"language,attr,omitempty"`
+	Rel      string `xml:"rel,attr,omitempty"`
+}
+
+type cdata struct {
+	Text string `xml:",cdata"`
+}
@@ -1,6 +1,7 @@
 package rss
 
 import (
+	"encoding/xml"
 	"fmt"
 	"log"
 	"net/url"
@@ -8,7 +9,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/eduncan911/podcast"
 	"github.com/example/rss-server/internal/models"
 )
 
@@ -38,34 +38,94 @@ func convertToAbsoluteURL(baseURL, relativePath string) (string, error) {
 	return absolute.String(), nil
 }
 
-// GenerateFeed creates an RSS 2.0 + iTunes feed from the podcast model
+// GenerateFeed creates an RSS 2.0 + iTunes feed from the podcast model.
+// Draft and scheduled episodes are left out.
 // T031: Updated signature to accept baseURL parameter
 func GenerateFeed(p *models.Podcast, baseURL string) ([]byte, error) {
-	now := time.Now()
+	return generateFeed(publishedOnly(p), baseURL, feedHistory{})
+}
+
+// GeneratePreviewFeed creates a feed of every episode, including drafts and
+// scheduled ones, for review before release. The channel is marked with
+// itunes:block so directories never list it. The audio, chapters and
+// transcripts of unreleased episodes are only served with the preview
+// token, so their URLs carry it.
+func GeneratePreviewFeed(p *models.Podcast, baseURL string, token string) ([]byte, error) {
+	preview := *p
+	preview.Title = "[Preview] " + p.Title
+	preview.Block = true
+	preview.Episodes = make([]models.Episode, len(p.Episodes))
+	for i, ep := range p.Episodes {
+		if !ep.Published() {
+			ep = previewEpisode(ep, baseURL, token)
+		}
+		preview.Episodes[i] = ep
+	}
+	return generateFeed(&preview, baseURL, feedHistory{})
+}
+
+// previewEpisode points an unreleased episode's audio, chapters and
+// transcripts at URLs carrying the preview token
+func previewEpisode(ep models.Episode, baseURL string, token string) models.Episode {
+	ep.AudioURL = withToken(ep.AudioURL, token)
+	if len(ep.Chapters) > 0 {
+		ep.ChaptersURL = withToken(ChaptersURL(baseURL, ep.ID), token)
+		ep.Chapters = nil
+	}
+	if ep.Transcript != nil {
+		ep.TranscriptLinks = transcriptLinks(ep, baseURL)
+		for i := range ep.TranscriptLinks {
+			ep.TranscriptLinks[i].URL = withToken(ep.TranscriptLinks[i].URL, token)
+		}
+		ep.Transcript = nil
+	}
+	return ep
+}
+
+// withToken adds the preview token to a URL's query
+func withToken(rawURL string, token string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || rawURL == "" {
+		return rawURL
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// generateFeed renders p and all of its episodes, with the feed history
+// (RFC 5005) elements of paged and archive feeds
+func generateFeed(p *models.Podcast, baseURL string, history feedHistory) ([]byte, error) {
 	pubDate := p.PubDate
 	if pubDate.IsZero() {
-		pubDate = now
+		pubDate = time.Now()
 	}
 
-	// Create podcast feed
-	feed := podcast.New(
-		p.Title,
-		p.Link,
-		p.Description,
-		&pubDate,
-		&now,
-	)
-
-	// Set language
-	feed.Language = p.Language
+	channel := rssChannel{
+		Title:          p.Title,
+		Link:           p.Link,
+		Description:    p.Description,
+		Category:       p.Category,
+		Generator:      generator,
+		Language:       p.Language,
+		Copyright:      p.Copyright,
+		LastBuildDate:  lastBuildDate(p, pubDate).Format(time.RFC1123Z),
+		PubDate:        pubDate.Format(time.RFC1123Z),
+		ITunesAuthor:   p.Author,
+		ITunesSubtitle: p.Subtitle,
+		ITunesExplicit: p.Explicit,
+		ITunesType:     showType(p.Type),
+		ITunesNewFeed:  p.NewFeedURL,
+		AtomLinks:      history.links,
+	}
+	if history.complete {
+		channel.FHComplete = &struct{}{}
+	}
 
 	// Add iTunes metadata
-	feed.IAuthor = p.Author
-	if p.Subtitle != "" {
-		feed.ISubtitle = p.Subtitle
-	}
 	if p.Summary != "" {
-		feed.ISummary = &podcast.ISummary{Text: p.Summary}
+		channel.ITunesSummary = &cdata{Text: p.Summary}
 	}
 	// T032: Apply URL conversion to podcast ImageURL
 	if p.ImageURL != "" {
@@ -73,65 +133,282 @@ func GenerateFeed(p *models.Podcast, baseURL string) ([]byte, error) {
 		if err != nil {
 			log.Printf("Warning: Failed to convert podcast image URL '%s': %v", p.ImageURL, err)
 		} else {
-			feed.IImage = &podcast.IImage{HREF: absoluteImageURL}
+			channel.ITunesImage = &itunesImage{HREF: absoluteImageURL}
 		}
 	}
-	if p.Explicit != "" {
-		feed.IExplicit = p.Explicit
-	}
 	if p.Category != "" {
-		feed.AddCategory(p.Category, nil)
+		channel.ITunesCategory = &itunesCategory{Text: p.Category}
+		if p.Subcategory != "" {
+			channel.ITunesCategory.Sub = &itunesCategory{Text: p.Subcategory}
+		}
+	}
+	if p.OwnerName != "" || p.OwnerEmail != "" {
+		channel.ITunesOwner = &itunesOwner{Name: p.OwnerName, Email: p.OwnerEmail}
+	}
+	if p.Block {
+		channel.ITunesBlock = "Yes"
+	}
+	if p.Complete {
+		channel.ITunesComplete = "Yes"
+	}
+
+	// Podcasting 2.0 metadata
+	channel.PodcastGUID = podcastGUID(p, baseURL)
+	channel.PodcastLocked = &podcastLocked{Value: "no"}
+	if p.Locked {
+		channel.PodcastLocked = &podcastLocked{Owner: p.OwnerEmail, Value: "yes"}
+	}
+	for _, f := range p.Funding {
+		channel.PodcastFunding = append(channel.PodcastFunding, podcastFunding{URL: f.URL, Label: f.Label})
+	}
+	channel.PodcastPersons = podcastPersons(p.Persons)
+	channel.PodcastLocation = podcastLocationOf(p.Location)
+	channel.PodcastLicense = podcastLicenseOf(p.License)
+	for _, t := range p.Trailers {
+		trailerURL, err := convertToAbsoluteURL(baseURL, t.URL)
+		if err != nil {
+			log.Printf("Warning: Skipping trailer '%s' due to invalid URL '%s': %v", t.Title, t.URL, err)
+			continue
+		}
+		channel.PodcastTrailers = append(channel.PodcastTrailers, podcastTrailer{
+			PubDate: t.PubDate.Format(time.RFC1123Z),
+			URL:     trailerURL,
+			Length:  t.Length,
+			Type:    t.Type,
+			Season:  t.Season,
+			Title:   t.Title,
+		})
 	}
 
 	// Sort episodes by PubDate (descending - newest first)
-	episodes := make([]models.Episode, len(p.Episodes))
-	copy(episodes, p.Episodes)
-	sort.Slice(episodes, func(i, j int) bool {
-		return episodes[i].PubDate.After(episodes[j].PubDate)
-	})
+	episodes := newestFirst(p.Episodes)
 
 	// Add episodes
 	// T034: Add error handling to skip malformed episodes
 	for _, ep := range episodes {
-		item := podcast.Item{
-			Title:       ep.Title,
-			Description: ep.Description,
-			PubDate:     &ep.PubDate,
+		if ep.Title == "" || ep.Description == "" {
+			log.Printf("Warning: Skipping episode '%s' without title or description", ep.ID)
+			continue
 		}
 
-		// Set GUID
-		if ep.GUID != "" {
-			item.GUID = ep.GUID
-		} else {
+		item := rssItem{
+			GUID:           ep.GUID,
+			Title:          ep.Title,
+			Description:    ep.Description,
+			PubDate:        ep.PubDate.Format(time.RFC1123Z),
+			ITunesAuthor:   p.Author,
+			ITunesDuration: ep.Duration,
+			ITunesExplicit: ep.Explicit,
+		}
+		if item.GUID == "" {
 			item.GUID = ep.ID
 		}
 
 		// T033: Apply URL conversion to episode AudioURL with RFC 3986 encoding
 		// Add enclosure (audio file)
-		if ep.AudioURL != "" {
-			absoluteAudioURL, err := convertToAbsoluteURL(baseURL, ep.AudioURL)
+		if ep.AudioURL == "" {
+			log.Printf("Warning: Skipping episode '%s' without audio", ep.ID)
+			continue
+		}
+		absoluteAudioURL, err := convertToAbsoluteURL(baseURL, ep.AudioURL)
+		if err != nil {
+			// T034: Skip malformed episodes, log error
+			log.Printf("Warning: Skipping episode '%s' due to invalid audio URL '%s': %v", ep.ID, ep.AudioURL, err)
+			continue
+		}
+		item.Link = absoluteAudioURL
+		item.Enclosure = &rssEnclosure{
+			URL:    absoluteAudioURL,
+			Length: max(ep.AudioLength, 0),
+			Type:   enclosureType(ep),
+		}
+
+		if ep.ImageURL != "" {
+			absoluteImageURL, err := convertToAbsoluteURL(baseURL, ep.ImageURL)
 			if err != nil {
-				// T034: Skip malformed episodes, log error
-				log.Printf("Warning: Skipping episode '%s' due to invalid audio URL '%s': %v", ep.ID, ep.AudioURL, err)
-				continue
+				log.Printf("Warning: Failed to convert episode image URL '%s': %v", ep.ImageURL, err)
+			} else {
+				item.ITunesImage = &itunesImage{HREF: absoluteImageURL}
 			}
-			item.AddEnclosure(absoluteAudioURL, podcast.MP3, ep.AudioLength)
 		}
 
-		// iTunes fields
-		if ep.Duration != "" {
-			item.IDuration = ep.Duration
+		// Serial show ordering and trailers/bonus content
+		if ep.EpisodeNum > 0 {
+			item.ITunesEpisode = ep.EpisodeNum
 		}
-		if ep.Explicit != "" {
-			item.IExplicit = ep.Explicit
+		if ep.SeasonNum > 0 {
+			item.ITunesSeason = ep.SeasonNum
 		}
+		item.ITunesEpisodeType = episodeType(ep.EpisodeType)
 
-		if _, err := feed.AddItem(item); err != nil {
-			log.Printf("Warning: Failed to add episode '%s': %v", ep.ID, err)
-			continue
+		item.PodcastPersons = podcastPersons(ep.Persons)
+		item.PodcastLocation = podcastLocationOf(ep.Location)
+		item.PodcastLicense = podcastLicenseOf(ep.License)
+
+		if len(ep.Chapters) > 0 {
+			item.PodcastChapters = &podcastChapters{URL: ChaptersURL(baseURL, ep.ID), Type: ChaptersType}
+		} else if ep.ChaptersURL != "" {
+			item.PodcastChapters = &podcastChapters{URL: ep.ChaptersURL, Type: ChaptersType}
+		}
+		for _, t := range transcriptLinks(ep, baseURL) {
+			item.PodcastTranscripts = append(item.PodcastTranscripts, podcastTranscript{URL: t.URL, Type: t.Type, Language: t.Language, Rel: t.Rel})
 		}
+
+		channel.Items = append(channel.Items, item)
+	}
+
+	doc := rssDoc{
+		Version:   "2.0",
+		ITunesNS:  itunesNS,
+		PodcastNS: podcastNS,
+		Channel:   channel,
+	}
+	if len(channel.AtomLinks) > 0 {
+		doc.AtomNS = atomNS
+	}
+	if history.complete {
+		doc.FHNS = fhNS
 	}
 
 	// Generate XML bytes
-	return feed.Bytes(), nil
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode feed: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// feedEntry is an episode as listed by the Atom and JSON feeds, with its
+// URLs made absolute
+type feedEntry struct {
+	models.Episode
+	audio string // absolute AudioURL
+	image string // absolute ImageURL, or ""
+}
+
+// feedEntries returns the episodes of p that can be listed, newest first.
+// Like the RSS feed, episodes without a title, description or valid audio
+// URL are skipped.
+func feedEntries(p *models.Podcast, baseURL string) []feedEntry {
+	var entries []feedEntry
+	for _, ep := range newestFirst(p.Episodes) {
+		if ep.Title == "" || ep.Description == "" || ep.AudioURL == "" {
+			continue
+		}
+		audio, err := convertToAbsoluteURL(baseURL, ep.AudioURL)
+		if err != nil {
+			continue
+		}
+
+		entry := feedEntry{Episode: ep, audio: audio}
+		if ep.ImageURL != "" {
+			entry.image, _ = convertToAbsoluteURL(baseURL, ep.ImageURL)
+		}
+		if entry.GUID == "" {
+			entry.GUID = ep.ID
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// podcastGUID returns the show's podcast:guid. The server saves one with
+// storage.EnsureGUID before serving feeds; podcasts without one fall back
+// to the GUID derived from the feed URL.
+func podcastGUID(p *models.Podcast, baseURL string) string {
+	if p.GUID != "" {
+		return p.GUID
+	}
+	return FeedGUID(strings.TrimSuffix(baseURL, "/") + "/feed.xml")
+}
+
+// podcastPersons converts people to podcast:person elements
+func podcastPersons(persons []models.Person) []podcastPerson {
+	var out []podcastPerson
+	for _, p := range persons {
+		out = append(out, podcastPerson{Role: p.Role, Group: p.Group, Img: p.Img, Href: p.Href, Name: p.Name})
+	}
+	return out
+}
+
+// podcastLocationOf converts a location to a podcast:location element
+func podcastLocationOf(l *models.Location) *podcastLocation {
+	if l == nil || l.Name == "" {
+		return nil
+	}
+	return &podcastLocation{Geo: l.Geo, OSM: l.OSM, Name: l.Name}
+}
+
+// podcastLicenseOf converts a license to a podcast:license element
+func podcastLicenseOf(l *models.License) *podcastLicense {
+	if l == nil || l.Name == "" {
+		return nil
+	}
+	return &podcastLicense{URL: l.URL, Name: l.Name}
+}
+
+// lastBuildDate is the most recent of the channel and episode dates. It is
+// derived from the content rather than the clock so that regenerating an
+// unchanged feed produces identical bytes.
+func lastBuildDate(p *models.Podcast, pubDate time.Time) time.Time {
+	latest := pubDate
+	for _, ep := range p.Episodes {
+		if ep.PubDate.After(latest) {
+			latest = ep.PubDate
+		}
+	}
+	return latest
+}
+
+// newestFirst returns a copy of episodes sorted by PubDate, newest first
+func newestFirst(episodes []models.Episode) []models.Episode {
+	sorted := make([]models.Episode, len(episodes))
+	copy(sorted, episodes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PubDate.After(sorted[j].PubDate)
+	})
+	return sorted
+}
+
+// publishedOnly returns a copy of p without draft or scheduled episodes
+func publishedOnly(p *models.Podcast) *models.Podcast {
+	published := *p
+	published.Episodes = nil
+	for _, ep := range p.Episodes {
+		if ep.Published() {
+			published.Episodes = append(published.Episodes, ep)
+		}
+	}
+	return &published
+}
+
+// enclosureType returns the episode's enclosure MIME type, defaulting to MP3
+// for episodes stored before the type was recorded
+func enclosureType(ep models.Episode) string {
+	if ep.AudioType != "" {
+		return ep.AudioType
+	}
+	return "audio/mpeg"
+}
+
+// episodeType normalizes an episode type to the values Apple Podcasts
+// accepts ("full", "trailer", "bonus"); anything else is omitted
+func episodeType(t string) string {
+	switch t = strings.ToLower(strings.TrimSpace(t)); t {
+	case "full", "trailer", "bonus":
+		return t
+	default:
+		return ""
+	}
+}
+
+// showType normalizes a show type to the values Apple Podcasts accepts
+// ("episodic", "serial"); anything else is omitted
+func showType(t string) string {
+	switch t = strings.ToLower(strings.TrimSpace(t)); t {
+	case "episodic", "serial":
+		return t
+	default:
+		return ""
+	}
 }
@@ -0,0 +1,45 @@
+package rss
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// guidNamespace is the UUID namespace the Podcasting 2.0 spec assigns to
+// podcast:guid
+var guidNamespace = [16]byte{0xea, 0xd4, 0xc2, 0x36, 0xbf, 0x58, 0x58, 0xc6, 0xa2, 0xc6, 0xa6, 0xb2, 0x8d, 0x12, 0x8c, 0xb6}
+
+// FeedGUID returns the podcast:guid for a feed URL: a UUIDv5 of the URL
+// with its scheme and trailing slashes removed
+func FeedGUID(feedURL string) string {
+	name := feedURL
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+3:]
+	}
+	name = strings.TrimRight(name, "/")
+
+	return uuid5(name)
+}
+
+// entryID returns a stable urn:uuid: ID for an episode of a feed, for
+// formats whose IDs must be URIs. GUIDs that already are URIs are kept.
+func entryID(feedGUID, guid string) string {
+	if u, err := url.Parse(guid); err == nil && u.Scheme != "" && u.Opaque+u.Host+u.Path != "" {
+		return guid
+	}
+	return "urn:uuid:" + uuid5(feedGUID+"/"+guid)
+}
+
+// uuid5 returns the UUIDv5 of name in the podcast:guid namespace
+func uuid5(name string) string {
+	h := sha1.New()
+	h.Write(guidNamespace[:])
+	h.Write([]byte(name))
+	u := h.Sum(nil)[:16]
+	u[6] = u[6]&0x0f | 0x50 // version 5
+	u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
@@ -0,0 +1,126 @@
+package rss
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+)
+
+// JSONFeedType is the MIME type of the JSON feed
+const JSONFeedType = "application/feed+json"
+
+// jsonFeedVersion identifies JSON Feed 1.1
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Icon        string         `json:"icon,omitempty"`
+	Authors     []jsonAuthor   `json:"authors,omitempty"`
+	Language    string         `json:"language,omitempty"`
+	Expired     bool           `json:"expired,omitempty"`
+	Hubs        []jsonHub      `json:"hubs,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished string           `json:"date_published"`
+	Attachments   []jsonAttachment `json:"attachments"`
+}
+
+type jsonAttachment struct {
+	URL               string `json:"url"`
+	MimeType          string `json:"mime_type"`
+	SizeInBytes       int64  `json:"size_in_bytes,omitempty"`
+	DurationInSeconds int    `json:"duration_in_seconds,omitempty"`
+}
+
+// GenerateJSONFeed creates a JSON Feed 1.1 document of the podcast's
+// published episodes, with each episode's audio as an attachment. The feed
+// lists hubs as its WebSub hubs.
+func GenerateJSONFeed(p *models.Podcast, baseURL string, hubs ...string) ([]byte, error) {
+	p = publishedOnly(p)
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	feed := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       p.Title,
+		HomePageURL: p.Link,
+		FeedURL:     baseURL + "/feed.json",
+		Description: p.Description,
+		Language:    p.Language,
+		Expired:     p.Complete,
+		Items:       []jsonFeedItem{},
+	}
+	for _, hub := range hubs {
+		feed.Hubs = append(feed.Hubs, jsonHub{Type: "WebSub", URL: hub})
+	}
+	if p.Author != "" {
+		feed.Authors = []jsonAuthor{{Name: p.Author}}
+	}
+	if p.ImageURL != "" {
+		feed.Icon, _ = convertToAbsoluteURL(baseURL, p.ImageURL)
+	}
+
+	for _, e := range feedEntries(p, baseURL) {
+		feed.Items = append(feed.Items, jsonFeedItem{
+			ID:            e.GUID,
+			URL:           e.audio,
+			Title:         e.Title,
+			ContentText:   e.Description,
+			Image:         e.image,
+			DatePublished: e.PubDate.UTC().Format(time.RFC3339),
+			Attachments: []jsonAttachment{{
+				URL:               e.audio,
+				MimeType:          enclosureType(e.Episode),
+				SizeInBytes:       max(e.AudioLength, 0),
+				DurationInSeconds: durationSeconds(e.Duration),
+			}},
+		})
+	}
+
+	out, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON feed: %w", err)
+	}
+	return out, nil
+}
+
+// durationSeconds parses an itunes:duration ("HH:MM:SS", "MM:SS" or
+// seconds), returning 0 when it is missing or malformed
+func durationSeconds(d string) int {
+	if d == "" {
+		return 0
+	}
+
+	total := 0
+	for _, part := range strings.Split(d, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0
+		}
+		total = total*60 + n
+	}
+	return total
+}
@@ -0,0 +1,122 @@
+package rss
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/example/rss-server/internal/models"
+)
+
+// ArchivePath is the path of the complete archive feed, relative to the base URL
+const ArchivePath = "/feed-archive.xml"
+
+// ErrPageNotFound is returned for a page number outside the paged feed
+var ErrPageNotFound = errors.New("feed page not found")
+
+// feedHistory holds the RFC 5005 feed history elements of a channel
+type feedHistory struct {
+	links    []rssAtomLink
+	complete bool
+}
+
+// PageCount returns the number of pages a feed of the podcast's published
+// episodes is split into with pageSize episodes per page. An empty feed
+// still has one page.
+func PageCount(p *models.Podcast, pageSize int) int {
+	published := len(publishedOnly(p).Episodes)
+	if pageSize <= 0 || published == 0 {
+		return 1
+	}
+	return (published + pageSize - 1) / pageSize
+}
+
+// GeneratePagedFeed creates page number page (from 1) of an RFC 5005 paged
+// feed listing pageSize published episodes per page, newest first. Each
+// page links to the first, last, previous and next pages with atom:link;
+// the first page is the feed itself at /feed.xml and later pages are
+// /feed.xml?page=N. The first page announces hubs as its WebSub hubs.
+func GeneratePagedFeed(p *models.Podcast, baseURL string, page int, pageSize int, hubs ...string) ([]byte, error) {
+	if pageSize <= 0 {
+		return GenerateLatestFeed(p, baseURL, 0, hubs...)
+	}
+
+	last := PageCount(p, pageSize)
+	if page < 1 || page > last {
+		return nil, ErrPageNotFound
+	}
+
+	published := publishedOnly(p)
+	episodes := newestFirst(published.Episodes)
+	start := (page - 1) * pageSize
+	end := min(start+pageSize, len(episodes))
+	published.Episodes = episodes[start:end]
+
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	links := []rssAtomLink{
+		{Rel: "self", Type: "application/rss+xml", Href: PageURL(baseURL, page)},
+	}
+	if page == 1 {
+		links = append(links, hubLinks(hubs)...)
+	}
+	links = append(links, rssAtomLink{Rel: "first", Href: PageURL(baseURL, 1)})
+	if page > 1 {
+		links = append(links, rssAtomLink{Rel: "previous", Href: PageURL(baseURL, page-1)})
+	}
+	if page < last {
+		links = append(links, rssAtomLink{Rel: "next", Href: PageURL(baseURL, page+1)})
+	}
+	links = append(links, rssAtomLink{Rel: "last", Href: PageURL(baseURL, last)})
+
+	return generateFeed(published, baseURL, feedHistory{links: links})
+}
+
+// GenerateLatestFeed creates a feed of the newest limit published episodes.
+// The rest remain in the archive feed, which a capped feed links to with
+// rel="archives". A limit of 0 lists every episode. With hubs, the feed
+// links to itself and announces them as its WebSub hubs.
+func GenerateLatestFeed(p *models.Podcast, baseURL string, limit int, hubs ...string) ([]byte, error) {
+	published := publishedOnly(p)
+	if limit > 0 && len(published.Episodes) > limit {
+		published.Episodes = newestFirst(published.Episodes)[:limit]
+	}
+
+	var history feedHistory
+	if len(hubs) > 0 {
+		history.links = append([]rssAtomLink{{Rel: "self", Type: "application/rss+xml", Href: PageURL(baseURL, 1)}}, hubLinks(hubs)...)
+	}
+	if limit > 0 {
+		history.links = append(history.links, rssAtomLink{Rel: "archives", Type: "application/rss+xml", Href: strings.TrimSuffix(baseURL, "/") + ArchivePath})
+	}
+	return generateFeed(published, baseURL, history)
+}
+
+// GenerateArchiveFeed creates a feed of every published episode, marked as
+// an RFC 5005 complete feed, for feeds capped with GenerateLatestFeed or
+// split into pages. The feed announces hubs as its WebSub hubs.
+func GenerateArchiveFeed(p *models.Podcast, baseURL string, hubs ...string) ([]byte, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	links := []rssAtomLink{{Rel: "self", Type: "application/rss+xml", Href: baseURL + ArchivePath}}
+	return generateFeed(publishedOnly(p), baseURL, feedHistory{
+		links:    append(links, hubLinks(hubs)...),
+		complete: true,
+	})
+}
+
+// hubLinks returns the atom:link elements announcing WebSub hubs
+func hubLinks(hubs []string) []rssAtomLink {
+	var links []rssAtomLink
+	for _, hub := range hubs {
+		links = append(links, rssAtomLink{Rel: "hub", Href: hub})
+	}
+	return links
+}
+
+// PageURL returns the URL of a page of the paged feed
+func PageURL(baseURL string, page int) string {
+	feedURL := strings.TrimSuffix(baseURL, "/") + "/feed.xml"
+	if page <= 1 {
+		return feedURL
+	}
+	return feedURL + "?page=" + strconv.Itoa(page)
+}
@@ -3,38 +3,154 @@ package rss
 import (
 	"encoding/xml"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/example/rss-server/internal/models"
 )
 
+// The types below are the feed as read. encoding/xml matches elements by
+// namespace URI rather than prefix, so extension elements are declared
+// with their full namespace. Un-namespaced tags match an element of that
+// local name in any namespace, which is why categories and links are
+// collected with their names and sorted out afterwards.
+
 // RSS represents the RSS 2.0 XML structure
 type RSS struct {
 	XMLName xml.Name `xml:"rss"`
 	Version string   `xml:"version,attr"`
-	XMLNS   string   `xml:"xmlns:itunes,attr"`
 	Channel Channel  `xml:"channel"`
 }
 
 // Channel represents the RSS channel element
 type Channel struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	Language    string `xml:"language"`
-	PubDate     string `xml:"pubDate,omitempty"`
+	Title         string     `xml:"title"`
+	Links         []Link     `xml:"link"`
+	Description   string     `xml:"description"`
+	Language      string     `xml:"language"`
+	Copyright     string     `xml:"copyright"`
+	PubDate       string     `xml:"pubDate"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	Categories    []Category `xml:"category"`
 
 	// iTunes fields
-	Author   string `xml:"itunes:author,omitempty"`
-	Subtitle string `xml:"itunes:subtitle,omitempty"`
-	Summary  string `xml:"itunes:summary,omitempty"`
-	ImageURL string `xml:"itunes:image,omitempty"`
-	Explicit string `xml:"itunes:explicit,omitempty"`
-	Category string `xml:"itunes:category,omitempty"`
+	Author     string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+	Subtitle   string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd subtitle"`
+	Summary    string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	Image      Image  `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	Explicit   string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd explicit"`
+	Type       string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd type"`
+	Owner      Owner  `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd owner"`
+	Block      string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd block"`
+	Complete   string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd complete"`
+	NewFeedURL string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd new-feed-url"`
+
+	// Podcasting 2.0 fields
+	GUID     string    `xml:"https://podcastindex.org/namespace/1.0 guid"`
+	Locked   Locked    `xml:"https://podcastindex.org/namespace/1.0 locked"`
+	Funding  []Funding `xml:"https://podcastindex.org/namespace/1.0 funding"`
+	Persons  []Person  `xml:"https://podcastindex.org/namespace/1.0 person"`
+	Location *Location `xml:"https://podcastindex.org/namespace/1.0 location"`
+	Trailers []Trailer `xml:"https://podcastindex.org/namespace/1.0 trailer"`
+	License  *License  `xml:"https://podcastindex.org/namespace/1.0 license"`
 
 	Items []Item `xml:"item"`
 }
 
+// Link is either the plain RSS channel link (text content) or an atom:link
+// (rel and href attributes), such as the RFC 5005 paging links
+type Link struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+	Rel     string `xml:"rel,attr"`
+	Href    string `xml:"href,attr"`
+}
+
+// Link returns the channel's RSS link, ignoring atom:link elements
+func (c Channel) Link() string {
+	for _, l := range c.Links {
+		if l.XMLName.Space == "" {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+// AtomLink returns the href of the channel's atom:link with the given rel,
+// or "" if there is none
+func (c Channel) AtomLink(rel string) string {
+	for _, l := range c.Links {
+		if l.XMLName.Space == atomNS && l.Rel == rel {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// Category is either a plain RSS category (text content) or an
+// itunes:category (text attribute, optionally with a nested subcategory)
+type Category struct {
+	XMLName xml.Name
+	Value   string     `xml:",chardata"`
+	Text    string     `xml:"text,attr"`
+	Subs    []Category `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd category"`
+}
+
+// Owner is the itunes:owner contact
+type Owner struct {
+	Name  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd name"`
+	Email string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd email"`
+}
+
+// Image is an itunes:image reference
+type Image struct {
+	HREF string `xml:"href,attr"`
+}
+
+// Locked is podcast:locked
+type Locked struct {
+	Owner string `xml:"owner,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Funding is podcast:funding
+type Funding struct {
+	URL   string `xml:"url,attr"`
+	Label string `xml:",chardata"`
+}
+
+// Person is podcast:person
+type Person struct {
+	Role  string `xml:"role,attr"`
+	Group string `xml:"group,attr"`
+	Img   string `xml:"img,attr"`
+	Href  string `xml:"href,attr"`
+	Name  string `xml:",chardata"`
+}
+
+// Location is podcast:location
+type Location struct {
+	Geo  string `xml:"geo,attr"`
+	OSM  string `xml:"osm,attr"`
+	Name string `xml:",chardata"`
+}
+
+// Trailer is podcast:trailer
+type Trailer struct {
+	PubDate string `xml:"pubdate,attr"`
+	URL     string `xml:"url,attr"`
+	Length  int64  `xml:"length,attr"`
+	Type    string `xml:"type,attr"`
+	Season  int    `xml:"season,attr"`
+	Title   string `xml:",chardata"`
+}
+
+// License is podcast:license
+type License struct {
+	URL  string `xml:"url,attr"`
+	Name string `xml:",chardata"`
+}
+
 // Item represents an RSS item (episode)
 type Item struct {
 	Title       string    `xml:"title"`
@@ -44,11 +160,33 @@ type Item struct {
 	Enclosure   Enclosure `xml:"enclosure"`
 
 	// iTunes fields
-	Duration    string `xml:"itunes:duration,omitempty"`
-	Explicit    string `xml:"itunes:explicit,omitempty"`
-	EpisodeNum  int    `xml:"itunes:episode,omitempty"`
-	SeasonNum   int    `xml:"itunes:season,omitempty"`
-	EpisodeType string `xml:"itunes:episodeType,omitempty"`
+	Image       Image  `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	Duration    string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Explicit    string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd explicit"`
+	EpisodeNum  int    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	SeasonNum   int    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season"`
+	EpisodeType string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episodeType"`
+
+	// Podcasting 2.0 fields
+	Persons     []Person     `xml:"https://podcastindex.org/namespace/1.0 person"`
+	Location    *Location    `xml:"https://podcastindex.org/namespace/1.0 location"`
+	License     *License     `xml:"https://podcastindex.org/namespace/1.0 license"`
+	Chapters    Chapters     `xml:"https://podcastindex.org/namespace/1.0 chapters"`
+	Transcripts []Transcript `xml:"https://podcastindex.org/namespace/1.0 transcript"`
+}
+
+// Chapters is a podcast:chapters reference
+type Chapters struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// Transcript is a podcast:transcript reference
+type Transcript struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Language string `xml:"language,attr"`
+	Rel      string `xml:"rel,attr"`
 }
 
 // Enclosure represents the audio file enclosure
@@ -64,46 +202,58 @@ func ParseFeed(data []byte) (*models.Podcast, error) {
 	if err := xml.Unmarshal(data, &rss); err != nil {
 		return nil, fmt.Errorf("failed to parse RSS XML: %w", err)
 	}
+	ch := rss.Channel
+	category, subcategory := channelCategory(ch.Categories)
 
-	// Parse pub date
-	pubDate := time.Now()
-	if rss.Channel.PubDate != "" {
-		parsed, err := time.Parse(time.RFC1123Z, rss.Channel.PubDate)
-		if err == nil {
-			pubDate = parsed
-		}
+	podcast := &models.Podcast{
+		Title:       ch.Title,
+		Link:        ch.Link(),
+		Description: ch.Description,
+		Language:    ch.Language,
+		PubDate:     parseDate(ch.PubDate),
+		Author:      ch.Author,
+		Subtitle:    ch.Subtitle,
+		Summary:     ch.Summary,
+		ImageURL:    ch.Image.HREF,
+		Explicit:    ch.Explicit,
+		Category:    category,
+		Subcategory: subcategory,
+		OwnerName:   ch.Owner.Name,
+		OwnerEmail:  ch.Owner.Email,
+		Type:        ch.Type,
+		Copyright:   ch.Copyright,
+		Block:       isYes(ch.Block),
+		Complete:    isYes(ch.Complete),
+		NewFeedURL:  ch.NewFeedURL,
+		GUID:        ch.GUID,
+		Locked:      isYes(ch.Locked.Value),
+		Persons:     persons(ch.Persons),
+		Location:    location(ch.Location),
+		License:     license(ch.License),
+		Episodes:    make([]models.Episode, 0, len(ch.Items)),
 	}
 
-	podcast := &models.Podcast{
-		Title:       rss.Channel.Title,
-		Link:        rss.Channel.Link,
-		Description: rss.Channel.Description,
-		Language:    rss.Channel.Language,
-		PubDate:     pubDate,
-		Author:      rss.Channel.Author,
-		Subtitle:    rss.Channel.Subtitle,
-		Summary:     rss.Channel.Summary,
-		ImageURL:    rss.Channel.ImageURL,
-		Explicit:    rss.Channel.Explicit,
-		Category:    rss.Channel.Category,
-		Episodes:    make([]models.Episode, 0, len(rss.Channel.Items)),
+	for _, f := range ch.Funding {
+		podcast.Funding = append(podcast.Funding, models.Funding{URL: f.URL, Label: strings.TrimSpace(f.Label)})
+	}
+	for _, t := range ch.Trailers {
+		podcast.Trailers = append(podcast.Trailers, models.Trailer{
+			Title:   strings.TrimSpace(t.Title),
+			URL:     t.URL,
+			PubDate: parseDate(t.PubDate),
+			Length:  t.Length,
+			Type:    t.Type,
+			Season:  t.Season,
+		})
 	}
 
 	// Parse episodes
-	for _, item := range rss.Channel.Items {
-		epPubDate := time.Now()
-		if item.PubDate != "" {
-			parsed, err := time.Parse(time.RFC1123Z, item.PubDate)
-			if err == nil {
-				epPubDate = parsed
-			}
-		}
-
+	for _, item := range ch.Items {
 		episode := models.Episode{
 			ID:          item.GUID,
 			Title:       item.Title,
 			Description: item.Description,
-			PubDate:     epPubDate,
+			PubDate:     parseDate(item.PubDate),
 			GUID:        item.GUID,
 			AudioURL:    item.Enclosure.URL,
 			AudioLength: item.Enclosure.Length,
@@ -113,6 +263,14 @@ func ParseFeed(data []byte) (*models.Podcast, error) {
 			EpisodeNum:  item.EpisodeNum,
 			SeasonNum:   item.SeasonNum,
 			EpisodeType: item.EpisodeType,
+			ImageURL:    item.Image.HREF,
+			Persons:     persons(item.Persons),
+			Location:    location(item.Location),
+			License:     license(item.License),
+			ChaptersURL: item.Chapters.URL,
+		}
+		for _, t := range item.Transcripts {
+			episode.TranscriptLinks = append(episode.TranscriptLinks, models.TranscriptLink{URL: t.URL, Type: t.Type, Language: t.Language, Rel: t.Rel})
 		}
 
 		podcast.Episodes = append(podcast.Episodes, episode)
@@ -120,3 +278,67 @@ func ParseFeed(data []byte) (*models.Podcast, error) {
 
 	return podcast, nil
 }
+
+// channelCategory returns the first itunes:category and its subcategory,
+// falling back to a plain RSS category
+func channelCategory(categories []Category) (string, string) {
+	var plain string
+	for _, c := range categories {
+		switch c.XMLName.Space {
+		case itunesNS:
+			if c.Text == "" {
+				continue
+			}
+			if len(c.Subs) > 0 {
+				return c.Text, c.Subs[0].Text
+			}
+			return c.Text, ""
+		case "":
+			if plain == "" {
+				plain = c.Value
+			}
+		}
+	}
+	return plain, ""
+}
+
+// persons converts podcast:person elements
+func persons(in []Person) []models.Person {
+	var out []models.Person
+	for _, p := range in {
+		out = append(out, models.Person{Name: strings.TrimSpace(p.Name), Role: p.Role, Group: p.Group, Img: p.Img, Href: p.Href})
+	}
+	return out
+}
+
+// location converts a podcast:location element
+func location(l *Location) *models.Location {
+	if l == nil {
+		return nil
+	}
+	return &models.Location{Name: strings.TrimSpace(l.Name), Geo: l.Geo, OSM: l.OSM}
+}
+
+// license converts a podcast:license element
+func license(l *License) *models.License {
+	if l == nil {
+		return nil
+	}
+	return &models.License{Name: strings.TrimSpace(l.Name), URL: l.URL}
+}
+
+// isYes reports whether an iTunes flag such as itunes:block is set
+func isYes(s string) bool {
+	return strings.EqualFold(strings.TrimSpace(s), "yes")
+}
+
+// parseDate parses an RFC 822 feed date, falling back to the current time
+// when it is missing or malformed
+func parseDate(s string) time.Time {
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
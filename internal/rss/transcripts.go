@@ -0,0 +1,46 @@
+package rss
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/example/rss-server/internal/media"
+	"github.com/example/rss-server/internal/models"
+)
+
+// TranscriptURL returns the URL an episode's transcript is served from in
+// the given format ("srt", "vtt" or "txt")
+func TranscriptURL(baseURL, episodeID, format string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/episodes/" + url.PathEscape(episodeID) + "/transcript." + format
+}
+
+// transcriptLinks lists the transcripts to advertise for an episode. A
+// timed transcript is offered as both SRT and WebVTT, its own format first.
+func transcriptLinks(ep models.Episode, baseURL string) []models.TranscriptLink {
+	t := ep.Transcript
+	if t == nil {
+		return ep.TranscriptLinks
+	}
+
+	formats := []string{t.Format}
+	switch t.Format {
+	case media.TranscriptSRT:
+		formats = append(formats, media.TranscriptVTT)
+	case media.TranscriptVTT:
+		formats = append(formats, media.TranscriptSRT)
+	}
+
+	var links []models.TranscriptLink
+	for _, format := range formats {
+		link := models.TranscriptLink{
+			URL:      TranscriptURL(baseURL, ep.ID, format),
+			Type:     media.TranscriptContentType(format),
+			Language: t.Language,
+		}
+		if format != media.TranscriptText {
+			link.Rel = "captions"
+		}
+		links = append(links, link)
+	}
+	return links
+}
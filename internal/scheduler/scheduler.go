@@ -0,0 +1,123 @@
+package scheduler
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// Hook is called after an episode has been published
+type Hook func(ep models.Episode)
+
+// Scheduler publishes scheduled episodes once their publication date has
+// passed and runs the post-publish hooks
+type Scheduler struct {
+	store storage.Store
+
+	mu    sync.Mutex
+	hooks []Hook
+
+	// Now returns the current time; tests may replace it
+	Now func() time.Time
+}
+
+// New creates a scheduler for the episodes in store
+func New(store storage.Store) *Scheduler {
+	return &Scheduler{store: store, Now: time.Now}
+}
+
+// OnPublish registers a hook to run after each episode is published
+func (s *Scheduler) OnPublish(hook Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// PublishDue publishes every scheduled episode whose publication date has
+// passed and returns how many were published. The store re-checks each
+// episode as it publishes it, so an episode edited since it was listed
+// (moved back to draft or to a later date) is left alone.
+func (s *Scheduler) PublishDue() int {
+	now := s.Now()
+
+	published := 0
+	for _, ep := range s.store.GetPodcast().Episodes {
+		if !ep.Scheduled() || ep.PubDate.After(now) {
+			continue
+		}
+
+		current, err := s.store.PublishIfDue(ep.ID, now)
+		if err != nil {
+			log.Printf("Warning: Failed to publish scheduled episode %s: %v", ep.ID, err)
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		log.Printf("Published scheduled episode %s", ep.ID)
+		published++
+
+		s.runHooks(*current)
+	}
+	return published
+}
+
+// runHooks calls the post-publish hooks, isolating them from each other
+func (s *Scheduler) runHooks(ep models.Episode) {
+	s.mu.Lock()
+	hooks := append([]Hook(nil), s.hooks...)
+	s.mu.Unlock()
+
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Warning: Post-publish hook panicked for episode %s: %v", ep.ID, r)
+				}
+			}()
+			hook(ep)
+		}()
+	}
+}
+
+// NextDue returns the earliest publication date of a scheduled episode
+func (s *Scheduler) NextDue() (time.Time, bool) {
+	var next time.Time
+	found := false
+	for _, ep := range s.store.GetPodcast().Episodes {
+		if ep.Scheduled() && (!found || ep.PubDate.Before(next)) {
+			next, found = ep.PubDate, true
+		}
+	}
+	return next, found
+}
+
+// Start publishes due episodes until stop is closed. It wakes at the next
+// scheduled publication date, and at least every interval to pick up
+// episodes scheduled since it last looked. Episodes that fail to publish
+// are retried no more than once a second.
+func (s *Scheduler) Start(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		for {
+			s.PublishDue()
+
+			wait := interval
+			if next, ok := s.NextDue(); ok {
+				if until := next.Sub(s.Now()); until < wait {
+					wait = max(until, time.Second)
+				}
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-stop:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
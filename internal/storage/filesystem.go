@@ -1,59 +1,215 @@
 package storage
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"log"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/example/rss-server/internal/media"
 	"github.com/example/rss-server/internal/models"
 )
 
-// SaveAudioFile saves an uploaded audio file to the filesystem with a unique filename
-func SaveAudioFile(originalName string, data []byte, audioDir string) (*models.AudioFile, error) {
-	// Generate unique filename
-	filename := GenerateUniqueFilename(originalName)
-	filePath := filepath.Join(audioDir, filename)
+// FSBlobStore stores blobs as files in a local directory
+type FSBlobStore struct {
+	dir       string
+	urlPrefix string
+}
 
-	// Ensure directory exists
-	if err := os.MkdirAll(audioDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create audio directory: %w", err)
+var (
+	_ BlobStore = (*FSBlobStore)(nil)
+	_ FileStore = (*FSBlobStore)(nil)
+)
+
+// NewFSBlobStore creates a blob store rooted at dir whose blobs are served
+// under urlPrefix (e.g. "/audio/")
+func NewFSBlobStore(dir string, urlPrefix string) *FSBlobStore {
+	return &FSBlobStore{
+		dir:       dir,
+		urlPrefix: urlPrefix,
 	}
+}
 
-	// Write file to disk
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return nil, fmt.Errorf("failed to write audio file: %w", err)
+// Put writes the blob to a temp file and renames it into place
+func (s *FSBlobStore) Put(name string, r io.Reader) (int64, error) {
+	if !ValidBlobName(name) {
+		return 0, fmt.Errorf("invalid blob name: %q", name)
+	}
+
+	dir, err := s.StagingDir()
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpFile := tmp.Name()
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmpFile)
+		return 0, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpFile)
+		return 0, fmt.Errorf("failed to close file: %w", err)
+	}
+
+	if err := s.PutFile(name, tmpFile, ""); err != nil {
+		os.Remove(tmpFile)
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// StagingDir returns the blob directory, creating it if needed
+func (s *FSBlobStore) StagingDir() (string, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	return s.dir, nil
+}
+
+// PutFile atomically renames a file staged in the blob directory into place
+func (s *FSBlobStore) PutFile(name string, path string, sha256Hex string) error {
+	filePath, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(path, 0644); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if err := os.Rename(path, filePath); err != nil {
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+
+	return nil
+}
+
+// Open opens the blob file for reading
+func (s *FSBlobStore) Open(name string) (Blob, error) {
+	filePath, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
 
-	// Get file info
-	info, err := os.Stat(filePath)
+	return &fileBlob{File: f, info: info}, nil
+}
+
+// Delete removes the blob file
+func (s *FSBlobStore) Delete(name string) error {
+	filePath, err := s.path(name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to stat audio file: %w", err)
+		return err
+	}
+
+	if err := os.Remove(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return ErrBlobNotFound
+		}
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	return nil
+}
+
+// URL returns the blob's URL under the configured prefix
+func (s *FSBlobStore) URL(name string) string {
+	return s.urlPrefix + url.PathEscape(name)
+}
+
+// path resolves a blob name to a file path, rejecting directory traversal
+func (s *FSBlobStore) path(name string) (string, error) {
+	if !ValidBlobName(name) {
+		return "", fmt.Errorf("invalid blob name: %q", name)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// fileBlob adapts an *os.File to the Blob interface
+type fileBlob struct {
+	*os.File
+	info os.FileInfo
+}
+
+func (b *fileBlob) Size() int64        { return b.info.Size() }
+func (b *fileBlob) ModTime() time.Time { return b.info.ModTime() }
+
+// ValidBlobName reports whether name is a flat name without path components
+func ValidBlobName(name string) bool {
+	return name != "" && name != "." && !strings.Contains(name, "..") && !strings.ContainsAny(name, `/\`)
+}
+
+// SaveAudioFile commits a staged audio upload of the given format to the
+// blob store with a unique filename. Files whose extension does not match
+// the format's MIME type are stored under the format's canonical extension.
+func SaveAudioFile(originalName string, staged *StagedFile, format *media.Format, blobs BlobStore) (*models.AudioFile, error) {
+	name := originalName
+	if ext := filepath.Ext(name); media.ContentType(ext) != format.MIMEType {
+		name = strings.TrimSuffix(name, ext) + format.Extensions[0]
 	}
 
-	return &models.AudioFile{
+	// Generate unique filename
+	filename := GenerateUniqueFilename(name)
+
+	audioFile := &models.AudioFile{
 		Filename:     filename,
 		OriginalName: originalName,
-		FilePath:     filePath,
-		Size:         info.Size(),
-		MimeType:     "audio/mpeg", // Assuming MP3
+		URL:          blobs.URL(filename),
+		Size:         staged.Size(),
+		MimeType:     format.MIMEType,
 		UploadDate:   time.Now(),
-	}, nil
-}
+	}
 
-// DeleteAudioFile removes an audio file from the filesystem
-func DeleteAudioFile(filename string, audioDir string) error {
-	filePath := filepath.Join(audioDir, filename)
+	// Measure duration and bitrate; an unreadable stream is still stored
+	info, err := format.Probe(staged.Reader(), staged.Size())
+	if err != nil {
+		log.Printf("Warning: Failed to read audio properties of %s: %v", originalName, err)
+	} else {
+		audioFile.Duration = media.FormatDuration(info.Duration)
+		audioFile.Bitrate = info.Bitrate
+	}
 
-	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("audio file not found: %s", filename)
+	if err := staged.Commit(blobs, filename); err != nil {
+		return nil, fmt.Errorf("failed to write audio file: %w", err)
 	}
 
-	// Delete file
-	if err := os.Remove(filePath); err != nil {
+	return audioFile, nil
+}
+
+// DeleteAudioFile removes an audio file from the blob store
+func DeleteAudioFile(filename string, blobs BlobStore) error {
+	if err := blobs.Delete(filename); err != nil {
+		if err == ErrBlobNotFound {
+			return fmt.Errorf("audio file not found: %s", filename)
+		}
 		return fmt.Errorf("failed to delete audio file: %w", err)
 	}
 
@@ -80,20 +236,40 @@ func GenerateUniqueFilename(originalName string) string {
 	return fmt.Sprintf("%s-%s%s", sanitized, timestamp, ext)
 }
 
-// SaveArtworkFile saves an uploaded artwork file to the filesystem
-func SaveArtworkFile(originalName string, data []byte, artworkDir string) (string, error) {
+// SaveArtworkFile saves an uploaded artwork file to the blob store
+func SaveArtworkFile(originalName string, data []byte, blobs BlobStore) (string, error) {
 	// Generate unique filename
 	filename := GenerateUniqueFilename(originalName)
-	filePath := filepath.Join(artworkDir, filename)
 
-	// Ensure directory exists
-	if err := os.MkdirAll(artworkDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create artwork directory: %w", err)
+	if _, err := blobs.Put(filename, bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("failed to write artwork file: %w", err)
 	}
 
-	// Write file to disk
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return "", fmt.Errorf("failed to write artwork file: %w", err)
+	return filename, nil
+}
+
+// BlobName returns the name of the blob served from blobURL by blobs. It
+// reports false for URLs the store does not serve, such as external images.
+func BlobName(blobs BlobStore, blobURL string) (string, bool) {
+	i := strings.LastIndex(blobURL, "/")
+	if i < 0 {
+		return "", false
+	}
+	name, err := url.PathUnescape(blobURL[i+1:])
+	if err != nil || !ValidBlobName(name) || blobs.URL(name) != blobURL {
+		return "", false
+	}
+	return name, true
+}
+
+// SaveTranscriptFile stores a normalized transcript beside its episode's
+// audio file, named after the audio with the format as extension. An
+// existing transcript in the same format is overwritten.
+func SaveTranscriptFile(audioFilename string, format string, data []byte, blobs BlobStore) (string, error) {
+	filename := strings.TrimSuffix(audioFilename, filepath.Ext(audioFilename)) + "." + format
+
+	if _, err := blobs.Put(filename, bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("failed to write transcript file: %w", err)
 	}
 
 	return filename, nil
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+)
+
+// notifyingStore calls a hook after changes that show in the public feeds
+type notifyingStore struct {
+	Store
+	changed func()
+
+	mu     sync.Mutex
+	public [sha256.Size]byte
+}
+
+// NotifyOnChange wraps store so that changed is called after each
+// successful change to what the public feeds show: the podcast metadata and
+// its published episodes. Drafts, scheduled episodes and the trash are not
+// public, so uploading a draft, editing a scheduled episode or trashing a
+// draft is not reported, while publishing an episode or trashing a
+// published one is.
+func NotifyOnChange(store Store, changed func()) Store {
+	s := &notifyingStore{Store: store, changed: changed}
+	s.public = publicState(store.GetPodcast())
+	return s
+}
+
+// notify calls the hook if err is nil and the public podcast changed, and
+// returns err
+func (s *notifyingStore) notify(err error) error {
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	state := publicState(s.Store.GetPodcast())
+	changed := state != s.public
+	s.public = state
+	s.mu.Unlock()
+
+	if changed {
+		s.changed()
+	}
+	return nil
+}
+
+// publicState returns a fingerprint of the podcast as the public feeds
+// show it, without its unpublished episodes
+func publicState(p *models.Podcast) [sha256.Size]byte {
+	public := *p
+	public.Episodes = nil
+	for _, ep := range p.Episodes {
+		if ep.Published() {
+			public.Episodes = append(public.Episodes, ep)
+		}
+	}
+	data, _ := json.Marshal(public)
+	return sha256.Sum256(data)
+}
+
+// AddEpisode adds the episode and reports the change
+func (s *notifyingStore) AddEpisode(ep models.Episode) error {
+	return s.notify(s.Store.AddEpisode(ep))
+}
+
+// DeleteEpisode removes the episode and reports the change
+func (s *notifyingStore) DeleteEpisode(episodeID string) error {
+	return s.notify(s.Store.DeleteEpisode(episodeID))
+}
+
+// UpdateEpisode replaces the episode and reports the change
+func (s *notifyingStore) UpdateEpisode(ep models.Episode) error {
+	return s.notify(s.Store.UpdateEpisode(ep))
+}
+
+// UpdateEpisodeFunc updates the episode and reports the change
+func (s *notifyingStore) UpdateEpisodeFunc(episodeID string, update func(ep *models.Episode) error) (*models.Episode, error) {
+	ep, err := s.Store.UpdateEpisodeFunc(episodeID, update)
+	return ep, s.notify(err)
+}
+
+// PublishIfDue publishes the episode if it is due and reports the change
+func (s *notifyingStore) PublishIfDue(episodeID string, now time.Time) (*models.Episode, error) {
+	ep, err := s.Store.PublishIfDue(episodeID, now)
+	if ep != nil {
+		s.notify(err)
+	}
+	return ep, err
+}
+
+// UpdatePodcast replaces the podcast metadata and reports the change
+func (s *notifyingStore) UpdatePodcast(p *models.Podcast) error {
+	return s.notify(s.Store.UpdatePodcast(p))
+}
+
+// TrashEpisode moves the episode to the trash and reports the change
+func (s *notifyingStore) TrashEpisode(episodeID string, deletedAt time.Time) error {
+	return s.notify(s.Store.TrashEpisode(episodeID, deletedAt))
+}
+
+// RestoreEpisode restores the episode from the trash and reports the change
+func (s *notifyingStore) RestoreEpisode(episodeID string) error {
+	return s.notify(s.Store.RestoreEpisode(episodeID))
+}
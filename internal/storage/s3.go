@@ -0,0 +1,456 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/rss-server/internal/media"
+)
+
+// S3Options configures an S3-compatible blob store
+type S3Options struct {
+	Endpoint        string        // e.g. "https://s3.us-east-1.amazonaws.com" or "http://localhost:9000"
+	Region          string        // e.g. "us-east-1"
+	Bucket          string        // bucket name
+	AccessKeyID     string        // access key
+	SecretAccessKey string        // secret key
+	PathStyle       bool          // address the bucket as endpoint/bucket instead of bucket.endpoint
+	PublicURL       string        // optional public/CDN base URL for direct links
+	PresignExpiry   time.Duration // lifetime of signed redirect URLs
+}
+
+// S3BlobStore stores blobs as objects under a key prefix in an S3-compatible bucket
+type S3BlobStore struct {
+	opts      S3Options
+	endpoint  *url.URL
+	prefix    string
+	urlPrefix string
+	client    *http.Client
+	now       func() time.Time
+}
+
+var (
+	_ BlobStore         = (*S3BlobStore)(nil)
+	_ FileStore         = (*S3BlobStore)(nil)
+	_ SignedURLProvider = (*S3BlobStore)(nil)
+)
+
+// NewS3BlobStore creates a blob store that keeps objects under keyPrefix
+// (e.g. "audio/"). When no public URL is configured, blobs are linked under
+// urlPrefix and served through signed redirects.
+func NewS3BlobStore(opts S3Options, keyPrefix string, urlPrefix string) (*S3BlobStore, error) {
+	endpoint, err := url.Parse(strings.TrimRight(opts.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint: %q", opts.Endpoint)
+	}
+
+	if opts.PresignExpiry <= 0 {
+		opts.PresignExpiry = time.Hour
+	}
+
+	return &S3BlobStore{
+		opts:      opts,
+		endpoint:  endpoint,
+		prefix:    keyPrefix,
+		urlPrefix: urlPrefix,
+		client:    &http.Client{},
+		now:       time.Now,
+	}, nil
+}
+
+// Put uploads the blob. The body is spooled to a temp file first so the
+// payload can be hashed and sent with a known length.
+func (s *S3BlobStore) Put(name string, r io.Reader) (int64, error) {
+	if !ValidBlobName(name) {
+		return 0, fmt.Errorf("invalid blob name: %q", name)
+	}
+
+	tmp, err := os.CreateTemp("", "s3-upload-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to buffer upload: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to rewind upload: %w", err)
+	}
+
+	if err := s.upload(name, tmp, size, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// StagingDir returns the system temp directory; staged uploads are sent
+// to the bucket from there
+func (s *S3BlobStore) StagingDir() (string, error) {
+	return os.TempDir(), nil
+}
+
+// PutFile uploads an already hashed local file and removes it
+func (s *S3BlobStore) PutFile(name string, path string, sha256Hex string) error {
+	if !ValidBlobName(name) {
+		return fmt.Errorf("invalid blob name: %q", name)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer os.Remove(path)
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat upload: %w", err)
+	}
+
+	return s.upload(name, f, info.Size(), sha256Hex)
+}
+
+// upload sends body to the bucket with a signed PUT
+func (s *S3BlobStore) upload(name string, body io.Reader, size int64, sha256Hex string) error {
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(name), io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType := media.ContentType(name); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, sha256Hex)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("upload", resp)
+	}
+
+	return nil
+}
+
+// Open returns a seekable reader that fetches the object with range requests
+func (s *S3BlobStore) Open(name string) (Blob, error) {
+	if !ValidBlobName(name) {
+		return nil, fmt.Errorf("invalid blob name: %q", name)
+	}
+
+	req, err := http.NewRequest(http.MethodHead, s.objectURL(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, emptyPayloadHash)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrBlobNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, s3Error("stat", resp)
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return &s3Object{
+		store:   s,
+		name:    name,
+		size:    resp.ContentLength,
+		modTime: modTime,
+	}, nil
+}
+
+// Delete removes the object
+func (s *S3BlobStore) Delete(name string) error {
+	if !ValidBlobName(name) {
+		return fmt.Errorf("invalid blob name: %q", name)
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, s.objectURL(name), nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, emptyPayloadHash)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrBlobNotFound
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return s3Error("delete", resp)
+	}
+
+	return nil
+}
+
+// URL returns the public/CDN URL when configured, otherwise the local
+// redirect URL under urlPrefix
+func (s *S3BlobStore) URL(name string) string {
+	if s.opts.PublicURL != "" {
+		return strings.TrimRight(s.opts.PublicURL, "/") + "/" + s3Escape(s.prefix+name, false)
+	}
+	return s.urlPrefix + url.PathEscape(name)
+}
+
+// SignedURL returns a presigned GET URL for the object
+func (s *S3BlobStore) SignedURL(name string) (string, error) {
+	if !ValidBlobName(name) {
+		return "", fmt.Errorf("invalid blob name: %q", name)
+	}
+
+	u, err := url.Parse(s.objectURL(name))
+	if err != nil {
+		return "", err
+	}
+
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.opts.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(s.opts.PresignExpiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		s3Escape(u.Path, false),
+		canonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(now, amzDate, scope, canonicalRequest))
+	u.RawQuery = canonicalQuery(query)
+
+	return u.String(), nil
+}
+
+// objectURL returns the request URL for a blob
+func (s *S3BlobStore) objectURL(name string) string {
+	key := s3Escape(s.prefix+name, false)
+	if s.opts.PathStyle {
+		return fmt.Sprintf("%s://%s/%s/%s", s.endpoint.Scheme, s.endpoint.Host, s.opts.Bucket, key)
+	}
+	return fmt.Sprintf("%s://%s.%s/%s", s.endpoint.Scheme, s.opts.Bucket, s.endpoint.Host, key)
+}
+
+// emptyPayloadHash is the SHA-256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign adds AWS Signature Version 4 headers to the request
+func (s *S3BlobStore) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Sign host plus every header set on the request
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3Escape(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := s.scope(now)
+	signature := s.signature(now, amzDate, scope, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// scope returns the credential scope for the given time
+func (s *S3BlobStore) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.opts.Region + "/s3/aws4_request"
+}
+
+// signature computes the SigV4 signature of a canonical request
+func (s *S3BlobStore) signature(now time.Time, amzDate string, scope string, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretAccessKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by key, as SigV4 requires
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape URI-encodes s per the SigV4 rules: everything except unreserved
+// characters is percent-encoded, and "/" is kept unless encodeSlash is set
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3Error builds an error from an unexpected S3 response
+func s3Error(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("S3 %s failed: %s: %s", op, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// s3Object is a lazily-fetched object that supports seeking via range requests
+type s3Object struct {
+	store   *S3BlobStore
+	name    string
+	size    int64
+	modTime time.Time
+	offset  int64
+	body    io.ReadCloser
+}
+
+func (o *s3Object) Size() int64        { return o.size }
+func (o *s3Object) ModTime() time.Time { return o.modTime }
+
+// Read fetches the object from the current offset on first use
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+
+	if o.body == nil {
+		req, err := http.NewRequest(http.MethodGet, o.store.objectURL(o.name), nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", o.offset))
+		o.store.sign(req, emptyPayloadHash)
+
+		resp, err := o.store.client.Do(req)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch object: %w", err)
+		}
+		if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return 0, s3Error("get", resp)
+		}
+		o.body = resp.Body
+	}
+
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+// Seek moves the offset; the next Read issues a new range request
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = o.offset + offset
+	case io.SeekEnd:
+		abs = o.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if abs < 0 {
+		return 0, fmt.Errorf("negative position: %d", abs)
+	}
+
+	if abs != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = abs
+	return abs, nil
+}
+
+// Close releases the open response body, if any
+func (o *s3Object) Close() error {
+	if o.body != nil {
+		return o.body.Close()
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// DefaultShow is the slug of the show kept at the configured paths and
+// served at the root routes (/feed.xml, /api/episodes)
+const DefaultShow = "default"
+
+// showSlugPattern matches a URL-safe show slug
+var showSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidShowSlug reports whether slug can name a show
+func ValidShowSlug(slug string) bool {
+	return showSlugPattern.MatchString(slug)
+}
+
+// ErrShowExists is returned when creating a show whose slug is taken
+var ErrShowExists = errors.New("show already exists")
+
+// ShowOpener opens (creating if needed) the store of the named show
+type ShowOpener func(slug string) (Store, error)
+
+// Shows manages the podcasts hosted by one server. Each show has its own
+// Store, and with it its own lock, so writes to one show never wait on
+// another; the manager's lock only guards the set of shows.
+type Shows struct {
+	mu     sync.RWMutex
+	dir    string
+	open   ShowOpener
+	stores map[string]Store
+}
+
+// OpenShows opens every show stored in a subdirectory of dir. New shows
+// are created there by Create.
+func OpenShows(dir string, open ShowOpener) (*Shows, error) {
+	shows := &Shows{dir: dir, open: open, stores: make(map[string]Store)}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list shows: %w", err)
+	}
+
+	for _, entry := range entries {
+		slug := entry.Name()
+		if !entry.IsDir() || !ValidShowSlug(slug) || slug == DefaultShow {
+			continue
+		}
+		store, err := open(slug)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open show %s: %w", slug, err)
+		}
+		shows.stores[slug] = store
+	}
+
+	return shows, nil
+}
+
+// Dir returns the directory a show's files are kept in
+func (s *Shows) Dir(slug string) string {
+	return filepath.Join(s.dir, slug)
+}
+
+// Add registers a store opened by the caller, such as the default show's
+func (s *Shows) Add(slug string, store Store) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.stores[slug]; ok {
+		return fmt.Errorf("%w: %s", ErrShowExists, slug)
+	}
+	s.stores[slug] = store
+	return nil
+}
+
+// Get returns the store of the named show
+func (s *Shows) Get(slug string) (Store, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	store, ok := s.stores[slug]
+	return store, ok
+}
+
+// Slugs returns the slugs of every show, the default show first and the
+// rest in alphabetical order
+func (s *Shows) Slugs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	slugs := make([]string, 0, len(s.stores))
+	for slug := range s.stores {
+		slugs = append(slugs, slug)
+	}
+	sort.Slice(slugs, func(i, j int) bool {
+		if slugs[i] == DefaultShow || slugs[j] == DefaultShow {
+			return slugs[i] == DefaultShow
+		}
+		return slugs[i] < slugs[j]
+	})
+	return slugs
+}
+
+// Create creates a new, empty show
+func (s *Shows) Create(slug string) (Store, error) {
+	if !ValidShowSlug(slug) {
+		return nil, fmt.Errorf("invalid show slug %q: use lowercase letters, digits and dashes", slug)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.stores[slug]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrShowExists, slug)
+	}
+
+	if err := os.MkdirAll(s.Dir(slug), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create show directory: %w", err)
+	}
+	store, err := s.open(slug)
+	if err != nil {
+		return nil, err
+	}
+	s.stores[slug] = store
+	return store, nil
+}
@@ -0,0 +1,638 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+
+	_ "modernc.org/sqlite" // pure-Go SQLite driver
+)
+
+// SQLiteStore keeps podcast and episode metadata in an embedded SQLite database.
+// The RSS feed is generated from the database on demand.
+type SQLiteStore struct {
+	db       *sql.DB
+	baseURL  string
+	revision atomic.Uint64
+
+	// settings is the JSON of the podcast settings last read from or
+	// written to the database, returned by GetPodcast when a read fails
+	settings atomic.Pointer[string]
+}
+
+var _ Store = (*SQLiteStore)(nil)
+
+// migrations are applied in order; each entry is a schema version.
+// Never edit an existing migration, append a new one instead.
+var migrations = []string{
+	// 1: podcast settings and episodes stored as JSON documents,
+	// with the columns needed for ordering broken out
+	`CREATE TABLE podcast (
+		id   INTEGER PRIMARY KEY CHECK (id = 1),
+		data TEXT NOT NULL
+	);
+	CREATE TABLE episodes (
+		id       TEXT PRIMARY KEY,
+		pub_date INTEGER NOT NULL,
+		data     TEXT NOT NULL
+	);
+	CREATE INDEX episodes_pub_date ON episodes (pub_date);`,
+
+	// 2: trashed episodes, kept until restored or purged
+	`CREATE TABLE trash (
+		id         TEXT PRIMARY KEY,
+		deleted_at INTEGER NOT NULL,
+		data       TEXT NOT NULL
+	);`,
+}
+
+// OpenSQLiteStore opens (or creates) the database at path and applies
+// any pending schema migrations
+func OpenSQLiteStore(path string, baseURL string) (*SQLiteStore, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite allows a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	store := &SQLiteStore{db: db, baseURL: baseURL}
+
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Seed the default podcast on first run
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM podcast`).Scan(&count); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read podcast: %w", err)
+	}
+	if count == 0 {
+		if err := store.UpdatePodcast(models.NewDefaultPodcast()); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to save default podcast: %w", err)
+		}
+	}
+	if _, err := store.loadPodcast(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// Close closes the underlying database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// migrate applies schema migrations that have not been applied yet
+func (s *SQLiteStore) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	var current int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %w", version, err)
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", version, err)
+		}
+
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+			version, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", version, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// GetPodcast loads the podcast and its episodes from the database
+func (s *SQLiteStore) GetPodcast() *models.Podcast {
+	p, err := s.loadPodcast(s.db)
+	if err != nil {
+		// Store.GetPodcast has no error return. Fall back to the last known
+		// settings rather than the defaults, which a settings form saving
+		// the podcast back would write over the stored ones.
+		log.Printf("Warning: Failed to load podcast, using the last known settings: %v", err)
+		return s.lastSettings()
+	}
+
+	rows, err := s.db.Query(`SELECT data FROM episodes ORDER BY rowid`)
+	if err != nil {
+		log.Printf("Warning: Failed to load episodes: %v", err)
+		return p
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			log.Printf("Warning: Failed to read episode row: %v", err)
+			continue
+		}
+
+		var ep models.Episode
+		if err := json.Unmarshal([]byte(data), &ep); err != nil {
+			log.Printf("Warning: Failed to decode episode: %v", err)
+			continue
+		}
+		p.Episodes = append(p.Episodes, ep)
+	}
+
+	return p
+}
+
+// AddEpisode inserts an episode and bumps the podcast pub date if needed
+func (s *SQLiteStore) AddEpisode(ep models.Episode) error {
+	data, err := json.Marshal(ep)
+	if err != nil {
+		return fmt.Errorf("failed to encode episode: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO episodes (id, pub_date, data) VALUES (?, ?, ?)`,
+		ep.ID, ep.PubDate.UnixNano(), string(data)); err != nil {
+		return fmt.Errorf("failed to insert episode: %w", err)
+	}
+
+	p, err := s.loadPodcast(tx)
+	if err != nil {
+		return err
+	}
+
+	// Update podcast pub date to latest published episode date
+	if ep.Published() && ep.PubDate.After(p.PubDate) {
+		p.PubDate = ep.PubDate
+		if err := savePodcast(tx, p); err != nil {
+			return err
+		}
+	}
+
+	return s.commit(tx)
+}
+
+// DeleteEpisode removes an episode by ID
+func (s *SQLiteStore) DeleteEpisode(episodeID string) error {
+	res, err := s.db.Exec(`DELETE FROM episodes WHERE id = ?`, episodeID)
+	if err != nil {
+		return fmt.Errorf("failed to delete episode: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("episode not found: %s", episodeID)
+	}
+
+	s.revision.Add(1)
+	return nil
+}
+
+// UpdateEpisode replaces the episode with the same ID, keeping its GUID
+func (s *SQLiteStore) UpdateEpisode(ep models.Episode) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var stored string
+	err = tx.QueryRow(`SELECT data FROM episodes WHERE id = ?`, ep.ID).Scan(&stored)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("episode not found: %s", ep.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load episode: %w", err)
+	}
+
+	var current models.Episode
+	if err := json.Unmarshal([]byte(stored), &current); err != nil {
+		return fmt.Errorf("failed to decode episode: %w", err)
+	}
+	ep.GUID = current.GUID
+
+	data, err := json.Marshal(ep)
+	if err != nil {
+		return fmt.Errorf("failed to encode episode: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE episodes SET pub_date = ?, data = ? WHERE id = ?`,
+		ep.PubDate.UnixNano(), string(data), ep.ID); err != nil {
+		return fmt.Errorf("failed to update episode: %w", err)
+	}
+
+	if ep.Published() {
+		p, err := s.loadPodcast(tx)
+		if err != nil {
+			return err
+		}
+		if ep.PubDate.After(p.PubDate) {
+			p.PubDate = ep.PubDate
+			if err := savePodcast(tx, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.commit(tx)
+}
+
+// UpdateEpisodeFunc applies update to the episode within one transaction
+func (s *SQLiteStore) UpdateEpisodeFunc(episodeID string, update func(ep *models.Episode) error) (*models.Episode, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var stored string
+	err = tx.QueryRow(`SELECT data FROM episodes WHERE id = ?`, episodeID).Scan(&stored)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrEpisodeNotFound, episodeID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load episode: %w", err)
+	}
+
+	var ep models.Episode
+	if err := json.Unmarshal([]byte(stored), &ep); err != nil {
+		return nil, fmt.Errorf("failed to decode episode: %w", err)
+	}
+	guid := ep.GUID
+	if err := update(&ep); err != nil {
+		return nil, err
+	}
+	ep.ID = episodeID
+	ep.GUID = guid
+
+	data, err := json.Marshal(ep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode episode: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE episodes SET pub_date = ?, data = ? WHERE id = ?`,
+		ep.PubDate.UnixNano(), string(data), ep.ID); err != nil {
+		return nil, fmt.Errorf("failed to update episode: %w", err)
+	}
+
+	if ep.Published() {
+		p, err := s.loadPodcast(tx)
+		if err != nil {
+			return nil, err
+		}
+		if ep.PubDate.After(p.PubDate) {
+			p.PubDate = ep.PubDate
+			if err := savePodcast(tx, p); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.commit(tx); err != nil {
+		return nil, err
+	}
+	return &ep, nil
+}
+
+// PublishIfDue publishes the episode if it is scheduled and due
+func (s *SQLiteStore) PublishIfDue(episodeID string, now time.Time) (*models.Episode, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var stored string
+	err = tx.QueryRow(`SELECT data FROM episodes WHERE id = ?`, episodeID).Scan(&stored)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load episode: %w", err)
+	}
+
+	var ep models.Episode
+	if err := json.Unmarshal([]byte(stored), &ep); err != nil {
+		return nil, fmt.Errorf("failed to decode episode: %w", err)
+	}
+	if !ep.Scheduled() || ep.PubDate.After(now) {
+		return nil, nil
+	}
+
+	ep.Status = models.StatusPublished
+	data, err := json.Marshal(ep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode episode: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE episodes SET data = ? WHERE id = ?`, string(data), ep.ID); err != nil {
+		return nil, fmt.Errorf("failed to update episode: %w", err)
+	}
+
+	p, err := s.loadPodcast(tx)
+	if err != nil {
+		return nil, err
+	}
+	if ep.PubDate.After(p.PubDate) {
+		p.PubDate = ep.PubDate
+		if err := savePodcast(tx, p); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.commit(tx); err != nil {
+		return nil, err
+	}
+	return &ep, nil
+}
+
+// TrashEpisode moves an episode to the trash
+func (s *SQLiteStore) TrashEpisode(episodeID string, deletedAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO trash (id, deleted_at, data)
+		SELECT id, ?, data FROM episodes WHERE id = ?`, deletedAt.UnixNano(), episodeID)
+	if err != nil {
+		return fmt.Errorf("failed to trash episode: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("episode not found: %s", episodeID)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM episodes WHERE id = ?`, episodeID); err != nil {
+		return fmt.Errorf("failed to trash episode: %w", err)
+	}
+
+	return s.commit(tx)
+}
+
+// GetTrash returns the trashed episodes, most recently deleted first
+func (s *SQLiteStore) GetTrash() []models.TrashedEpisode {
+	rows, err := s.db.Query(`SELECT deleted_at, data FROM trash ORDER BY deleted_at DESC`)
+	if err != nil {
+		log.Printf("Warning: Failed to load trash: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var trash []models.TrashedEpisode
+	for rows.Next() {
+		var deletedAt int64
+		var data string
+		if err := rows.Scan(&deletedAt, &data); err != nil {
+			log.Printf("Warning: Failed to read trash row: %v", err)
+			continue
+		}
+
+		t := models.TrashedEpisode{DeletedAt: time.Unix(0, deletedAt)}
+		if err := json.Unmarshal([]byte(data), &t.Episode); err != nil {
+			log.Printf("Warning: Failed to decode trashed episode: %v", err)
+			continue
+		}
+		trash = append(trash, t)
+	}
+
+	return trash
+}
+
+// RestoreEpisode moves an episode from the trash back into the podcast
+func (s *SQLiteStore) RestoreEpisode(episodeID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var data string
+	err = tx.QueryRow(`SELECT data FROM trash WHERE id = ?`, episodeID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("episode not found in trash: %s", episodeID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load trashed episode: %w", err)
+	}
+
+	var ep models.Episode
+	if err := json.Unmarshal([]byte(data), &ep); err != nil {
+		return fmt.Errorf("failed to decode trashed episode: %w", err)
+	}
+
+	res, err := tx.Exec(`INSERT OR IGNORE INTO episodes (id, pub_date, data) VALUES (?, ?, ?)`,
+		ep.ID, ep.PubDate.UnixNano(), data)
+	if err != nil {
+		return fmt.Errorf("failed to restore episode: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("episode already exists: %s", episodeID)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM trash WHERE id = ?`, episodeID); err != nil {
+		return fmt.Errorf("failed to restore episode: %w", err)
+	}
+
+	return s.commit(tx)
+}
+
+// PurgeEpisode permanently removes an episode from the trash
+func (s *SQLiteStore) PurgeEpisode(episodeID string) error {
+	res, err := s.db.Exec(`DELETE FROM trash WHERE id = ?`, episodeID)
+	if err != nil {
+		return fmt.Errorf("failed to purge episode: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("episode not found in trash: %s", episodeID)
+	}
+
+	return nil
+}
+
+// UpdatePodcast replaces the podcast-level metadata
+func (s *SQLiteStore) UpdatePodcast(p *models.Podcast) error {
+	if err := savePodcast(s.db, p); err != nil {
+		return err
+	}
+
+	settings := *p
+	settings.Episodes = nil
+	if data, err := json.Marshal(settings); err == nil {
+		saved := string(data)
+		s.settings.Store(&saved)
+	}
+
+	s.revision.Add(1)
+	return nil
+}
+
+// ServeXML renders the RSS feed from the database
+func (s *SQLiteStore) ServeXML() ([]byte, error) {
+	return rss.GenerateFeed(s.GetPodcast(), s.baseURL)
+}
+
+// Revision returns the number of podcast changes made through the store
+// since it was opened
+func (s *SQLiteStore) Revision() uint64 {
+	return s.revision.Load()
+}
+
+// commit commits a transaction that changed the podcast or its episodes
+func (s *SQLiteStore) commit(tx *sql.Tx) error {
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.revision.Add(1)
+	return nil
+}
+
+// ImportFeed performs a one-shot import of an existing file-based store.
+// The JSON metadata sidecar is preferred when present; otherwise the RSS
+// file is parsed with rss.ParseFeed. Episodes already in the database are
+// skipped. It returns the number of episodes imported.
+func (s *SQLiteStore) ImportFeed(rssPath string) (int, error) {
+	p, err := loadMetadata(MetadataPath(rssPath))
+	if err != nil {
+		return 0, err
+	}
+
+	if p == nil {
+		data, err := os.ReadFile(rssPath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read RSS file: %w", err)
+		}
+
+		p, err = parsePodcastXML(data)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse RSS XML: %w", err)
+		}
+
+		for i := range p.Episodes {
+			recoverEpisodeMetadata(&p.Episodes[i])
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := savePodcast(tx, p); err != nil {
+		return 0, err
+	}
+
+	imported := 0
+	for _, ep := range p.Episodes {
+		data, err := json.Marshal(ep)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode episode %s: %w", ep.ID, err)
+		}
+
+		res, err := tx.Exec(`INSERT OR IGNORE INTO episodes (id, pub_date, data) VALUES (?, ?, ?)`,
+			ep.ID, ep.PubDate.UnixNano(), string(data))
+		if err != nil {
+			return 0, fmt.Errorf("failed to import episode %s: %w", ep.ID, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			imported++
+		}
+	}
+
+	if err := s.commit(tx); err != nil {
+		return 0, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return imported, nil
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// loadPodcast reads the podcast-level metadata (without episodes)
+func (s *SQLiteStore) loadPodcast(q querier) (*models.Podcast, error) {
+	var data string
+	if err := q.QueryRow(`SELECT data FROM podcast WHERE id = 1`).Scan(&data); err != nil {
+		return nil, fmt.Errorf("failed to read podcast: %w", err)
+	}
+
+	var p models.Podcast
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		return nil, fmt.Errorf("failed to decode podcast: %w", err)
+	}
+	p.Episodes = []models.Episode{}
+	s.settings.Store(&data)
+
+	return &p, nil
+}
+
+// lastSettings returns a copy of the podcast settings last read from or
+// written to the database, without episodes
+func (s *SQLiteStore) lastSettings() *models.Podcast {
+	var p models.Podcast
+	if data := s.settings.Load(); data != nil {
+		if err := json.Unmarshal([]byte(*data), &p); err != nil {
+			log.Printf("Warning: Failed to decode podcast settings: %v", err)
+		}
+	}
+	p.Episodes = []models.Episode{}
+
+	return &p
+}
+
+// savePodcast writes the podcast-level metadata; episodes are stored separately
+func savePodcast(q querier, p *models.Podcast) error {
+	settings := *p
+	settings.Episodes = nil
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode podcast: %w", err)
+	}
+
+	if _, err := q.Exec(`INSERT INTO podcast (id, data) VALUES (1, ?)
+		ON CONFLICT (id) DO UPDATE SET data = excluded.data`, string(data)); err != nil {
+		return fmt.Errorf("failed to save podcast: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+)
+
+// Store persists podcast and episode metadata.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// GetPodcast returns a copy of the podcast including its episodes
+	GetPodcast() *models.Podcast
+
+	// AddEpisode adds a new episode to the podcast
+	AddEpisode(ep models.Episode) error
+
+	// DeleteEpisode removes an episode by ID
+	DeleteEpisode(episodeID string) error
+
+	// UpdateEpisode replaces the stored episode with the same ID. The
+	// stored GUID is kept: it identifies the episode to podcast apps.
+	UpdateEpisode(ep models.Episode) error
+
+	// UpdateEpisodeFunc calls update with a copy of the stored episode and
+	// saves the result, reading and writing it in one step so concurrent
+	// edits are never overwritten. If update returns an error nothing is
+	// saved and the error is returned as is. The episode's ID and GUID are
+	// kept. update must not call the store. It returns the saved episode,
+	// or an error wrapping ErrEpisodeNotFound if there is none.
+	UpdateEpisodeFunc(episodeID string, update func(ep *models.Episode) error) (*models.Episode, error)
+
+	// PublishIfDue publishes the episode if it is still scheduled and its
+	// publication date is not after now, checking and updating it in one
+	// step so concurrent edits are never overwritten. It returns the
+	// published episode, or nil if there was nothing to publish.
+	PublishIfDue(episodeID string, now time.Time) (*models.Episode, error)
+
+	// UpdatePodcast replaces the podcast-level metadata, preserving episodes
+	UpdatePodcast(p *models.Podcast) error
+
+	// TrashEpisode moves an episode to the trash, out of the podcast
+	TrashEpisode(episodeID string, deletedAt time.Time) error
+
+	// GetTrash returns the trashed episodes, most recently deleted first
+	GetTrash() []models.TrashedEpisode
+
+	// RestoreEpisode moves an episode from the trash back into the podcast
+	RestoreEpisode(episodeID string) error
+
+	// PurgeEpisode permanently removes an episode from the trash
+	PurgeEpisode(episodeID string) error
+
+	// ServeXML renders the RSS feed
+	ServeXML() ([]byte, error)
+
+	// Revision returns a number that changes whenever the podcast or its
+	// episodes change, so feeds rendered from the store can be cached until
+	// it does. Read it before rendering, never after.
+	Revision() uint64
+}
+
+// BlobStore persists binary assets such as audio and artwork files.
+// Blobs are addressed by a flat, URL-safe name.
+// Implementations must be safe for concurrent use.
+type BlobStore interface {
+	// Put stores the contents of r under name and returns the number of bytes written
+	Put(name string, r io.Reader) (int64, error)
+
+	// Open returns a reader for the named blob
+	Open(name string) (Blob, error)
+
+	// Delete removes the named blob
+	Delete(name string) error
+
+	// URL returns the URL the blob is served from (may be relative to the base URL)
+	URL(name string) string
+}
+
+// Blob is an open, seekable blob returned by BlobStore.Open
+type Blob interface {
+	io.ReadSeekCloser
+
+	// Size returns the blob size in bytes
+	Size() int64
+
+	// ModTime returns when the blob was last written
+	ModTime() time.Time
+}
+
+// ErrBlobNotFound is returned when a blob does not exist
+var ErrBlobNotFound = errors.New("blob not found")
+
+// ErrEpisodeNotFound is returned when an episode does not exist
+var ErrEpisodeNotFound = errors.New("episode not found")
+
+// SignedURLProvider is implemented by blob stores that can hand out
+// short-lived direct download URLs. Blobs from such stores are served by
+// redirecting to the signed URL instead of proxying the content.
+type SignedURLProvider interface {
+	SignedURL(name string) (string, error)
+}
+
+// FileStore is implemented by blob stores that can adopt a file already
+// written to local disk, so large uploads are not copied a second time
+type FileStore interface {
+	// StagingDir returns the directory uploads should be staged in. For
+	// local stores this is the blob directory, making PutFile a rename.
+	StagingDir() (string, error)
+
+	// PutFile moves the file at path into the store as name. sha256Hex is
+	// the hex-encoded SHA-256 of the file contents.
+	PutFile(name string, path string, sha256Hex string) error
+}
+
+// EnsureGUID gives a podcast without a podcast:guid the one derived from
+// its feed URL and saves it, so the feed keeps its identity when the base
+// URL changes later. It returns the podcast's GUID.
+func EnsureGUID(store Store, feedURL string) (string, error) {
+	p := store.GetPodcast()
+	if p.GUID != "" {
+		return p.GUID, nil
+	}
+
+	p.GUID = rss.FeedGUID(feedURL)
+	if err := store.UpdatePodcast(p); err != nil {
+		return "", fmt.Errorf("failed to save podcast GUID: %w", err)
+	}
+	return p.GUID, nil
+}
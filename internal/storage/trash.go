@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/example/rss-server/internal/models"
+)
+
+// TrashPath returns the path of the trash file for an RSS file
+// (e.g. "data/podcast.xml" -> "data/podcast.trash.json")
+func TrashPath(rssPath string) string {
+	return strings.TrimSuffix(rssPath, filepath.Ext(rssPath)) + ".trash.json"
+}
+
+// loadTrash reads a trash file, returning an empty trash if it does not
+// exist yet
+func loadTrash(trashPath string) ([]models.TrashedEpisode, error) {
+	data, err := os.ReadFile(trashPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trash: %w", err)
+	}
+
+	var trash []models.TrashedEpisode
+	if err := json.Unmarshal(data, &trash); err != nil {
+		return nil, fmt.Errorf("failed to parse trash: %w", err)
+	}
+	return trash, nil
+}
+
+// sortTrash orders trashed episodes most recently deleted first
+func sortTrash(trash []models.TrashedEpisode) {
+	sort.SliceStable(trash, func(i, j int) bool {
+		return trash[i].DeletedAt.After(trash[j].DeletedAt)
+	})
+}
+
+// MoveBlob moves the named blob from one store to another. Local stores
+// on the same filesystem are moved with a rename; otherwise the blob is
+// copied and then deleted from the source.
+func MoveBlob(name string, from BlobStore, to BlobStore) error {
+	if src, ok := from.(*FSBlobStore); ok {
+		if dst, ok := to.(*FSBlobStore); ok {
+			srcPath, err := src.path(name)
+			if err != nil {
+				return err
+			}
+			dstPath, err := dst.path(name)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dst.dir, 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			err = os.Rename(srcPath, dstPath)
+			if os.IsNotExist(err) {
+				return ErrBlobNotFound
+			}
+			if err == nil {
+				return nil
+			}
+			// Fall back to copying, e.g. across filesystems
+		}
+	}
+
+	blob, err := from.Open(name)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	if _, err := to.Put(name, blob); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", name, err)
+	}
+	blob.Close()
+
+	return from.Delete(name)
+}
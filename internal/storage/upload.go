@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrTooLarge is returned when a staged upload exceeds its size limit
+var ErrTooLarge = errors.New("upload exceeds size limit")
+
+// StagedFile is an upload streamed to a local temp file, ready to be
+// inspected and then committed to a BlobStore
+type StagedFile struct {
+	file   *os.File
+	size   int64
+	sha256 string
+	done   bool
+}
+
+// StageUpload streams r into a temp file, hashing it and enforcing maxSize
+// as it goes. When blobs is a FileStore the temp file is created in its
+// staging directory so that committing it is an atomic rename.
+func StageUpload(blobs BlobStore, r io.Reader, maxSize int64) (*StagedFile, error) {
+	dir := ""
+	if fs, ok := blobs.(FileStore); ok {
+		var err error
+		if dir, err = fs.StagingDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, ".upload-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	staged := &StagedFile{file: tmp}
+
+	// Read one byte past the limit to detect oversized uploads
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(r, maxSize+1))
+	if err != nil {
+		staged.Discard()
+		return nil, fmt.Errorf("failed to write upload: %w", err)
+	}
+	if size > maxSize {
+		staged.Discard()
+		return nil, ErrTooLarge
+	}
+
+	staged.size = size
+	staged.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return staged, nil
+}
+
+// Reader returns a seekable reader over the staged contents
+func (s *StagedFile) Reader() io.ReadSeeker {
+	return s.file
+}
+
+// Size returns the staged size in bytes
+func (s *StagedFile) Size() int64 {
+	return s.size
+}
+
+// SHA256 returns the hex-encoded SHA-256 of the staged contents
+func (s *StagedFile) SHA256() string {
+	return s.sha256
+}
+
+// Commit stores the staged file in blobs under name. The temp file is
+// consumed whether or not the commit succeeds.
+func (s *StagedFile) Commit(blobs BlobStore, name string) error {
+	if s.done {
+		return fmt.Errorf("staged upload already committed")
+	}
+	defer s.Discard()
+
+	if fs, ok := blobs.(FileStore); ok {
+		if err := s.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync upload: %w", err)
+		}
+		if err := s.file.Close(); err != nil {
+			return fmt.Errorf("failed to close upload: %w", err)
+		}
+		return fs.PutFile(name, s.file.Name(), s.sha256)
+	}
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind upload: %w", err)
+	}
+	_, err := blobs.Put(name, s.file)
+	return err
+}
+
+// Discard removes the temp file. It is safe to call more than once.
+func (s *StagedFile) Discard() {
+	if s.done {
+		return
+	}
+	s.done = true
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
+
+// StageFile adopts an existing local file, such as a completed resumable
+// upload, as a staged upload. The file is moved into the staging directory
+// when possible and copied otherwise; either way path no longer exists
+// afterwards.
+func StageFile(blobs BlobStore, path string) (*StagedFile, error) {
+	dir := os.TempDir()
+	if fs, ok := blobs.(FileStore); ok {
+		var err error
+		if dir, err = fs.StagingDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, ".upload-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmp.Close()
+
+	if err := os.Rename(path, tmp.Name()); err != nil {
+		// Different filesystem: copy instead
+		os.Remove(tmp.Name())
+		src, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open upload: %w", err)
+		}
+		defer os.Remove(path)
+		defer src.Close()
+
+		info, err := src.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat upload: %w", err)
+		}
+		return StageUpload(blobs, src, info.Size())
+	}
+
+	f, err := os.OpenFile(tmp.Name(), os.O_RDWR, 0)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+	staged := &StagedFile{file: f}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		staged.Discard()
+		return nil, fmt.Errorf("failed to hash upload: %w", err)
+	}
+
+	staged.size = size
+	staged.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return staged, nil
+}
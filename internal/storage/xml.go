@@ -1,31 +1,58 @@
 package storage
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/example/rss-server/internal/models"
 	"github.com/example/rss-server/internal/rss"
 )
 
-// RSSStore manages the RSS feed with thread-safe access
+// RSSStore manages the RSS feed with thread-safe access.
+// Podcast and episode metadata is persisted in a JSON sidecar file next to
+// the RSS file; the RSS file itself is regenerated from that metadata.
 type RSSStore struct {
-	mu       sync.RWMutex
-	podcast  *models.Podcast
-	filepath string
-	baseURL  string
+	mu        sync.RWMutex
+	podcast   *models.Podcast
+	trash     []models.TrashedEpisode
+	filepath  string
+	metaPath  string
+	trashPath string
+	baseURL   string
+	revision  uint64
 }
 
+var _ Store = (*RSSStore)(nil)
+
 // LoadRSSStore loads or creates a new RSS store from the given file path
 func LoadRSSStore(path string, baseURL string) (*RSSStore, error) {
 	store := &RSSStore{
-		filepath: path,
-		baseURL:  baseURL,
+		filepath:  path,
+		metaPath:  MetadataPath(path),
+		trashPath: TrashPath(path),
+		baseURL:   baseURL,
+	}
+
+	// Prefer the metadata sidecar, which holds every episode field
+	p, err := loadMetadata(store.metaPath)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		store.podcast = p
+		if err := store.loadTrash(); err != nil {
+			return nil, err
+		}
+		return store, nil
 	}
 
-	// Try to load existing feed
+	// Fall back to an existing feed written before the sidecar existed
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -43,15 +70,32 @@ func LoadRSSStore(path string, baseURL string) (*RSSStore, error) {
 	}
 
 	// Parse existing feed
-	p, err := parsePodcastXML(data)
+	p, err = parsePodcastXML(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse RSS XML: %w", err)
 	}
 
+	// Recover internal metadata that the public feed does not carry
+	for i := range p.Episodes {
+		recoverEpisodeMetadata(&p.Episodes[i])
+	}
+
 	store.podcast = p
+
+	// Write the sidecar so subsequent loads no longer depend on the feed
+	if err := store.saveToDisk(); err != nil {
+		return nil, fmt.Errorf("failed to migrate RSS file to metadata store: %w", err)
+	}
+
 	return store, nil
 }
 
+// MetadataPath returns the path of the metadata sidecar for an RSS file
+// (e.g. "data/podcast.xml" -> "data/podcast.json")
+func MetadataPath(rssPath string) string {
+	return strings.TrimSuffix(rssPath, filepath.Ext(rssPath)) + ".json"
+}
+
 // GetPodcast returns a copy of the current podcast (thread-safe read)
 func (s *RSSStore) GetPodcast() *models.Podcast {
 	s.mu.RLock()
@@ -70,8 +114,8 @@ func (s *RSSStore) AddEpisode(ep models.Episode) error {
 	// Add episode to list
 	s.podcast.Episodes = append(s.podcast.Episodes, ep)
 
-	// Update podcast pub date to latest episode date
-	if ep.PubDate.After(s.podcast.PubDate) {
+	// Update podcast pub date to latest published episode date
+	if ep.Published() && ep.PubDate.After(s.podcast.PubDate) {
 		s.podcast.PubDate = ep.PubDate
 	}
 
@@ -105,6 +149,92 @@ func (s *RSSStore) DeleteEpisode(episodeID string) error {
 	return s.saveToDisk()
 }
 
+// UpdateEpisode replaces the episode with the same ID and saves atomically.
+// The stored GUID is kept so podcast apps don't see a new episode.
+func (s *RSSStore) UpdateEpisode(ep models.Episode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.podcast.Episodes {
+		if s.podcast.Episodes[i].ID == ep.ID {
+			ep.GUID = s.podcast.Episodes[i].GUID
+
+			// Copy rather than write in place: GetPodcast callers share the slice
+			episodes := append([]models.Episode(nil), s.podcast.Episodes...)
+			episodes[i] = ep
+			s.podcast.Episodes = episodes
+
+			if ep.Published() && ep.PubDate.After(s.podcast.PubDate) {
+				s.podcast.PubDate = ep.PubDate
+			}
+			return s.saveToDisk()
+		}
+	}
+
+	return fmt.Errorf("episode not found: %s", ep.ID)
+}
+
+// UpdateEpisodeFunc applies update to the episode under the store lock and
+// saves atomically
+func (s *RSSStore) UpdateEpisodeFunc(episodeID string, update func(ep *models.Episode) error) (*models.Episode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, ep := range s.podcast.Episodes {
+		if ep.ID != episodeID {
+			continue
+		}
+		if err := update(&ep); err != nil {
+			return nil, err
+		}
+		ep.ID = episodeID
+		ep.GUID = s.podcast.Episodes[i].GUID
+
+		episodes := append([]models.Episode(nil), s.podcast.Episodes...)
+		episodes[i] = ep
+		s.podcast.Episodes = episodes
+
+		if ep.Published() && ep.PubDate.After(s.podcast.PubDate) {
+			s.podcast.PubDate = ep.PubDate
+		}
+		if err := s.saveToDisk(); err != nil {
+			return nil, err
+		}
+		return &ep, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrEpisodeNotFound, episodeID)
+}
+
+// PublishIfDue publishes the episode if it is scheduled and due
+func (s *RSSStore) PublishIfDue(episodeID string, now time.Time) (*models.Episode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, ep := range s.podcast.Episodes {
+		if ep.ID != episodeID {
+			continue
+		}
+		if !ep.Scheduled() || ep.PubDate.After(now) {
+			return nil, nil
+		}
+
+		ep.Status = models.StatusPublished
+		episodes := append([]models.Episode(nil), s.podcast.Episodes...)
+		episodes[i] = ep
+		s.podcast.Episodes = episodes
+
+		if ep.PubDate.After(s.podcast.PubDate) {
+			s.podcast.PubDate = ep.PubDate
+		}
+		if err := s.saveToDisk(); err != nil {
+			return nil, err
+		}
+		return &ep, nil
+	}
+	return nil, nil
+}
+
 // UpdatePodcast updates the podcast-level metadata
 func (s *RSSStore) UpdatePodcast(p *models.Podcast) error {
 	s.mu.Lock()
@@ -128,25 +258,246 @@ func (s *RSSStore) ServeXML() ([]byte, error) {
 	return rss.GenerateFeed(s.podcast, s.baseURL)
 }
 
-// saveToDisk writes the podcast to disk using atomic write (temp file + rename)
+// Revision returns the number of podcast changes since the store was loaded
+func (s *RSSStore) Revision() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.revision
+}
+
+// TrashEpisode moves an episode to the trash. The trash is written before
+// the episode is removed from the sidecar, so an interrupted move leaves
+// the episode live rather than lost.
+func (s *RSSStore) TrashEpisode(episodeID string, deletedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, ep := range s.podcast.Episodes {
+		if ep.ID != episodeID {
+			continue
+		}
+
+		trash := append([]models.TrashedEpisode{{Episode: ep, DeletedAt: deletedAt}}, s.trash...)
+		if err := s.saveTrash(trash); err != nil {
+			return err
+		}
+		s.trash = trash
+
+		episodes := make([]models.Episode, 0, len(s.podcast.Episodes)-1)
+		episodes = append(episodes, s.podcast.Episodes[:i]...)
+		s.podcast.Episodes = append(episodes, s.podcast.Episodes[i+1:]...)
+		return s.saveToDisk()
+	}
+
+	return fmt.Errorf("episode not found: %s", episodeID)
+}
+
+// GetTrash returns a copy of the trashed episodes, most recently deleted first
+func (s *RSSStore) GetTrash() []models.TrashedEpisode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.TrashedEpisode(nil), s.trash...)
+}
+
+// RestoreEpisode moves an episode from the trash back into the podcast
+func (s *RSSStore) RestoreEpisode(episodeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.trashIndex(episodeID)
+	if i < 0 {
+		return fmt.Errorf("episode not found in trash: %s", episodeID)
+	}
+	for _, ep := range s.podcast.Episodes {
+		if ep.ID == episodeID {
+			return fmt.Errorf("episode already exists: %s", episodeID)
+		}
+	}
+
+	s.podcast.Episodes = append(append([]models.Episode(nil), s.podcast.Episodes...), s.trash[i].Episode)
+	if err := s.saveToDisk(); err != nil {
+		return err
+	}
+	return s.removeFromTrash(i)
+}
+
+// PurgeEpisode permanently removes an episode from the trash
+func (s *RSSStore) PurgeEpisode(episodeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.trashIndex(episodeID)
+	if i < 0 {
+		return fmt.Errorf("episode not found in trash: %s", episodeID)
+	}
+	return s.removeFromTrash(i)
+}
+
+// trashIndex returns the position of an episode in the trash, or -1
+func (s *RSSStore) trashIndex(episodeID string) int {
+	for i, t := range s.trash {
+		if t.ID == episodeID {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeFromTrash drops the i-th trashed episode and saves the trash
+func (s *RSSStore) removeFromTrash(i int) error {
+	trash := make([]models.TrashedEpisode, 0, len(s.trash)-1)
+	trash = append(trash, s.trash[:i]...)
+	trash = append(trash, s.trash[i+1:]...)
+	if err := s.saveTrash(trash); err != nil {
+		return err
+	}
+	s.trash = trash
+	return nil
+}
+
+// loadTrash reads the trash file. Entries for episodes that are also live
+// (left behind by an interrupted move) are dropped.
+func (s *RSSStore) loadTrash() error {
+	trash, err := loadTrash(s.trashPath)
+	if err != nil {
+		return err
+	}
+
+	live := make(map[string]bool, len(s.podcast.Episodes))
+	for _, ep := range s.podcast.Episodes {
+		live[ep.ID] = true
+	}
+	for _, t := range trash {
+		if !live[t.ID] {
+			s.trash = append(s.trash, t)
+		}
+	}
+	sortTrash(s.trash)
+	return nil
+}
+
+// saveTrash writes the trash file
+func (s *RSSStore) saveTrash(trash []models.TrashedEpisode) error {
+	data, err := json.MarshalIndent(trash, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trash: %w", err)
+	}
+	if err := writeFileAtomic(s.trashPath, data); err != nil {
+		return fmt.Errorf("failed to write trash: %w", err)
+	}
+	return nil
+}
+
+// saveToDisk writes the metadata sidecar and the regenerated RSS feed,
+// each using an atomic write (temp file + rename).
+// The sidecar is written first since it is the source of truth.
 // T038: Updated to pass baseURL to GenerateFeed()
 func (s *RSSStore) saveToDisk() error {
+	s.revision++
+
+	metaData, err := json.MarshalIndent(s.podcast, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode podcast metadata: %w", err)
+	}
+
+	if err := writeFileAtomic(s.metaPath, metaData); err != nil {
+		return fmt.Errorf("failed to write podcast metadata: %w", err)
+	}
+
 	// Generate RSS XML
 	xmlData, err := rss.GenerateFeed(s.podcast, s.baseURL)
 	if err != nil {
 		return fmt.Errorf("failed to generate RSS XML: %w", err)
 	}
 
-	// Write to temp file first
-	dir := filepath.Dir(s.filepath)
-	tmpFile := filepath.Join(dir, ".podcast.xml.tmp")
+	if err := writeFileAtomic(s.filepath, xmlData); err != nil {
+		return fmt.Errorf("failed to write RSS file: %w", err)
+	}
+
+	return nil
+}
+
+// loadMetadata reads the metadata sidecar. It returns nil without an error
+// if the sidecar does not exist yet.
+func loadMetadata(metaPath string) (*models.Podcast, error) {
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read podcast metadata: %w", err)
+	}
+
+	var p models.Podcast
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse podcast metadata: %w", err)
+	}
+
+	if p.Episodes == nil {
+		p.Episodes = []models.Episode{}
+	}
+
+	return &p, nil
+}
+
+// recoverEpisodeMetadata fills in internal fields for an episode parsed from
+// a feed written before the metadata sidecar existed
+func recoverEpisodeMetadata(ep *models.Episode) {
+	// Audio served by this server lives under /audio/{filename}
+	if ep.Filename == "" && ep.AudioURL != "" {
+		audioPath := ep.AudioURL
+		if idx := strings.Index(audioPath, "/audio/"); idx >= 0 {
+			ep.Filename = path.Base(audioPath[idx:])
+		}
+	}
+
+	if ep.UploadDate.IsZero() {
+		ep.UploadDate = ep.PubDate
+	}
+}
+
+// writeFileAtomic writes data to a temp file in the target directory and
+// renames it over the target, so readers never observe a partial file
+func writeFileAtomic(target string, data []byte) error {
+	dir := filepath.Dir(target)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
 
-	if err := os.WriteFile(tmpFile, xmlData, 0644); err != nil {
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpFile := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpFile)
 		return fmt.Errorf("failed to write temp file: %w", err)
 	}
 
+	// Flush to stable storage before the rename makes it visible
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Chmod(tmpFile, 0644); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to set temp file permissions: %w", err)
+	}
+
 	// Atomic rename (replaces old file)
-	if err := os.Rename(tmpFile, s.filepath); err != nil {
+	if err := os.Rename(tmpFile, target); err != nil {
 		os.Remove(tmpFile) // Clean up temp file on error
 		return fmt.Errorf("failed to rename temp file: %w", err)
 	}
@@ -0,0 +1,207 @@
+package websub
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Retry policy for failed deliveries: the wait doubles after each failed
+// attempt, from initialBackoff up to maxBackoff, and a ping is given up
+// after maxAttempts attempts
+const (
+	initialBackoff = 30 * time.Second
+	maxBackoff     = time.Hour
+	maxAttempts    = 10
+)
+
+// delivery is a pending ping of one hub about one topic
+type delivery struct {
+	hub      string
+	topic    string
+	attempts int
+	due      time.Time
+
+	// renotified is set when a change is notified after the last ping was
+	// sent, which therefore may not cover it
+	renotified bool
+}
+
+// Publisher notifies WebSub hubs that feeds changed, so subscribers are
+// updated in real time instead of polling. Pings are queued and delivered
+// in the background; failed deliveries are retried with exponential
+// backoff. A change notified while a ping of the same hub and topic is
+// still queued is covered by that ping, since the hub fetches the feed's
+// current content.
+type Publisher struct {
+	hubs []string
+
+	mu      sync.Mutex
+	pending map[string]*delivery
+	wake    chan struct{}
+
+	// Client sends the pings; tests may replace it
+	Client *http.Client
+
+	// Now returns the current time; tests may replace it
+	Now func() time.Time
+}
+
+// New creates a publisher pinging the given hub URLs
+func New(hubs []string) *Publisher {
+	return &Publisher{
+		hubs:    hubs,
+		pending: make(map[string]*delivery),
+		wake:    make(chan struct{}, 1),
+		Client:  &http.Client{Timeout: 30 * time.Second},
+		Now:     time.Now,
+	}
+}
+
+// Notify queues a ping of every hub for each of the topic (feed) URLs
+func (p *Publisher) Notify(topics ...string) {
+	p.mu.Lock()
+	now := p.Now()
+	for _, hub := range p.hubs {
+		for _, topic := range topics {
+			key := hub + " " + topic
+			if d, ok := p.pending[key]; ok {
+				d.renotified = true
+			} else {
+				p.pending[key] = &delivery{hub: hub, topic: topic, due: now}
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pending returns the number of queued pings, including those waiting to
+// be retried
+func (p *Publisher) Pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pending)
+}
+
+// DeliverDue sends every queued ping that is due and returns how many were
+// delivered. Failed pings are rescheduled, or dropped once they have been
+// attempted maxAttempts times or the hub rejected them.
+func (p *Publisher) DeliverDue() int {
+	now := p.Now()
+
+	p.mu.Lock()
+	var due []*delivery
+	for _, d := range p.pending {
+		if !d.due.After(now) {
+			d.renotified = false
+			due = append(due, d)
+		}
+	}
+	p.mu.Unlock()
+
+	delivered := 0
+	for _, d := range due {
+		retry, err := p.ping(d.hub, d.topic)
+
+		p.mu.Lock()
+		d.attempts++
+		switch {
+		case err == nil && d.renotified:
+			// The feed changed again while the ping was being sent
+			delivered++
+			d.attempts, d.due = 0, now
+		case err == nil:
+			delivered++
+			delete(p.pending, d.hub+" "+d.topic)
+		case !retry || d.attempts >= maxAttempts:
+			log.Printf("Warning: Giving up notifying WebSub hub %s about %s after %d attempts: %v", d.hub, d.topic, d.attempts, err)
+			delete(p.pending, d.hub+" "+d.topic)
+		default:
+			d.due = now.Add(backoff(d.attempts))
+			log.Printf("Warning: Failed to notify WebSub hub %s about %s, retrying at %s: %v", d.hub, d.topic, d.due.Format(time.RFC3339), err)
+		}
+		p.mu.Unlock()
+	}
+	return delivered
+}
+
+// NextDue returns when the earliest queued ping is due
+func (p *Publisher) NextDue() (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var next time.Time
+	found := false
+	for _, d := range p.pending {
+		if !found || d.due.Before(next) {
+			next, found = d.due, true
+		}
+	}
+	return next, found
+}
+
+// Start delivers queued pings until stop is closed. It wakes when a change
+// is notified and when the next retry is due.
+func (p *Publisher) Start(stop <-chan struct{}) {
+	go func() {
+		for {
+			p.DeliverDue()
+
+			var timeout <-chan time.Time
+			var timer *time.Timer
+			if next, ok := p.NextDue(); ok {
+				timer = time.NewTimer(max(next.Sub(p.Now()), time.Second))
+				timeout = timer.C
+			}
+
+			select {
+			case <-p.wake:
+			case <-timeout:
+			case <-stop:
+				return
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}()
+}
+
+// ping sends a publish notification to the hub. It reports whether a
+// failed ping is worth retrying: network errors, rate limiting and server
+// errors are, other rejections are not.
+func (p *Publisher) ping(hub, topic string) (bool, error) {
+	form := url.Values{"hub.mode": {"publish"}, "hub.url": {topic}}
+	resp, err := p.Client.Post(hub, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("hub responded with status %d", resp.StatusCode)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// backoff returns the wait before the next attempt after the given number
+// of failed attempts
+func backoff(attempts int) time.Duration {
+	wait := initialBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// openStores returns each Store implementation backed by a temp dir
+func openStores(t *testing.T) map[string]func() storage.Store {
+	t.Helper()
+	dir := t.TempDir()
+
+	return map[string]func() storage.Store{
+		"file": func() storage.Store {
+			store, err := storage.LoadRSSStore(filepath.Join(dir, "podcast.xml"), "http://example.com")
+			if err != nil {
+				t.Fatalf("Failed to load RSS store: %v", err)
+			}
+			return store
+		},
+		"sqlite": func() storage.Store {
+			store, err := storage.OpenSQLiteStore(filepath.Join(dir, "podcast.db"), "http://example.com")
+			if err != nil {
+				t.Fatalf("Failed to open SQLite store: %v", err)
+			}
+			t.Cleanup(func() { store.Close() })
+			return store
+		},
+	}
+}
+
+// UpdateEpisode replaces an episode in place and persists across reopen
+func TestStoreUpdateEpisode(t *testing.T) {
+	for name, open := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open()
+
+			pubDate := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+			for _, id := range []string{"ep-1", "ep-2"} {
+				if err := store.AddEpisode(models.Episode{ID: id, GUID: id, Title: id, Description: id, PubDate: pubDate, AudioURL: "/audio/" + id + ".mp3"}); err != nil {
+					t.Fatalf("Failed to add episode: %v", err)
+				}
+			}
+
+			// A copy taken before the update must not change underneath its holder
+			before := store.GetPodcast()
+
+			ep := before.Episodes[0]
+			ep.Chapters = []models.Chapter{{StartTime: 0, Title: "Intro"}, {StartTime: 60, Title: "Main"}}
+			ep.GUID = "changed"
+			if err := store.UpdateEpisode(ep); err != nil {
+				t.Fatalf("Failed to update episode: %v", err)
+			}
+			if err := store.UpdateEpisode(models.Episode{ID: "missing"}); err == nil {
+				t.Error("Expected error updating a missing episode")
+			}
+
+			if len(before.Episodes[0].Chapters) != 0 {
+				t.Error("Expected earlier GetPodcast copy to be unaffected")
+			}
+
+			after := open().GetPodcast()
+			if len(after.Episodes) != 2 {
+				t.Fatalf("Expected 2 episodes, got %d", len(after.Episodes))
+			}
+			for _, got := range after.Episodes {
+				if got.ID == "ep-1" && len(got.Chapters) != 2 {
+					t.Errorf("Expected updated chapters after reopen, got %+v", got.Chapters)
+				}
+				if got.ID == "ep-1" && got.GUID != "ep-1" {
+					t.Errorf("Expected GUID to be preserved, got %q", got.GUID)
+				}
+				if got.ID == "ep-2" && len(got.Chapters) != 0 {
+					t.Errorf("Expected other episode untouched, got %+v", got.Chapters)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package integration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// The feed carries validators, answers conditional requests with 304 until
+// the store changes, and is compressed as the client asks
+func TestFeedConditionalGet(t *testing.T) {
+	store, err := storage.LoadRSSStore(filepath.Join(t.TempDir(), "podcast.xml"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	addEpisode := func(id string) {
+		t.Helper()
+		ep := models.Episode{ID: id, Title: "Episode " + id, Description: "d", PubDate: time.Now(), AudioURL: "/audio/" + id + ".mp3", AudioLength: 100}
+		if err := store.AddEpisode(ep); err != nil {
+			t.Fatalf("Failed to add episode: %v", err)
+		}
+	}
+	addEpisode("ep-1")
+
+	h := handlers.NewFeedHandler(store, "http://example.com")
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.HandleFeed(rec, req)
+		return rec
+	}
+
+	first := get(nil)
+	etag, lastModified := first.Header().Get("ETag"), first.Header().Get("Last-Modified")
+	if first.Code != http.StatusOK || etag == "" || lastModified == "" {
+		t.Fatalf("Expected 200 with ETag and Last-Modified, got %d %q %q", first.Code, etag, lastModified)
+	}
+	if first.Header().Get("Cache-Control") == "" || first.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Expected Cache-Control and Vary headers, got %v", first.Header())
+	}
+	body := first.Body.Bytes()
+
+	if rec := get(map[string]string{"If-None-Match": etag}); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("Expected 304 for a matching ETag, got %d", rec.Code)
+	}
+	if rec := get(map[string]string{"If-Modified-Since": lastModified}); rec.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for an unchanged feed, got %d", rec.Code)
+	}
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	}
+	for encoding, decode := range decoders {
+		rec := get(map[string]string{"Accept-Encoding": encoding})
+		if rec.Header().Get("Content-Encoding") != encoding {
+			t.Fatalf("Expected Content-Encoding %s, got %q", encoding, rec.Header().Get("Content-Encoding"))
+		}
+		if rec.Header().Get("ETag") == etag {
+			t.Errorf("Expected the %s variant to have its own ETag", encoding)
+		}
+		r, err := decode(rec.Body)
+		if err != nil {
+			t.Fatalf("Failed to decode %s feed: %v", encoding, err)
+		}
+		if decoded, _ := io.ReadAll(r); !bytes.Equal(decoded, body) {
+			t.Errorf("Expected the %s feed to decode to the uncompressed feed", encoding)
+		}
+	}
+	if rec := get(map[string]string{"Accept-Encoding": "gzip;q=1, br;q=0.5"}); rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Expected the client's preferred encoding, got %q", rec.Header().Get("Content-Encoding"))
+	}
+	if rec := get(map[string]string{"Accept-Encoding": "identity"}); rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected an uncompressed feed, got %q", rec.Header().Get("Content-Encoding"))
+	}
+
+	addEpisode("ep-2")
+	rec := get(map[string]string{"If-None-Match": etag})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Fatalf("Expected a new feed after the store changed, got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+	if !bytes.Contains(rec.Body.Bytes(), []byte("Episode ep-2")) {
+		t.Error("Expected the new episode in the feed")
+	}
+}
+
+// countingStore counts how often the podcast is read to render a feed
+type countingStore struct {
+	storage.Store
+	reads atomic.Int32
+}
+
+func (s *countingStore) GetPodcast() *models.Podcast {
+	s.reads.Add(1)
+	return s.Store.GetPodcast()
+}
+
+// Concurrent requests for a changed feed share a single rendering
+func TestFeedRenderedOnce(t *testing.T) {
+	rssStore, err := storage.LoadRSSStore(filepath.Join(t.TempDir(), "podcast.xml"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	store := &countingStore{Store: rssStore}
+	h := handlers.NewFeedHandler(store, "http://example.com")
+
+	for i := 0; i < 3; i++ {
+		ep := models.Episode{ID: fmt.Sprintf("ep-%d", i), Title: "Episode", Description: "d", PubDate: time.Now(), AudioURL: "/audio/ep.mp3", AudioLength: 100}
+		if err := store.AddEpisode(ep); err != nil {
+			t.Fatalf("Failed to add episode: %v", err)
+		}
+		store.reads.Store(0)
+
+		var wg sync.WaitGroup
+		etags := make([]string, 20)
+		for j := range etags {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				rec := httptest.NewRecorder()
+				h.HandleFeed(rec, httptest.NewRequest(http.MethodGet, "/feed.xml", nil))
+				etags[j] = rec.Header().Get("ETag")
+			}(j)
+		}
+		wg.Wait()
+
+		if n := store.reads.Load(); n != 1 {
+			t.Errorf("Expected the feed to be rendered once, got %d renders", n)
+		}
+		for _, etag := range etags {
+			if etag != etags[0] {
+				t.Errorf("Expected every request to get the same feed, got %q and %q", etag, etags[0])
+				break
+			}
+		}
+	}
+}
+
+// Stores report a new revision after every change to the podcast
+func TestStoreRevision(t *testing.T) {
+	dir := t.TempDir()
+	rssStore, err := storage.LoadRSSStore(filepath.Join(dir, "podcast.xml"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create RSS store: %v", err)
+	}
+	sqliteStore, err := storage.OpenSQLiteStore(filepath.Join(dir, "podcast.db"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite store: %v", err)
+	}
+	defer sqliteStore.Close()
+
+	for name, store := range map[string]storage.Store{"rss": rssStore, "sqlite": sqliteStore} {
+		t.Run(name, func(t *testing.T) {
+			changes := []func() error{
+				func() error {
+					return store.AddEpisode(models.Episode{ID: "ep-1", Title: "t", Description: "d", PubDate: time.Now(), AudioURL: "/audio/ep-1.mp3"})
+				},
+				func() error {
+					ep := store.GetPodcast().Episodes[0]
+					ep.Title = "Renamed"
+					return store.UpdateEpisode(ep)
+				},
+				func() error { return store.UpdatePodcast(store.GetPodcast()) },
+				func() error { return store.TrashEpisode("ep-1", time.Now()) },
+				func() error { return store.RestoreEpisode("ep-1") },
+				func() error { return store.DeleteEpisode("ep-1") },
+			}
+
+			revision := store.Revision()
+			for i, change := range changes {
+				if err := change(); err != nil {
+					t.Fatalf("Change %d failed: %v", i, err)
+				}
+				if store.Revision() == revision {
+					t.Errorf("Expected a new revision after change %d", i)
+				}
+				revision = store.Revision()
+			}
+
+			if err := store.DeleteEpisode("missing"); err == nil || store.Revision() != revision {
+				t.Error("Expected a failed change to keep the revision")
+			}
+		})
+	}
+}
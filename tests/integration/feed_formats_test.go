@@ -0,0 +1,171 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+)
+
+// The Atom and JSON feeds of the round-trip podcasts match their golden files
+func TestAlternateFeedsGolden(t *testing.T) {
+	baseURL := "http://podcast.example.com"
+	formats := map[string]func(*models.Podcast, string) ([]byte, error){
+		".atom": func(p *models.Podcast, baseURL string) ([]byte, error) { return rss.GenerateAtom(p, baseURL) },
+		".json": func(p *models.Podcast, baseURL string) ([]byte, error) { return rss.GenerateJSONFeed(p, baseURL) },
+	}
+
+	for name, podcast := range roundTripPodcasts() {
+		for ext, generate := range formats {
+			t.Run(name+ext, func(t *testing.T) {
+				got, err := generate(podcast, baseURL)
+				if err != nil {
+					t.Fatalf("Failed to generate feed: %v", err)
+				}
+
+				golden := filepath.Join("testdata", "feeds", name+ext)
+				if *updateGolden {
+					if err := os.WriteFile(golden, got, 0644); err != nil {
+						t.Fatalf("Failed to write golden file: %v", err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("Generated feed does not match %s:\n%s", golden, got)
+				}
+			})
+		}
+	}
+}
+
+// The Atom feed is well-formed, lists published episodes only and links
+// their audio as the alternate representation and as enclosures
+func TestAtomFeed(t *testing.T) {
+	pubDate := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	podcast := &models.Podcast{
+		Title:       "Show",
+		Description: "About things",
+		Author:      "Host",
+		PubDate:     pubDate,
+		Episodes: []models.Episode{
+			{ID: "ep-1", GUID: "ep-1", Title: "Live", Description: "d", PubDate: pubDate, AudioURL: "/audio/ep-1.mp3", AudioLength: 100, AudioType: "audio/mpeg"},
+			{ID: "ep-2", GUID: "ep-2", Title: "Draft", Description: "d", PubDate: pubDate, AudioURL: "/audio/ep-2.mp3", Status: models.StatusDraft},
+		},
+	}
+
+	data, err := rss.GenerateAtom(podcast, "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate Atom feed: %v", err)
+	}
+
+	var feed struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Links   []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			ID    string `xml:"id"`
+			Title string `xml:"title"`
+			Links []struct {
+				Rel    string `xml:"rel,attr"`
+				Href   string `xml:"href,attr"`
+				Length int64  `xml:"length,attr"`
+			} `xml:"link"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("Failed to parse Atom feed: %v", err)
+	}
+
+	if !strings.HasPrefix(feed.ID, "urn:uuid:") {
+		t.Errorf("Expected a urn:uuid: feed ID, got %q", feed.ID)
+	}
+	if len(feed.Links) == 0 || feed.Links[0].Rel != "self" || feed.Links[0].Href != "http://example.com/feed.atom" {
+		t.Errorf("Expected a self link to /feed.atom, got %+v", feed.Links)
+	}
+	if len(feed.Entries) != 1 || feed.Entries[0].Title != "Live" {
+		t.Fatalf("Expected only the published episode, got %+v", feed.Entries)
+	}
+	entry := feed.Entries[0]
+	if !strings.HasPrefix(entry.ID, "urn:uuid:") {
+		t.Errorf("Expected a urn:uuid: entry ID, got %q", entry.ID)
+	}
+	if len(entry.Links) != 2 {
+		t.Fatalf("Expected alternate and enclosure links, got %+v", entry.Links)
+	}
+	// RFC 4287 requires an alternate link of entries without content
+	if entry.Links[0].Rel != "alternate" || entry.Links[0].Href != "http://example.com/audio/ep-1.mp3" {
+		t.Errorf("Expected the audio as the alternate link, got %+v", entry.Links[0])
+	}
+	if entry.Links[1].Rel != "enclosure" || entry.Links[1].Href != "http://example.com/audio/ep-1.mp3" || entry.Links[1].Length != 100 {
+		t.Errorf("Expected the audio as an enclosure link, got %+v", entry.Links[1])
+	}
+}
+
+// The JSON feed follows JSON Feed 1.1, with the audio as an attachment
+func TestJSONFeed(t *testing.T) {
+	pubDate := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	podcast := &models.Podcast{
+		Title:       "Show",
+		Description: "About things",
+		PubDate:     pubDate,
+		Episodes: []models.Episode{
+			{ID: "ep-1", GUID: "ep-1", Title: "Live", Description: "d", PubDate: pubDate, AudioURL: "/audio/ep-1.m4a", AudioLength: 100, AudioType: "audio/x-m4a", Duration: "01:02:03"},
+			{ID: "ep-2", GUID: "ep-2", Title: "Soon", Description: "d", PubDate: pubDate.Add(time.Hour), AudioURL: "/audio/ep-2.mp3", Status: models.StatusScheduled},
+		},
+	}
+
+	data, err := rss.GenerateJSONFeed(podcast, "http://example.com/")
+	if err != nil {
+		t.Fatalf("Failed to generate JSON feed: %v", err)
+	}
+
+	var feed struct {
+		Version string `json:"version"`
+		FeedURL string `json:"feed_url"`
+		Items   []struct {
+			ID            string `json:"id"`
+			DatePublished string `json:"date_published"`
+			Attachments   []struct {
+				URL      string `json:"url"`
+				MimeType string `json:"mime_type"`
+				Size     int64  `json:"size_in_bytes"`
+				Duration int    `json:"duration_in_seconds"`
+			} `json:"attachments"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("Failed to parse JSON feed: %v", err)
+	}
+
+	if feed.Version != "https://jsonfeed.org/version/1.1" || feed.FeedURL != "http://example.com/feed.json" {
+		t.Errorf("Unexpected version or feed URL: %q %q", feed.Version, feed.FeedURL)
+	}
+	if len(feed.Items) != 1 || feed.Items[0].ID != "ep-1" {
+		t.Fatalf("Expected only the published episode, got %+v", feed.Items)
+	}
+	if feed.Items[0].DatePublished != "2024-03-01T09:30:00Z" {
+		t.Errorf("Expected an RFC 3339 date, got %q", feed.Items[0].DatePublished)
+	}
+	want := []struct {
+		URL      string `json:"url"`
+		MimeType string `json:"mime_type"`
+		Size     int64  `json:"size_in_bytes"`
+		Duration int    `json:"duration_in_seconds"`
+	}{{URL: "http://example.com/audio/ep-1.m4a", MimeType: "audio/x-m4a", Size: 100, Duration: 3723}}
+	if got := feed.Items[0].Attachments; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("Expected attachment %+v, got %+v", want[0], got)
+	}
+}
@@ -0,0 +1,170 @@
+package integration
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// openPagingStore returns a store with five published episodes, ep-5 the
+// newest, and one draft
+func openPagingStore(t *testing.T) storage.Store {
+	t.Helper()
+
+	store, err := storage.LoadRSSStore(filepath.Join(t.TempDir(), "podcast.xml"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	p := store.GetPodcast()
+	p.Link = "https://example.com/show"
+	if err := store.UpdatePodcast(p); err != nil {
+		t.Fatalf("Failed to update podcast: %v", err)
+	}
+
+	pubDate := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i := 1; i <= 5; i++ {
+		id := fmt.Sprintf("ep-%d", i)
+		ep := models.Episode{ID: id, Title: "Episode " + id, Description: "d", PubDate: pubDate.AddDate(0, 0, i), AudioURL: "/audio/" + id + ".mp3"}
+		if err := store.AddEpisode(ep); err != nil {
+			t.Fatalf("Failed to add episode: %v", err)
+		}
+	}
+	draft := models.Episode{ID: "draft", Title: "Draft", Description: "d", PubDate: pubDate, AudioURL: "/audio/draft.mp3", Status: models.StatusDraft}
+	if err := store.AddEpisode(draft); err != nil {
+		t.Fatalf("Failed to add draft: %v", err)
+	}
+	return store
+}
+
+// getFeed requests target from the feed handler and parses the response
+func getFeed(t *testing.T, h *handlers.FeedHandler, target string) (int, *rss.RSS) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if req.URL.Path == "/feed-archive.xml" {
+		h.HandleArchive(rec, req)
+	} else {
+		h.HandleFeed(rec, req)
+	}
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+
+	var doc rss.RSS
+	if err := xml.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to parse %s: %v", target, err)
+	}
+	return rec.Code, &doc
+}
+
+// Following the next links of a paged feed visits every published episode
+// once, newest first
+func TestPagedFeed(t *testing.T) {
+	h := handlers.NewFeedHandler(openPagingStore(t), "http://example.com")
+	h.SetPaging(2, 0)
+
+	_, first := getFeed(t, h, "/feed.xml")
+	ch := first.Channel
+	if ch.Link() != "https://example.com/show" {
+		t.Errorf("Expected the channel link to survive the atom:link elements, got %q", ch.Link())
+	}
+	for rel, want := range map[string]string{
+		"self":     "http://example.com/feed.xml",
+		"first":    "http://example.com/feed.xml",
+		"next":     "http://example.com/feed.xml?page=2",
+		"last":     "http://example.com/feed.xml?page=3",
+		"previous": "",
+	} {
+		if got := ch.AtomLink(rel); got != want {
+			t.Errorf("Expected first page %s link %q, got %q", rel, want, got)
+		}
+	}
+
+	var titles []string
+	target := "/feed.xml"
+	for pages := 0; target != ""; pages++ {
+		if pages == 3 {
+			t.Fatal("Expected the last page to have no next link")
+		}
+		code, doc := getFeed(t, h, target)
+		if code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d", target, code)
+		}
+		for _, item := range doc.Channel.Items {
+			titles = append(titles, item.Title)
+		}
+
+		target = ""
+		if next := doc.Channel.AtomLink("next"); next != "" {
+			u, _ := url.Parse(next)
+			target = u.RequestURI()
+		}
+	}
+	if got := strings.Join(titles, ","); got != "Episode ep-5,Episode ep-4,Episode ep-3,Episode ep-2,Episode ep-1" {
+		t.Errorf("Expected every published episode once, newest first, got %s", got)
+	}
+
+	_, last := getFeed(t, h, "/feed.xml?page=3")
+	if got := last.Channel.AtomLink("previous"); got != "http://example.com/feed.xml?page=2" {
+		t.Errorf("Expected the last page to link to the previous one, got %q", got)
+	}
+
+	if code, _ := getFeed(t, h, "/feed.xml?page=4"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 past the last page, got %d", code)
+	}
+	if code, _ := getFeed(t, h, "/feed.xml?page=zero"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid page, got %d", code)
+	}
+}
+
+// A capped feed lists only the newest episodes, while the archive feed
+// stays complete
+func TestCappedFeedAndArchive(t *testing.T) {
+	h := handlers.NewFeedHandler(openPagingStore(t), "http://example.com")
+	h.SetPaging(0, 2)
+
+	_, latest := getFeed(t, h, "/feed.xml")
+	if len(latest.Channel.Items) != 2 || latest.Channel.Items[0].Title != "Episode ep-5" {
+		t.Errorf("Expected the two newest episodes, got %+v", latest.Channel.Items)
+	}
+	if got := latest.Channel.AtomLink("archives"); got != "http://example.com/feed-archive.xml" {
+		t.Errorf("Expected the capped feed to link to the archive, got %q", got)
+	}
+	if code, _ := getFeed(t, h, "/feed.xml?page=2"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a page of an unpaged feed, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	h.HandleArchive(rec, httptest.NewRequest(http.MethodGet, "/feed-archive.xml", nil))
+	if !strings.Contains(rec.Body.String(), "<fh:complete></fh:complete>") {
+		t.Error("Expected the archive to be marked as a complete feed")
+	}
+
+	_, archive := getFeed(t, h, "/feed-archive.xml")
+	if len(archive.Channel.Items) != 5 {
+		t.Errorf("Expected all five published episodes in the archive, got %d", len(archive.Channel.Items))
+	}
+	if got := archive.Channel.AtomLink("self"); got != "http://example.com/feed-archive.xml" {
+		t.Errorf("Expected the archive's self link, got %q", got)
+	}
+
+	podcast, err := rss.ParseFeed(rec.Body.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to parse archive: %v", err)
+	}
+	if podcast.Link != "https://example.com/show" || len(podcast.Episodes) != 5 {
+		t.Errorf("Expected the archive to round-trip, got link %q and %d episodes", podcast.Link, len(podcast.Episodes))
+	}
+}
@@ -0,0 +1,280 @@
+package integration
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+	"github.com/example/rss-server/internal/storage"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden feed files")
+
+// roundTripPodcasts are the feeds covered by the golden files in
+// testdata/feeds
+func roundTripPodcasts() map[string]*models.Podcast {
+	pst := time.FixedZone("PST", -8*60*60)
+	pubDate := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	return map[string]*models.Podcast{
+		"full": {
+			Title:       "Full Podcast",
+			Link:        "https://example.com",
+			Description: "Every field the generator writes",
+			Language:    "en-us",
+			PubDate:     pubDate,
+			Author:      "Jane Host",
+			Subtitle:    "A subtitle",
+			Summary:     "A longer summary of the show",
+			ImageURL:    "/static/artwork/cover.jpg",
+			Explicit:    "no",
+			Category:    "Science",
+			Subcategory: "Astronomy",
+			OwnerName:   "Jane Host",
+			OwnerEmail:  "jane@example.com",
+			Type:        "serial",
+			Copyright:   "© 2024 Jane Host",
+			Block:       true,
+			Complete:    true,
+			NewFeedURL:  "https://new.example.com/feed.xml",
+			Locked:      true,
+			Funding:     []models.Funding{{URL: "https://example.com/donate", Label: "Support the show"}},
+			Persons: []models.Person{
+				{Name: "Jane Host", Role: "host", Img: "https://example.com/jane.jpg"},
+				{Name: "Sam Producer", Role: "producer", Group: "audio post-production"},
+			},
+			Location: &models.Location{Name: "Austin, TX", Geo: "geo:30.2672,97.7431", OSM: "R113314"},
+			Trailers: []models.Trailer{{Title: "Coming soon", URL: "/audio/trailer.mp3", PubDate: pubDate.Add(-72 * time.Hour), Length: 12345, Type: "audio/mpeg", Season: 1}},
+			License:  &models.License{Name: "cc-by-4.0"},
+			Episodes: []models.Episode{
+				{ID: "ep-1", GUID: "ep-1", Title: "Pilot", Description: "The first one", PubDate: pubDate.Add(-48 * time.Hour), AudioURL: "/audio/ep-1.mp3", AudioLength: 1234567, AudioType: "audio/mpeg", Duration: "00:42:10", Explicit: "no", EpisodeNum: 1, SeasonNum: 1, EpisodeType: "full", ImageURL: "/static/artwork/ep-1.jpg",
+					Persons: []models.Person{{Name: "Guest Star", Role: "guest", Href: "https://example.com/guest"}}, Location: &models.Location{Name: "Mars", Geo: "geo:0,0"}, License: &models.License{Name: "My License", URL: "https://example.com/license"},
+					Chapters:   []models.Chapter{{StartTime: 0, Title: "Intro"}, {StartTime: 62.5, Title: "Interview"}},
+					Transcript: &models.Transcript{Filename: "ep-1.srt", Format: "srt", Language: "en"}},
+				{ID: "ep-2", GUID: "ep-2", Title: "Bonus", Description: "Extra material", PubDate: time.Date(2024, 3, 2, 18, 0, 0, 0, pst), AudioURL: "/audio/ep-2.m4a", AudioLength: 7654321, AudioType: "audio/x-m4a", Duration: "00:05:00", EpisodeType: "bonus", ChaptersURL: "https://cdn.example.com/ep-2/chapters.json",
+					TranscriptLinks: []models.TranscriptLink{{URL: "https://cdn.example.com/ep-2/transcript.txt", Type: "text/plain"}}},
+			},
+		},
+		"minimal": {
+			Title:       "Minimal Podcast",
+			Link:        "https://example.com",
+			Description: "No artwork, no category, no episodes",
+			PubDate:     pubDate,
+			GUID:        "c9d5b5f4-1c54-5a4b-9c3a-5a7a2d9c0c11",
+			Episodes:    []models.Episode{},
+		},
+		"escaping": {
+			Title:       "Q&A <Live>",
+			Link:        "https://example.com/?show=1&lang=en",
+			Description: `Quotes "here" & 'there'`,
+			Language:    "fr-ca",
+			PubDate:     pubDate,
+			Author:      "Zoë & Zoé",
+			Summary:     "Summary with <b>markup</b> & entities",
+			ImageURL:    "https://cdn.example.com/art work.png",
+			Category:    "Society & Culture",
+			Episodes: []models.Episode{
+				{ID: "ep-é", GUID: "ep-é", Title: "Café <Talk>", Description: "Line one\nLine two & more", PubDate: pubDate, AudioURL: "/audio/café talk.mp3", AudioLength: 1, AudioType: "audio/mpeg"},
+			},
+		},
+	}
+}
+
+// Generated feeds match their golden files and survive parse -> generate
+// unchanged, so a restart never loses channel or episode metadata
+func TestFeedGoldenRoundTrip(t *testing.T) {
+	baseURL := "http://podcast.example.com"
+
+	for name, podcast := range roundTripPodcasts() {
+		t.Run(name, func(t *testing.T) {
+			first, err := rss.GenerateFeed(podcast, baseURL)
+			if err != nil {
+				t.Fatalf("Failed to generate feed: %v", err)
+			}
+
+			golden := filepath.Join("testdata", "feeds", name+".xml")
+			if *updateGolden {
+				if err := os.WriteFile(golden, first, 0644); err != nil {
+					t.Fatalf("Failed to write golden file: %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(first, want) {
+				t.Errorf("Generated feed does not match %s:\n%s", golden, first)
+			}
+
+			parsed, err := rss.ParseFeed(first)
+			if err != nil {
+				t.Fatalf("Failed to parse feed: %v", err)
+			}
+			second, err := rss.GenerateFeed(parsed, baseURL)
+			if err != nil {
+				t.Fatalf("Failed to regenerate feed: %v", err)
+			}
+			if !bytes.Equal(first, second) {
+				t.Errorf("Feed changed after round-trip:\n--- first\n%s\n--- second\n%s", first, second)
+			}
+		})
+	}
+}
+
+// Artwork, category and author are recovered from the feed
+func TestParseFeedChannelMetadata(t *testing.T) {
+	xmlBytes, err := rss.GenerateFeed(roundTripPodcasts()["full"], "http://podcast.example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate feed: %v", err)
+	}
+
+	parsed, err := rss.ParseFeed(xmlBytes)
+	if err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+
+	if parsed.ImageURL != "http://podcast.example.com/static/artwork/cover.jpg" {
+		t.Errorf("Expected artwork URL to survive, got %q", parsed.ImageURL)
+	}
+	if parsed.Category != "Science" || parsed.Subcategory != "Astronomy" {
+		t.Errorf("Expected category Science > Astronomy, got %q > %q", parsed.Category, parsed.Subcategory)
+	}
+	if parsed.OwnerName != "Jane Host" || parsed.OwnerEmail != "jane@example.com" {
+		t.Errorf("Expected owner to survive, got %q <%s>", parsed.OwnerName, parsed.OwnerEmail)
+	}
+	if parsed.Type != "serial" || parsed.Copyright != "© 2024 Jane Host" || parsed.NewFeedURL != "https://new.example.com/feed.xml" {
+		t.Errorf("Expected type, copyright and new feed URL to survive, got %+v", parsed)
+	}
+	if !parsed.Block || !parsed.Complete {
+		t.Errorf("Expected block and complete to survive, got block=%v complete=%v", parsed.Block, parsed.Complete)
+	}
+	if parsed.Author != "Jane Host" {
+		t.Errorf("Expected author Jane Host, got %q", parsed.Author)
+	}
+	if parsed.Summary != "A longer summary of the show" {
+		t.Errorf("Expected summary to survive, got %q", parsed.Summary)
+	}
+	if got := parsed.Episodes[1].ImageURL; got != "http://podcast.example.com/static/artwork/ep-1.jpg" {
+		t.Errorf("Expected episode artwork to survive, got %q", got)
+	}
+}
+
+// A plain RSS category is used when there is no itunes:category
+func TestParseFeedPlainCategory(t *testing.T) {
+	feed := `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>T</title><category>News</category></channel></rss>`
+
+	parsed, err := rss.ParseFeed([]byte(feed))
+	if err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+	if parsed.Category != "News" {
+		t.Errorf("Expected category News, got %q", parsed.Category)
+	}
+}
+
+// Podcasting 2.0 metadata is recovered from the feed
+func TestParseFeedPodcastNamespace(t *testing.T) {
+	xmlBytes, err := rss.GenerateFeed(roundTripPodcasts()["full"], "http://podcast.example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate feed: %v", err)
+	}
+
+	parsed, err := rss.ParseFeed(xmlBytes)
+	if err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+
+	if parsed.GUID != rss.FeedGUID("http://podcast.example.com/feed.xml") {
+		t.Errorf("Expected GUID derived from the feed URL, got %q", parsed.GUID)
+	}
+	if !parsed.Locked {
+		t.Error("Expected feed to be locked")
+	}
+	if len(parsed.Funding) != 1 || parsed.Funding[0].Label != "Support the show" {
+		t.Errorf("Expected funding to survive, got %+v", parsed.Funding)
+	}
+	if len(parsed.Persons) != 2 || parsed.Persons[1].Group != "audio post-production" {
+		t.Errorf("Expected persons to survive, got %+v", parsed.Persons)
+	}
+	if parsed.Location == nil || parsed.Location.OSM != "R113314" {
+		t.Errorf("Expected location to survive, got %+v", parsed.Location)
+	}
+	if len(parsed.Trailers) != 1 || parsed.Trailers[0].URL != "http://podcast.example.com/audio/trailer.mp3" || parsed.Trailers[0].Season != 1 {
+		t.Errorf("Expected trailer to survive, got %+v", parsed.Trailers)
+	}
+	if parsed.License == nil || parsed.License.Name != "cc-by-4.0" {
+		t.Errorf("Expected license to survive, got %+v", parsed.License)
+	}
+
+	ep := parsed.Episodes[1]
+	if len(ep.Persons) != 1 || ep.Persons[0].Role != "guest" {
+		t.Errorf("Expected episode guest to survive, got %+v", ep.Persons)
+	}
+	if ep.ChaptersURL != "http://podcast.example.com/episodes/ep-1/chapters.json" {
+		t.Errorf("Expected podcast:chapters to reference the chapters document, got %q", ep.ChaptersURL)
+	}
+	if parsed.Episodes[0].ChaptersURL != "https://cdn.example.com/ep-2/chapters.json" {
+		t.Errorf("Expected external chapters URL to survive, got %q", parsed.Episodes[0].ChaptersURL)
+	}
+	if len(ep.TranscriptLinks) != 2 || ep.TranscriptLinks[0].URL != "http://podcast.example.com/episodes/ep-1/transcript.srt" ||
+		ep.TranscriptLinks[1].Type != "text/vtt" || ep.TranscriptLinks[1].Rel != "captions" || ep.TranscriptLinks[1].Language != "en" {
+		t.Errorf("Expected SRT and WebVTT transcripts, got %+v", ep.TranscriptLinks)
+	}
+	if links := parsed.Episodes[0].TranscriptLinks; len(links) != 1 || links[0].Type != "text/plain" || links[0].Rel != "" {
+		t.Errorf("Expected external transcript to survive, got %+v", links)
+	}
+	if ep.Location == nil || ep.Location.Name != "Mars" || ep.License == nil || ep.License.URL != "https://example.com/license" {
+		t.Errorf("Expected episode location and license to survive, got %+v, %+v", ep.Location, ep.License)
+	}
+}
+
+// podcast:guid matches the example in the Podcasting 2.0 spec
+func TestFeedGUID(t *testing.T) {
+	want := "917393e3-1b1e-5cef-ace4-edaa54e1f810"
+	for _, feedURL := range []string{
+		"https://mp3s.nashownotes.com/pc20rss.xml",
+		"http://mp3s.nashownotes.com/pc20rss.xml",
+		"mp3s.nashownotes.com/pc20rss.xml/",
+	} {
+		if got := rss.FeedGUID(feedURL); got != want {
+			t.Errorf("FeedGUID(%q) = %s, want %s", feedURL, got, want)
+		}
+	}
+}
+
+// The derived podcast:guid is saved the first time, so a later base URL
+// change doesn't change the feed's identity
+func TestEnsureGUID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "podcast.xml")
+	store, err := storage.LoadRSSStore(path, "http://old.example.com")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	want := rss.FeedGUID("http://old.example.com/feed.xml")
+	if guid, err := storage.EnsureGUID(store, "http://old.example.com/feed.xml"); err != nil || guid != want {
+		t.Fatalf("Expected the derived GUID %s, got %s, %v", want, guid, err)
+	}
+
+	moved, err := storage.LoadRSSStore(path, "https://new.example.com")
+	if err != nil {
+		t.Fatalf("Failed to reload store: %v", err)
+	}
+	if guid, _ := storage.EnsureGUID(moved, "https://new.example.com/feed.xml"); guid != want {
+		t.Errorf("Expected the saved GUID to be kept after moving, got %s", guid)
+	}
+	feed, err := rss.GenerateFeed(moved.GetPodcast(), "https://new.example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate feed: %v", err)
+	}
+	if !strings.Contains(string(feed), "<podcast:guid>"+want+"</podcast:guid>") {
+		t.Errorf("Expected the moved feed to keep its GUID %s", want)
+	}
+}
@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// Internal episode metadata survives a store reload
+func TestMetadataSurvivesRestart(t *testing.T) {
+	rssFile := filepath.Join(t.TempDir(), "podcast.xml")
+	baseURL := "http://example.com"
+
+	store, err := storage.LoadRSSStore(rssFile, baseURL)
+	if err != nil {
+		t.Fatalf("Failed to create RSS store: %v", err)
+	}
+
+	uploadDate := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	episode := models.Episode{
+		ID:          "ep-20240301-first",
+		Title:       "First",
+		Description: "First episode",
+		PubDate:     uploadDate,
+		GUID:        "guid-first",
+		AudioURL:    "/audio/first.mp3",
+		AudioLength: 1234,
+		AudioType:   "audio/mpeg",
+		Filename:    "first.mp3",
+		UploadDate:  uploadDate,
+	}
+	if err := store.AddEpisode(episode); err != nil {
+		t.Fatalf("Failed to add episode: %v", err)
+	}
+
+	if _, err := os.Stat(storage.MetadataPath(rssFile)); err != nil {
+		t.Fatalf("Expected metadata sidecar to exist: %v", err)
+	}
+
+	// Simulate a restart
+	reloaded, err := storage.LoadRSSStore(rssFile, baseURL)
+	if err != nil {
+		t.Fatalf("Failed to reload RSS store: %v", err)
+	}
+
+	episodes := reloaded.GetPodcast().Episodes
+	if len(episodes) != 1 {
+		t.Fatalf("Expected 1 episode after reload, got %d", len(episodes))
+	}
+
+	got := episodes[0]
+	if got.ID != episode.ID {
+		t.Errorf("Expected ID %q, got %q", episode.ID, got.ID)
+	}
+	if got.GUID != episode.GUID {
+		t.Errorf("Expected GUID %q, got %q", episode.GUID, got.GUID)
+	}
+	if got.Filename != episode.Filename {
+		t.Errorf("Expected filename %q, got %q", episode.Filename, got.Filename)
+	}
+	if !got.UploadDate.Equal(episode.UploadDate) {
+		t.Errorf("Expected upload date %v, got %v", episode.UploadDate, got.UploadDate)
+	}
+}
+
+// A feed written before the sidecar existed is migrated on load
+func TestLegacyFeedMigration(t *testing.T) {
+	dir := t.TempDir()
+	rssFile := filepath.Join(dir, "podcast.xml")
+	baseURL := "http://example.com"
+
+	podcast := models.NewDefaultPodcast()
+	podcast.Episodes = []models.Episode{
+		{
+			ID:          "ep-legacy",
+			Title:       "Legacy",
+			Description: "Legacy episode",
+			PubDate:     time.Now(),
+			GUID:        "ep-legacy",
+			AudioURL:    "/audio/legacy-20240101-120000.mp3",
+			AudioLength: 42,
+		},
+	}
+
+	xmlData, err := rss.GenerateFeed(podcast, baseURL)
+	if err != nil {
+		t.Fatalf("Failed to generate feed: %v", err)
+	}
+	if err := os.WriteFile(rssFile, xmlData, 0644); err != nil {
+		t.Fatalf("Failed to write legacy feed: %v", err)
+	}
+
+	store, err := storage.LoadRSSStore(rssFile, baseURL)
+	if err != nil {
+		t.Fatalf("Failed to load legacy feed: %v", err)
+	}
+
+	if _, err := os.Stat(storage.MetadataPath(rssFile)); err != nil {
+		t.Fatalf("Expected legacy feed to be migrated to a sidecar: %v", err)
+	}
+
+	episodes := store.GetPodcast().Episodes
+	if len(episodes) != 1 {
+		t.Fatalf("Expected 1 episode, got %d", len(episodes))
+	}
+	if episodes[0].Filename != "legacy-20240101-120000.mp3" {
+		t.Errorf("Expected filename to be recovered from audio URL, got %q", episodes[0].Filename)
+	}
+}
@@ -0,0 +1,165 @@
+package integration
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+	"github.com/example/rss-server/internal/scheduler"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// Draft and scheduled episodes stay out of the feed, including its dates
+func TestFeedOnlyIncludesPublishedEpisodes(t *testing.T) {
+	pubDate := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	p := models.NewDefaultPodcast()
+	p.PubDate = pubDate
+	p.Episodes = []models.Episode{
+		{ID: "legacy", AudioURL: "/audio/legacy.mp3", Title: "Legacy", Description: "No status", PubDate: pubDate},
+		{ID: "live", AudioURL: "/audio/live.mp3", Title: "Live", Description: "Published", PubDate: pubDate, Status: models.StatusPublished},
+		{ID: "soon", AudioURL: "/audio/soon.mp3", Title: "Soon", Description: "Scheduled", PubDate: pubDate.AddDate(1, 0, 0), Status: models.StatusScheduled},
+		{ID: "wip", AudioURL: "/audio/wip.mp3", Title: "Wip", Description: "Draft", PubDate: pubDate, Status: models.StatusDraft},
+	}
+
+	xmlBytes, err := rss.GenerateFeed(p, "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate feed: %v", err)
+	}
+
+	parsed, err := rss.ParseFeed(xmlBytes)
+	if err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+	if len(parsed.Episodes) != 2 {
+		t.Fatalf("Expected 2 published episodes, got %d", len(parsed.Episodes))
+	}
+	if bytes.Contains(xmlBytes, []byte("Scheduled")) || bytes.Contains(xmlBytes, []byte("Draft")) {
+		t.Error("Expected unpublished episodes to be left out")
+	}
+	if want := pubDate.Format(time.RFC1123Z); !bytes.Contains(xmlBytes, []byte("<lastBuildDate>"+want+"</lastBuildDate>")) {
+		t.Errorf("Expected lastBuildDate to ignore the scheduled episode, got %s", xmlBytes)
+	}
+}
+
+// Scheduled episodes appear in each store's feed once the scheduler runs
+func TestSchedulerPublishesToFeed(t *testing.T) {
+	for name, open := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open()
+			due := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+			if err := store.AddEpisode(models.Episode{ID: "ep-1", GUID: "ep-1", Title: "Timed", Description: "Release", AudioURL: "/audio/ep-1.mp3", PubDate: due, Status: models.StatusScheduled}); err != nil {
+				t.Fatalf("Failed to add episode: %v", err)
+			}
+
+			feed, err := store.ServeXML()
+			if err != nil {
+				t.Fatalf("Failed to render feed: %v", err)
+			}
+			if bytes.Contains(feed, []byte("Timed")) {
+				t.Fatal("Expected the scheduled episode to be left out before publishing")
+			}
+
+			if n := scheduler.New(store).PublishDue(); n != 1 {
+				t.Fatalf("Expected 1 episode published, got %d", n)
+			}
+
+			if feed, _ = store.ServeXML(); !bytes.Contains(feed, []byte("Timed")) {
+				t.Error("Expected the episode in the feed once published")
+			}
+			if got := open().GetPodcast().Episodes[0].Status; got != models.StatusPublished {
+				t.Errorf("Expected published status to persist, got %q", got)
+			}
+		})
+	}
+}
+
+// Only episodes still scheduled and due are published, whatever the caller
+// last saw of them
+func TestPublishIfDue(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	for name, open := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open()
+			episodes := []models.Episode{
+				{ID: "due", Status: models.StatusScheduled, PubDate: now.Add(-time.Minute)},
+				{ID: "later", Status: models.StatusScheduled, PubDate: now.Add(time.Hour)},
+				{ID: "draft", Status: models.StatusDraft, PubDate: now.Add(-time.Minute)},
+			}
+			for _, ep := range episodes {
+				ep.GUID, ep.Title, ep.Description, ep.AudioURL = ep.ID, ep.ID, "d", "/audio/"+ep.ID+".mp3"
+				if err := store.AddEpisode(ep); err != nil {
+					t.Fatalf("Failed to add episode: %v", err)
+				}
+			}
+
+			for _, id := range []string{"later", "draft", "missing"} {
+				if ep, err := store.PublishIfDue(id, now); ep != nil || err != nil {
+					t.Errorf("Expected %s not to be published, got %+v, %v", id, ep, err)
+				}
+			}
+
+			ep, err := store.PublishIfDue("due", now)
+			if err != nil || ep == nil || ep.Status != models.StatusPublished {
+				t.Fatalf("Expected the due episode to be published, got %+v, %v", ep, err)
+			}
+			if ep, _ := store.PublishIfDue("due", now); ep != nil {
+				t.Error("Expected an episode to be published only once")
+			}
+		})
+	}
+}
+
+// UpdateEpisodeFunc reads and writes an episode in one step, so concurrent
+// edits of different fields all survive, and saves nothing when the update
+// fails
+func TestUpdateEpisodeFunc(t *testing.T) {
+	for name, open := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open()
+			if err := store.AddEpisode(models.Episode{ID: "ep-1", GUID: "guid-1", Title: "Old", Description: "d", AudioURL: "/audio/old.mp3", PubDate: time.Now()}); err != nil {
+				t.Fatalf("Failed to add episode: %v", err)
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					store.UpdateEpisodeFunc("ep-1", func(ep *models.Episode) error {
+						ep.AudioURL = "/audio/new.mp3"
+						return nil
+					})
+				}()
+				go func() {
+					defer wg.Done()
+					store.UpdateEpisodeFunc("ep-1", func(ep *models.Episode) error {
+						ep.Title = "New"
+						ep.GUID = "changed"
+						return nil
+					})
+				}()
+			}
+			wg.Wait()
+
+			ep := store.GetPodcast().Episodes[0]
+			if ep.AudioURL != "/audio/new.mp3" || ep.Title != "New" || ep.GUID != "guid-1" {
+				t.Errorf("Expected both edits with the GUID kept, got %+v", ep)
+			}
+
+			rejected := errors.New("rejected")
+			if _, err := store.UpdateEpisodeFunc("ep-1", func(ep *models.Episode) error {
+				ep.Title = "Lost"
+				return rejected
+			}); err != rejected || store.GetPodcast().Episodes[0].Title != "New" {
+				t.Errorf("Expected a failed update to save nothing, got %v", err)
+			}
+			if _, err := store.UpdateEpisodeFunc("missing", func(*models.Episode) error { return nil }); !errors.Is(err, storage.ErrEpisodeNotFound) {
+				t.Errorf("Expected ErrEpisodeNotFound, got %v", err)
+			}
+		})
+	}
+}
@@ -25,7 +25,8 @@ type Item struct {
 }
 
 type Enclosure struct {
-	URL string `xml:"url,attr"`
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
 }
 
 // T027: RSS XML structure valid
@@ -126,3 +127,91 @@ func TestEnclosureAbsoluteURLs(t *testing.T) {
 		}
 	}
 }
+
+// Enclosure types come from each episode's stored MIME type
+func TestEnclosureTypes(t *testing.T) {
+	baseURL := "http://podcast.example.com"
+	now := time.Now()
+	podcast := &models.Podcast{
+		Title:       "Test Podcast",
+		Link:        "http://example.com",
+		Description: "Test Description",
+		Language:    "en-us",
+		PubDate:     now,
+		Episodes: []models.Episode{
+			{ID: "ep1", Title: "Opus", Description: "Opus episode", PubDate: now, AudioURL: "/audio/ep1.opus", AudioLength: 1, AudioType: "audio/ogg"},
+			{ID: "ep2", Title: "FLAC", Description: "FLAC episode", PubDate: now.Add(-time.Hour), AudioURL: "/audio/ep2.flac", AudioLength: 1, AudioType: "audio/flac"},
+			{ID: "ep3", Title: "Legacy", Description: "No stored type", PubDate: now.Add(-2 * time.Hour), AudioURL: "/audio/ep3.mp3", AudioLength: 1},
+		},
+	}
+
+	xmlBytes, err := rss.GenerateFeed(podcast, baseURL)
+	if err != nil {
+		t.Fatalf("Failed to generate feed: %v", err)
+	}
+
+	var feed RSSFeed
+	if err := xml.Unmarshal(xmlBytes, &feed); err != nil {
+		t.Fatalf("Generated RSS XML is invalid: %v", err)
+	}
+
+	expected := []string{"audio/ogg", "audio/flac", "audio/mpeg"}
+	if len(feed.Channel.Items) != len(expected) {
+		t.Fatalf("Expected %d items, got %d", len(expected), len(feed.Channel.Items))
+	}
+	for i, item := range feed.Channel.Items {
+		if item.Enclosure.Type != expected[i] {
+			t.Errorf("Item %d: expected enclosure type %s, got %s", i, expected[i], item.Enclosure.Type)
+		}
+	}
+}
+
+// Episode, season and episode type are emitted and survive a parse
+func TestEpisodeNumberingRoundTrip(t *testing.T) {
+	now := time.Now()
+	podcast := &models.Podcast{
+		Title:       "Serial Podcast",
+		Link:        "http://example.com",
+		Description: "Test Description",
+		Language:    "en-us",
+		PubDate:     now,
+		Episodes: []models.Episode{
+			{ID: "ep1", Title: "Chapter One", Description: "First", PubDate: now, AudioURL: "/audio/ep1.mp3", AudioLength: 1, EpisodeNum: 1, SeasonNum: 2, EpisodeType: "Full"},
+			{ID: "ep2", Title: "Teaser", Description: "Trailer", PubDate: now.Add(-time.Hour), AudioURL: "/audio/ep2.mp3", AudioLength: 1, EpisodeType: "trailer"},
+			{ID: "ep3", Title: "Odd", Description: "Unknown type", PubDate: now.Add(-2 * time.Hour), AudioURL: "/audio/ep3.mp3", AudioLength: 1, EpisodeType: "special"},
+		},
+	}
+
+	xmlBytes, err := rss.GenerateFeed(podcast, "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate feed: %v", err)
+	}
+
+	xmlStr := string(xmlBytes)
+	for _, tag := range []string{"<itunes:episode>1</itunes:episode>", "<itunes:season>2</itunes:season>", "<itunes:episodeType>full</itunes:episodeType>", "<itunes:episodeType>trailer</itunes:episodeType>"} {
+		if !strings.Contains(xmlStr, tag) {
+			t.Errorf("Expected feed to contain %s", tag)
+		}
+	}
+	if strings.Contains(xmlStr, "special") {
+		t.Error("Expected unknown episode type to be omitted")
+	}
+
+	parsed, err := rss.ParseFeed(xmlBytes)
+	if err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+	if len(parsed.Episodes) != 3 {
+		t.Fatalf("Expected 3 episodes, got %d", len(parsed.Episodes))
+	}
+	first := parsed.Episodes[0]
+	if first.EpisodeNum != 1 || first.SeasonNum != 2 || first.EpisodeType != "full" {
+		t.Errorf("Expected episode 1, season 2, type full; got %d, %d, %q", first.EpisodeNum, first.SeasonNum, first.EpisodeType)
+	}
+	if parsed.Episodes[1].EpisodeNum != 0 || parsed.Episodes[1].EpisodeType != "trailer" {
+		t.Errorf("Expected unnumbered trailer, got %+v", parsed.Episodes[1])
+	}
+	if parsed.Episodes[2].EpisodeType != "" {
+		t.Errorf("Expected empty episode type, got %q", parsed.Episodes[2].EpisodeType)
+	}
+}
@@ -0,0 +1,189 @@
+package integration
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// stubS3 is a minimal path-style S3 stand-in, similar to a local MinIO
+type stubS3 struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newStubS3(t *testing.T) (*stubS3, *httptest.Server) {
+	stub := &stubS3{t: t, objects: make(map[string][]byte)}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+func (s *stubS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") {
+		http.Error(w, "missing signature", http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			http.Error(w, "payload hash mismatch", http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = body
+	case http.MethodHead, http.MethodGet:
+		data, ok := s.objects[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, key, time.Unix(0, 0), bytes.NewReader(data))
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unsupported", http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestS3Store(t *testing.T, endpoint string, publicURL string) *storage.S3BlobStore {
+	store, err := storage.NewS3BlobStore(storage.S3Options{
+		Endpoint:        endpoint,
+		Region:          "us-east-1",
+		Bucket:          "podcast",
+		AccessKeyID:     "test-key",
+		SecretAccessKey: "test-secret",
+		PathStyle:       true,
+		PublicURL:       publicURL,
+	}, "audio/", "/audio/")
+	if err != nil {
+		t.Fatalf("Failed to create S3 blob store: %v", err)
+	}
+	return store
+}
+
+// Objects can be written, read with seeking, and deleted
+func TestS3BlobStoreLifecycle(t *testing.T) {
+	stub, server := newStubS3(t)
+	store := newTestS3Store(t, server.URL, "")
+
+	content := []byte("0123456789abcdef")
+	size, err := store.Put("episode.mp3", bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to put object: %v", err)
+	}
+	if size != int64(len(content)) {
+		t.Errorf("Expected size %d, got %d", len(content), size)
+	}
+	if _, ok := stub.objects["/podcast/audio/episode.mp3"]; !ok {
+		t.Fatalf("Expected object under key prefix, have %v", stub.objects)
+	}
+
+	blob, err := store.Open("episode.mp3")
+	if err != nil {
+		t.Fatalf("Failed to open object: %v", err)
+	}
+	defer blob.Close()
+
+	if blob.Size() != int64(len(content)) {
+		t.Errorf("Expected blob size %d, got %d", len(content), blob.Size())
+	}
+
+	if _, err := blob.Seek(10, io.SeekStart); err != nil {
+		t.Fatalf("Failed to seek: %v", err)
+	}
+	tail, err := io.ReadAll(blob)
+	if err != nil {
+		t.Fatalf("Failed to read object: %v", err)
+	}
+	if string(tail) != "abcdef" {
+		t.Errorf("Expected ranged read 'abcdef', got %q", tail)
+	}
+
+	if err := store.Delete("episode.mp3"); err != nil {
+		t.Fatalf("Failed to delete object: %v", err)
+	}
+	if _, err := store.Open("episode.mp3"); err != storage.ErrBlobNotFound {
+		t.Errorf("Expected ErrBlobNotFound after delete, got %v", err)
+	}
+}
+
+// Enclosure URLs point at the CDN when a public URL is configured
+func TestS3BlobStorePublicURL(t *testing.T) {
+	_, server := newStubS3(t)
+
+	cdn := newTestS3Store(t, server.URL, "https://cdn.example.com/")
+	if got := cdn.URL("ep 1.mp3"); got != "https://cdn.example.com/audio/ep%201.mp3" {
+		t.Errorf("Expected CDN URL, got %q", got)
+	}
+
+	private := newTestS3Store(t, server.URL, "")
+	if got := private.URL("ep.mp3"); got != "/audio/ep.mp3" {
+		t.Errorf("Expected local redirect URL, got %q", got)
+	}
+}
+
+// /audio/ redirects to a presigned URL for S3-backed audio
+func TestS3AudioSignedRedirect(t *testing.T) {
+	_, server := newStubS3(t)
+	store := newTestS3Store(t, server.URL, "")
+	podcasts, err := storage.LoadRSSStore(filepath.Join(t.TempDir(), "podcast.xml"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	handler := handlers.NewStaticHandler(podcasts, store, store)
+	req := httptest.NewRequest(http.MethodGet, "/audio/episode.mp3", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleAudio(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("Expected redirect, got %d", rec.Code)
+	}
+
+	location := rec.Header().Get("Location")
+	if !strings.HasPrefix(location, server.URL+"/podcast/audio/episode.mp3?") {
+		t.Errorf("Expected redirect to bucket object, got %q", location)
+	}
+	if !strings.Contains(location, "X-Amz-Signature=") {
+		t.Errorf("Expected presigned URL, got %q", location)
+	}
+}
+
+// Staged uploads are sent to the bucket with their precomputed hash
+func TestS3CommitStagedUpload(t *testing.T) {
+	stub, server := newStubS3(t)
+	store := newTestS3Store(t, server.URL, "")
+
+	content := bytes.Repeat([]byte("staged "), 1000)
+	staged, err := storage.StageUpload(store, bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("Failed to stage upload: %v", err)
+	}
+	if err := staged.Commit(store, "staged.mp3"); err != nil {
+		t.Fatalf("Failed to commit upload: %v", err)
+	}
+
+	if got := stub.objects["/podcast/audio/staged.mp3"]; !bytes.Equal(got, content) {
+		t.Errorf("Expected committed object to match staged content, got %d bytes", len(got))
+	}
+}
@@ -0,0 +1,203 @@
+package integration
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+	"github.com/example/rss-server/internal/storage"
+)
+
+const showsBaseURL = "http://example.com"
+
+// openTestShows opens file-backed shows under dir, with the default show
+// kept beside them
+func openTestShows(t *testing.T, dir string) *storage.Shows {
+	t.Helper()
+
+	shows, err := storage.OpenShows(filepath.Join(dir, "shows"), func(slug string) (storage.Store, error) {
+		return storage.LoadRSSStore(filepath.Join(dir, "shows", slug, "podcast.xml"), handlers.ShowBaseURL(showsBaseURL, slug))
+	})
+	if err != nil {
+		t.Fatalf("Failed to open shows: %v", err)
+	}
+
+	store, err := storage.LoadRSSStore(filepath.Join(dir, "podcast.xml"), showsBaseURL)
+	if err != nil {
+		t.Fatalf("Failed to load default show: %v", err)
+	}
+	if err := shows.Add(storage.DefaultShow, store); err != nil {
+		t.Fatalf("Failed to add default show: %v", err)
+	}
+	return shows
+}
+
+// Shows are created in their own directories, kept apart and found again
+// on the next start
+func TestShowsCreateAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	shows := openTestShows(t, dir)
+
+	for _, slug := range []string{"tech-talk", "cooking"} {
+		if _, err := shows.Create(slug); err != nil {
+			t.Fatalf("Failed to create show %s: %v", slug, err)
+		}
+	}
+	if _, err := shows.Create("cooking"); !errors.Is(err, storage.ErrShowExists) {
+		t.Errorf("Expected ErrShowExists for a duplicate slug, got %v", err)
+	}
+	for _, slug := range []string{"", "Bad Slug", "../etc", "-leading"} {
+		if _, err := shows.Create(slug); err == nil {
+			t.Errorf("Expected error creating show %q", slug)
+		}
+	}
+
+	cooking, _ := shows.Get("cooking")
+	if err := cooking.AddEpisode(models.Episode{ID: "ep-1", Title: "Bread", Description: "d", PubDate: time.Now(), AudioURL: "/shows/cooking/audio/bread.mp3"}); err != nil {
+		t.Fatalf("Failed to add episode: %v", err)
+	}
+
+	reopened := openTestShows(t, dir)
+	if got := strings.Join(reopened.Slugs(), ","); got != "default,cooking,tech-talk" {
+		t.Errorf("Expected default show first, then alphabetical, got %s", got)
+	}
+
+	cooking, _ = reopened.Get("cooking")
+	tech, _ := reopened.Get("tech-talk")
+	if len(cooking.GetPodcast().Episodes) != 1 || len(tech.GetPodcast().Episodes) != 0 {
+		t.Error("Expected each show to keep its own episodes")
+	}
+}
+
+// mountFeedAndEpisodes mounts a minimal show: its feed and episode list
+func mountFeedAndEpisodes(slug string, store storage.Store) (http.Handler, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.xml", handlers.NewFeedHandler(store, handlers.ShowBaseURL(showsBaseURL, slug)).HandleFeed)
+	mux.HandleFunc("/api/episodes", handlers.NewEpisodesHandler(store, nil, nil, 10, nil, nil).HandleList)
+	return mux, nil
+}
+
+// Show routes are served from the show's own store, and each show's feed
+// gets its own podcast:guid
+func TestShowRoutes(t *testing.T) {
+	shows := openTestShows(t, t.TempDir())
+	h := handlers.NewShowsHandler(shows, showsBaseURL, mountFeedAndEpisodes)
+	if err := h.MountShows(); err != nil {
+		t.Fatalf("Failed to mount shows: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", h.ServeDefault)
+	mux.HandleFunc("/api/shows", h.HandleShows)
+	mux.HandleFunc("/api/shows/", h.HandleShow)
+	mux.HandleFunc("/shows/", h.HandleShow)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "/api/shows", `{"slug": "tech-talk", "title": "Tech Talk"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(http.MethodPost, "/api/shows", `{"slug": "tech-talk"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a duplicate show, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/api/shows", `{"slug": "no/slashes"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid slug, got %d", rec.Code)
+	}
+
+	tech, _ := shows.Get("tech-talk")
+	tech.AddEpisode(models.Episode{ID: "ep-1", Title: "Routers", Description: "d", PubDate: time.Now(), AudioURL: "/shows/tech-talk/audio/routers.mp3"})
+
+	feed := serve(http.MethodGet, "/shows/tech-talk/feed.xml", "").Body.String()
+	for _, want := range []string{
+		"<title>Tech Talk</title>",
+		"http://example.com/shows/tech-talk/audio/routers.mp3",
+		rss.FeedGUID(showsBaseURL + "/shows/tech-talk/feed.xml"),
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("Expected show feed to contain %q", want)
+		}
+	}
+	if root := serve(http.MethodGet, "/feed.xml", "").Body.String(); strings.Contains(root, "Routers") || !strings.Contains(root, rss.FeedGUID(showsBaseURL+"/feed.xml")) {
+		t.Error("Expected the root feed to serve the default show unchanged")
+	}
+
+	var episodes []models.Episode
+	json.NewDecoder(serve(http.MethodGet, "/api/shows/tech-talk/episodes", "").Body).Decode(&episodes)
+	if len(episodes) != 1 || episodes[0].ID != "ep-1" {
+		t.Errorf("Expected the show's episodes, got %+v", episodes)
+	}
+
+	var list []struct {
+		Slug    string `json:"slug"`
+		FeedURL string `json:"feedURL"`
+	}
+	json.NewDecoder(serve(http.MethodGet, "/api/shows", "").Body).Decode(&list)
+	if len(list) != 2 || list[1].FeedURL != showsBaseURL+"/shows/tech-talk/feed.xml" {
+		t.Errorf("Expected both shows listed with their feed URLs, got %+v", list)
+	}
+
+	if rec := serve(http.MethodGet, "/shows/missing/feed.xml", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown show, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodGet, "/shows/tech-talk", ""); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/shows/tech-talk/" {
+		t.Errorf("Expected redirect to the show's dashboard, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+// A show's dashboard lists every show and sends its API requests to the
+// show's routes
+func TestShowDashboard(t *testing.T) {
+	shows := openTestShows(t, t.TempDir())
+	if _, err := shows.Create("cooking"); err != nil {
+		t.Fatalf("Failed to create show: %v", err)
+	}
+
+	var h *handlers.ShowsHandler
+	h = handlers.NewShowsHandler(shows, showsBaseURL, func(slug string, store storage.Store) (http.Handler, error) {
+		store.AddEpisode(models.Episode{ID: "ep-1", Title: "Soup", Description: "d", PubDate: time.Now(), AudioURL: "/audio/ep-1.mp3"})
+		web, err := handlers.NewWebHandler(store, "./../../web/templates", handlers.ShowBaseURL(showsBaseURL, slug))
+		if err != nil {
+			return nil, err
+		}
+		web.SetShows(h, slug)
+		return http.HandlerFunc(web.HandleDashboard), nil
+	})
+	if err := h.MountShows(); err != nil {
+		t.Fatalf("Failed to mount shows: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.HandleShow(rec, httptest.NewRequest(http.MethodGet, "/shows/cooking/", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`hx-get="/api/shows/cooking/podcast/settings"`,
+		`hx-get="/api/shows/cooking/trash"`,
+		`hx-post="/api/shows/cooking/episodes"`,
+		`hx-delete="/api/shows/cooking/episodes/ep-1"`,
+		`<option value="/" >`,
+		`<option value="/shows/cooking/" selected>`,
+		showsBaseURL + "/shows/cooking/feed.xml",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected dashboard to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package integration
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// SQLite store persists episodes across reopen and renders the feed on demand
+func TestSQLiteStoreRoundTrip(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "podcast.db")
+	baseURL := "http://example.com"
+
+	store, err := storage.OpenSQLiteStore(dbPath, baseURL)
+	if err != nil {
+		t.Fatalf("Failed to open SQLite store: %v", err)
+	}
+
+	pubDate := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	for _, id := range []string{"ep-1", "ep-2"} {
+		ep := models.Episode{
+			ID:          id,
+			Title:       "Episode " + id,
+			Description: "Description " + id,
+			PubDate:     pubDate,
+			GUID:        id,
+			AudioURL:    "/audio/" + id + ".mp3",
+			AudioLength: 100,
+			Filename:    id + ".mp3",
+			UploadDate:  pubDate,
+		}
+		if err := store.AddEpisode(ep); err != nil {
+			t.Fatalf("Failed to add episode %s: %v", id, err)
+		}
+	}
+
+	if err := store.DeleteEpisode("ep-1"); err != nil {
+		t.Fatalf("Failed to delete episode: %v", err)
+	}
+	if err := store.DeleteEpisode("ep-1"); err == nil {
+		t.Error("Expected error deleting a missing episode")
+	}
+	store.Close()
+
+	// Reopen to verify persistence and that migrations are not reapplied
+	store, err = storage.OpenSQLiteStore(dbPath, baseURL)
+	if err != nil {
+		t.Fatalf("Failed to reopen SQLite store: %v", err)
+	}
+	defer store.Close()
+
+	p := store.GetPodcast()
+	if len(p.Episodes) != 1 || p.Episodes[0].ID != "ep-2" {
+		t.Fatalf("Expected only ep-2 after reopen, got %+v", p.Episodes)
+	}
+	if p.Episodes[0].Filename != "ep-2.mp3" {
+		t.Errorf("Expected filename to persist, got %q", p.Episodes[0].Filename)
+	}
+
+	xmlData, err := store.ServeXML()
+	if err != nil {
+		t.Fatalf("Failed to render feed: %v", err)
+	}
+	if !strings.Contains(string(xmlData), "http://example.com/audio/ep-2.mp3") {
+		t.Error("Expected feed to contain the remaining episode")
+	}
+}
+
+// Importer loads podcast settings and episodes from an existing podcast.xml
+func TestSQLiteImportFeed(t *testing.T) {
+	dir := t.TempDir()
+	rssFile := filepath.Join(dir, "podcast.xml")
+	baseURL := "http://example.com"
+
+	podcast := models.NewDefaultPodcast()
+	podcast.Title = "Imported Show"
+	podcast.Episodes = []models.Episode{
+		{
+			ID:          "ep-old",
+			Title:       "Old",
+			Description: "Old episode",
+			PubDate:     time.Now(),
+			GUID:        "ep-old",
+			AudioURL:    "/audio/old.mp3",
+			AudioLength: 42,
+		},
+	}
+	xmlData, err := rss.GenerateFeed(podcast, baseURL)
+	if err != nil {
+		t.Fatalf("Failed to generate feed: %v", err)
+	}
+	if err := os.WriteFile(rssFile, xmlData, 0644); err != nil {
+		t.Fatalf("Failed to write feed: %v", err)
+	}
+
+	store, err := storage.OpenSQLiteStore(filepath.Join(dir, "podcast.db"), baseURL)
+	if err != nil {
+		t.Fatalf("Failed to open SQLite store: %v", err)
+	}
+	defer store.Close()
+
+	imported, err := store.ImportFeed(rssFile)
+	if err != nil {
+		t.Fatalf("Failed to import feed: %v", err)
+	}
+	if imported != 1 {
+		t.Errorf("Expected 1 imported episode, got %d", imported)
+	}
+
+	// A second import is a no-op for existing episodes
+	if imported, err := store.ImportFeed(rssFile); err != nil || imported != 0 {
+		t.Errorf("Expected re-import to skip existing episodes, got %d, %v", imported, err)
+	}
+
+	p := store.GetPodcast()
+	if p.Title != "Imported Show" {
+		t.Errorf("Expected imported title, got %q", p.Title)
+	}
+	if len(p.Episodes) != 1 || p.Episodes[0].Filename != "old.mp3" {
+		t.Errorf("Expected imported episode with recovered filename, got %+v", p.Episodes)
+	}
+}
+
+// A podcast that can't be read falls back to the last known settings, so
+// saving it back never replaces the stored settings with defaults
+func TestSQLiteStoreUnreadablePodcast(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "podcast.db")
+	store, err := storage.OpenSQLiteStore(dbPath, "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite store: %v", err)
+	}
+	defer store.Close()
+
+	p := store.GetPodcast()
+	p.Title = "My Show"
+	p.Author = "Jane"
+	if err := store.UpdatePodcast(p); err != nil {
+		t.Fatalf("Failed to update podcast: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`UPDATE podcast SET data = 'not json' WHERE id = 1`); err != nil {
+		t.Fatalf("Failed to corrupt podcast row: %v", err)
+	}
+
+	p = store.GetPodcast()
+	if p.Title != "My Show" || p.Author != "Jane" {
+		t.Fatalf("Expected the last known settings, got %q by %q", p.Title, p.Author)
+	}
+	p.Description = "Edited while the row was unreadable"
+	if err := store.UpdatePodcast(p); err != nil {
+		t.Fatalf("Failed to update podcast: %v", err)
+	}
+
+	var data string
+	if err := db.QueryRow(`SELECT data FROM podcast WHERE id = 1`).Scan(&data); err != nil {
+		t.Fatalf("Failed to read podcast row: %v", err)
+	}
+	if !strings.Contains(data, "My Show") || strings.Contains(data, models.NewDefaultPodcast().Title) {
+		t.Errorf("Expected the saved settings to keep the title, got %s", data)
+	}
+}
@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/models"
+)
+
+// Trashed episodes leave the podcast, survive a reopen and can be restored
+// or purged
+func TestStoreTrash(t *testing.T) {
+	for name, open := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open()
+
+			pubDate := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+			for _, id := range []string{"ep-1", "ep-2", "ep-3"} {
+				if err := store.AddEpisode(models.Episode{ID: id, GUID: "guid-" + id, Title: id, Description: id, PubDate: pubDate, AudioURL: "/audio/" + id + ".mp3"}); err != nil {
+					t.Fatalf("Failed to add episode: %v", err)
+				}
+			}
+
+			deletedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+			if err := store.TrashEpisode("ep-1", deletedAt); err != nil {
+				t.Fatalf("Failed to trash episode: %v", err)
+			}
+			if err := store.TrashEpisode("ep-2", deletedAt.Add(time.Hour)); err != nil {
+				t.Fatalf("Failed to trash episode: %v", err)
+			}
+			if err := store.TrashEpisode("missing", deletedAt); err == nil {
+				t.Error("Expected error trashing a missing episode")
+			}
+
+			// Reopen to check both the podcast and the trash were persisted
+			store = open()
+			if got := len(store.GetPodcast().Episodes); got != 1 {
+				t.Fatalf("Expected 1 live episode, got %d", got)
+			}
+
+			trash := store.GetTrash()
+			if len(trash) != 2 {
+				t.Fatalf("Expected 2 trashed episodes, got %d", len(trash))
+			}
+			if trash[0].ID != "ep-2" || trash[1].ID != "ep-1" {
+				t.Errorf("Expected most recently deleted first, got %s, %s", trash[0].ID, trash[1].ID)
+			}
+			if !trash[1].DeletedAt.Equal(deletedAt) {
+				t.Errorf("Expected deletedAt %v, got %v", deletedAt, trash[1].DeletedAt)
+			}
+
+			if err := store.RestoreEpisode("ep-1"); err != nil {
+				t.Fatalf("Failed to restore episode: %v", err)
+			}
+			if err := store.RestoreEpisode("ep-1"); err == nil {
+				t.Error("Expected error restoring an episode no longer in the trash")
+			}
+			if err := store.PurgeEpisode("ep-2"); err != nil {
+				t.Fatalf("Failed to purge episode: %v", err)
+			}
+			if err := store.PurgeEpisode("ep-2"); err == nil {
+				t.Error("Expected error purging an episode no longer in the trash")
+			}
+
+			store = open()
+			if got := len(store.GetTrash()); got != 0 {
+				t.Errorf("Expected empty trash, got %d episodes", got)
+			}
+
+			var restored *models.Episode
+			for _, ep := range store.GetPodcast().Episodes {
+				if ep.ID == "ep-1" {
+					restored = &ep
+				}
+			}
+			if restored == nil {
+				t.Fatal("Expected restored episode in the podcast")
+			}
+			if restored.GUID != "guid-ep-1" || !restored.PubDate.Equal(pubDate) {
+				t.Errorf("Expected GUID and date to survive the trash, got %q %v", restored.GUID, restored.PubDate)
+			}
+		})
+	}
+}
+
+// Restoring fails while a live episode has the same ID
+func TestStoreRestoreConflict(t *testing.T) {
+	for name, open := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open()
+
+			ep := models.Episode{ID: "ep-1", GUID: "guid-1", Title: "Old", Description: "d", PubDate: time.Now(), AudioURL: "/audio/old.mp3"}
+			if err := store.AddEpisode(ep); err != nil {
+				t.Fatalf("Failed to add episode: %v", err)
+			}
+			if err := store.TrashEpisode("ep-1", time.Now()); err != nil {
+				t.Fatalf("Failed to trash episode: %v", err)
+			}
+
+			ep.Title = "New"
+			if err := store.AddEpisode(ep); err != nil {
+				t.Fatalf("Failed to add episode: %v", err)
+			}
+			if err := store.RestoreEpisode("ep-1"); err == nil {
+				t.Fatal("Expected conflict restoring over a live episode")
+			}
+
+			if got := len(store.GetTrash()); got != 1 {
+				t.Errorf("Expected episode to stay in the trash, got %d", got)
+			}
+			if got := store.GetPodcast().Episodes; len(got) != 1 || got[0].Title != "New" {
+				t.Errorf("Expected live episode untouched, got %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// tusFixture wires a tus handler to filesystem stores in temp dirs
+type tusFixture struct {
+	handler    *handlers.TusHandler
+	store      storage.Store
+	audioDir   string
+	uploadsDir string
+}
+
+func newTusFixture(t *testing.T, expiry time.Duration) *tusFixture {
+	t.Helper()
+
+	root := t.TempDir()
+	store, err := storage.LoadRSSStore(filepath.Join(root, "podcast.xml"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create RSS store: %v", err)
+	}
+
+	audioDir := filepath.Join(root, "audio")
+	episodes := handlers.NewEpisodesHandler(store, storage.NewFSBlobStore(audioDir, "/audio/"), storage.NewFSBlobStore(filepath.Join(root, "artwork"), "/static/artwork/"), 10, nil, nil)
+
+	uploadsDir := filepath.Join(root, "uploads")
+	handler, err := handlers.NewTusHandler(episodes, uploadsDir, expiry)
+	if err != nil {
+		t.Fatalf("Failed to create tus handler: %v", err)
+	}
+
+	return &tusFixture{handler: handler, store: store, audioDir: audioDir, uploadsDir: uploadsDir}
+}
+
+// create starts an upload and returns its URL path
+func (f *tusFixture) create(t *testing.T, length int, metadata map[string]string) string {
+	t.Helper()
+
+	var pairs []string
+	for k, v := range metadata {
+		pairs = append(pairs, k+" "+base64.StdEncoding.EncodeToString([]byte(v)))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/uploads", nil)
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	req.Header.Set("Upload-Length", strconv.Itoa(length))
+	req.Header.Set("Upload-Metadata", strings.Join(pairs, ","))
+	rec := httptest.NewRecorder()
+	f.handler.HandleCreate(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 on create, got %d: %s", rec.Code, rec.Body.String())
+	}
+	location := rec.Header().Get("Location")
+	if location == "" {
+		t.Fatal("Expected Location header on create")
+	}
+	return location
+}
+
+// patch sends a chunk at offset
+func (f *tusFixture) patch(location string, offset int, chunk []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, location, bytes.NewReader(chunk))
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.Itoa(offset))
+	rec := httptest.NewRecorder()
+	f.handler.HandlePatch(rec, req)
+	return rec
+}
+
+// head fetches the upload's state
+func (f *tusFixture) head(location string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodHead, location, nil)
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	rec := httptest.NewRecorder()
+	f.handler.HandleHead(rec, req)
+	return rec
+}
+
+// A chunked upload resumes from the server's offset and becomes an episode
+func TestTusResumableUpload(t *testing.T) {
+	f := newTusFixture(t, time.Hour)
+
+	data := mp3Frames(160)
+	location := f.create(t, len(data), map[string]string{
+		"filename":      "long-episode.mp3",
+		"title":         "Two Hour Special",
+		"description":   "Uploaded over hotel Wi-Fi",
+		"episodeNumber": "42",
+	})
+
+	half := len(data) / 2
+	if rec := f.patch(location, 0, data[:half]); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204 for first chunk, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// After a dropped connection the client asks where to resume
+	rec := f.head(location)
+	if got := rec.Header().Get("Upload-Offset"); got != strconv.Itoa(half) {
+		t.Fatalf("Expected Upload-Offset %d, got %q", half, got)
+	}
+
+	if rec := f.patch(location, 0, data[:10]); rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for stale offset, got %d", rec.Code)
+	}
+
+	rec = f.patch(location, half, data[half:])
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for final chunk, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Upload-Offset"); got != strconv.Itoa(len(data)) {
+		t.Errorf("Expected final Upload-Offset %d, got %q", len(data), got)
+	}
+
+	var episode models.Episode
+	if err := json.NewDecoder(rec.Body).Decode(&episode); err != nil {
+		t.Fatalf("Failed to decode episode: %v", err)
+	}
+	if episode.Title != "Two Hour Special" || episode.EpisodeNum != 42 {
+		t.Errorf("Expected episode fields from Upload-Metadata, got %+v", episode)
+	}
+
+	stored, err := os.ReadFile(filepath.Join(f.audioDir, episode.Filename))
+	if err != nil {
+		t.Fatalf("Failed to read stored audio: %v", err)
+	}
+	if !bytes.Equal(stored, data) {
+		t.Error("Stored audio does not match the uploaded chunks")
+	}
+
+	if entries, _ := os.ReadDir(f.uploadsDir); len(entries) != 0 {
+		t.Errorf("Expected completed upload to be cleaned up, found %d files", len(entries))
+	}
+	if len(f.store.GetPodcast().Episodes) != 1 {
+		t.Error("Expected episode to be added to the store")
+	}
+}
+
+// Requests without the protocol version header are rejected
+func TestTusRequiresVersion(t *testing.T) {
+	f := newTusFixture(t, time.Hour)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/uploads", nil)
+	req.Header.Set("Upload-Length", "10")
+	rec := httptest.NewRecorder()
+	f.handler.HandleCreate(rec, req)
+
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412, got %d", rec.Code)
+	}
+	if rec.Header().Get("Tus-Version") != "1.0.0" {
+		t.Error("Expected Tus-Version header on version mismatch")
+	}
+}
+
+// Unsupported file types are rejected before any data is sent
+func TestTusRejectsUnsupportedType(t *testing.T) {
+	f := newTusFixture(t, time.Hour)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/uploads", nil)
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	req.Header.Set("Upload-Length", "10")
+	req.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte("notes.txt")))
+	rec := httptest.NewRecorder()
+	f.handler.HandleCreate(rec, req)
+
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status 415, got %d", rec.Code)
+	}
+}
+
+// Terminated uploads are removed
+func TestTusTermination(t *testing.T) {
+	f := newTusFixture(t, time.Hour)
+	location := f.create(t, 100, map[string]string{"filename": "episode.mp3"})
+	f.patch(location, 0, make([]byte, 50))
+
+	req := httptest.NewRequest(http.MethodDelete, location, nil)
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	rec := httptest.NewRecorder()
+	f.handler.HandleDelete(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", rec.Code)
+	}
+	if rec := f.head(location); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after termination, got %d", rec.Code)
+	}
+}
+
+// The janitor removes partial uploads past their expiry
+func TestTusJanitorPurgesExpired(t *testing.T) {
+	f := newTusFixture(t, 10*time.Millisecond)
+	location := f.create(t, 100, map[string]string{"filename": "episode.mp3"})
+
+	time.Sleep(20 * time.Millisecond)
+
+	if rec := f.head(location); rec.Code != http.StatusGone {
+		t.Errorf("Expected status 410 for expired upload, got %d", rec.Code)
+	}
+	if n := f.handler.PurgeExpired(); n != 1 {
+		t.Errorf("Expected 1 purged upload, got %d", n)
+	}
+	if entries, _ := os.ReadDir(f.uploadsDir); len(entries) != 0 {
+		t.Errorf("Expected uploads dir to be empty, found %d files", len(entries))
+	}
+}
@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// newStreamingUploadRequest builds a multipart upload whose audio part is
+// written after the text fields, streamed through a pipe
+func newStreamingUploadRequest(filename string, audio io.Reader) *http.Request {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		mw.WriteField("title", "Streamed Episode")
+		mw.WriteField("description", "Uploaded without buffering")
+		part, err := mw.CreateFormFile("audio", filename)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(part, audio); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/episodes", pr)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// mp3Frames returns n silent MPEG-1 Layer III frames (128 kbps, 44.1 kHz)
+func mp3Frames(n int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return bytes.Repeat(frame, n)
+}
+
+// listDir returns the names of the files in dir
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// Uploads are staged in the audio dir and renamed into place
+func TestStreamingUploadToFilesystem(t *testing.T) {
+	audioDir := filepath.Join(t.TempDir(), "audio")
+	audio := storage.NewFSBlobStore(audioDir, "/audio/")
+	store, err := storage.LoadRSSStore(filepath.Join(t.TempDir(), "podcast.xml"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create RSS store: %v", err)
+	}
+	handler := handlers.NewEpisodesHandler(store, audio, storage.NewFSBlobStore(t.TempDir(), "/static/artwork/"), 1, nil, nil)
+
+	data := mp3Frames(1300) // ~540 KB
+	rec := httptest.NewRecorder()
+	handler.HandleUpload(rec, newStreamingUploadRequest("episode.mp3", bytes.NewReader(data)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	files := listDir(t, audioDir)
+	if len(files) != 1 || filepath.Ext(files[0]) != ".mp3" {
+		t.Fatalf("Expected only the stored audio file in %s, got %v", audioDir, files)
+	}
+
+	stored, err := os.ReadFile(filepath.Join(audioDir, files[0]))
+	if err != nil {
+		t.Fatalf("Failed to read stored audio: %v", err)
+	}
+	if !bytes.Equal(stored, data) {
+		t.Error("Stored audio does not match the upload")
+	}
+}
+
+// Oversized uploads are rejected mid-stream and leave no temp files behind
+func TestStreamingUploadTooLarge(t *testing.T) {
+	audioDir := filepath.Join(t.TempDir(), "audio")
+	audio := storage.NewFSBlobStore(audioDir, "/audio/")
+	store, err := storage.LoadRSSStore(filepath.Join(t.TempDir(), "podcast.xml"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create RSS store: %v", err)
+	}
+	handler := handlers.NewEpisodesHandler(store, audio, storage.NewFSBlobStore(t.TempDir(), "/static/artwork/"), 1, nil, nil)
+
+	data := bytes.Repeat([]byte("x"), 2*1024*1024)
+	req := newStreamingUploadRequest("episode.mp3", bytes.NewReader(data))
+	defer req.Body.Close() // unblocks the writer once the handler gives up
+	rec := httptest.NewRecorder()
+	handler.HandleUpload(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected status 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if files := listDir(t, audioDir); len(files) != 0 {
+		t.Errorf("Expected no files left in %s, got %v", audioDir, files)
+	}
+	if len(store.GetPodcast().Episodes) != 0 {
+		t.Error("Expected no episode to be added")
+	}
+}
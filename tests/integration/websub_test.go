@@ -0,0 +1,307 @@
+package integration
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+	"github.com/example/rss-server/internal/scheduler"
+	"github.com/example/rss-server/internal/storage"
+	"github.com/example/rss-server/internal/websub"
+)
+
+// stubHub is a WebSub hub recording the publish notifications it receives.
+// It answers with the queued statuses first, then 204.
+type stubHub struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	topics   []string
+	received chan string
+}
+
+func newStubHub(t *testing.T, statuses ...int) *stubHub {
+	h := &stubHub{statuses: statuses, received: make(chan string, 100)}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method != http.MethodPost || r.PostForm.Get("hub.mode") != "publish" {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		h.mu.Lock()
+		status := http.StatusNoContent
+		if len(h.statuses) > 0 {
+			status, h.statuses = h.statuses[0], h.statuses[1:]
+		}
+		if status == http.StatusNoContent {
+			h.topics = append(h.topics, r.PostForm.Get("hub.url"))
+			h.received <- r.PostForm.Get("hub.url")
+		}
+		h.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+// notified returns the topics the hub was notified about, sorted
+func (h *stubHub) notified() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	topics := append([]string(nil), h.topics...)
+	sort.Strings(topics)
+	return topics
+}
+
+// Adding episodes, changing settings and publishing scheduled episodes
+// each ping the hub about the show's feeds
+func TestWebSubNotifiesOnChange(t *testing.T) {
+	hub := newStubHub(t)
+	publisher := websub.New([]string{hub.URL})
+
+	rssStore, err := storage.LoadRSSStore(filepath.Join(t.TempDir(), "podcast.xml"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	store := storage.NotifyOnChange(rssStore, func() {
+		publisher.Notify("http://example.com/feed.xml", "http://example.com/feed.atom", "http://example.com/feed.json")
+	})
+
+	pubDate := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	changes := map[string]func() error{
+		"add episode": func() error {
+			return store.AddEpisode(models.Episode{ID: "ep-1", Title: "t", Description: "d", PubDate: pubDate, AudioURL: "/audio/ep-1.mp3"})
+		},
+		"update podcast": func() error {
+			p := store.GetPodcast()
+			p.Title = "Renamed"
+			return store.UpdatePodcast(p)
+		},
+		"scheduled publish": func() error {
+			ep := models.Episode{ID: "ep-2", Title: "t", Description: "d", PubDate: pubDate.Add(time.Hour), AudioURL: "/audio/ep-2.mp3", Status: models.StatusScheduled}
+			if err := rssStore.AddEpisode(ep); err != nil {
+				return err
+			}
+			s := scheduler.New(store)
+			s.Now = func() time.Time { return pubDate.Add(2 * time.Hour) }
+			s.PublishDue()
+			return nil
+		},
+	}
+
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			hub.mu.Lock()
+			hub.topics = nil
+			hub.mu.Unlock()
+
+			if err := change(); err != nil {
+				t.Fatalf("Change failed: %v", err)
+			}
+			// Repeated changes before delivery are covered by one ping
+			p := store.GetPodcast()
+			p.Description += "."
+			store.UpdatePodcast(p)
+
+			if got := publisher.Pending(); got != 3 {
+				t.Fatalf("Expected 3 queued pings, got %d", got)
+			}
+			if got := publisher.DeliverDue(); got != 3 {
+				t.Errorf("Expected 3 delivered pings, got %d", got)
+			}
+			if got := strings.Join(hub.notified(), ","); got != "http://example.com/feed.atom,http://example.com/feed.json,http://example.com/feed.xml" {
+				t.Errorf("Expected the hub to be notified about every feed, got %s", got)
+			}
+		})
+	}
+
+	if err := store.DeleteEpisode("missing"); err == nil || publisher.Pending() != 0 {
+		t.Error("Expected a failed change not to ping the hub")
+	}
+}
+
+// Changes to drafts, scheduled episodes and the trash leave the public
+// feeds unchanged and do not ping the hub
+func TestWebSubIgnoresUnpublishedChanges(t *testing.T) {
+	publisher := websub.New([]string{newStubHub(t).URL})
+
+	rssStore, err := storage.LoadRSSStore(filepath.Join(t.TempDir(), "podcast.xml"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	store := storage.NotifyOnChange(rssStore, func() {
+		publisher.Notify("http://example.com/feed.xml")
+	})
+
+	pubDate := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	draft := models.Episode{ID: "draft", Title: "t", Description: "d", PubDate: pubDate, AudioURL: "/audio/draft.mp3", Status: models.StatusDraft}
+	scheduled := models.Episode{ID: "later", Title: "t", Description: "d", PubDate: pubDate.Add(time.Hour), AudioURL: "/audio/later.mp3", Status: models.StatusScheduled}
+	changes := []struct {
+		name   string
+		change func() error
+	}{
+		{"upload draft", func() error { return store.AddEpisode(draft) }},
+		{"schedule episode", func() error { return store.AddEpisode(scheduled) }},
+		{"edit scheduled episode", func() error {
+			scheduled.Title = "Retitled"
+			return store.UpdateEpisode(scheduled)
+		}},
+		{"trash draft", func() error { return store.TrashEpisode("draft", pubDate) }},
+		{"restore draft", func() error { return store.RestoreEpisode("draft") }},
+		{"republish unchanged settings", func() error { return store.UpdatePodcast(store.GetPodcast()) }},
+	}
+	for _, c := range changes {
+		if err := c.change(); err != nil {
+			t.Fatalf("%s: change failed: %v", c.name, err)
+		}
+		if got := publisher.Pending(); got != 0 {
+			t.Fatalf("%s: expected no pings, got %d", c.name, got)
+		}
+	}
+
+	// Publishing the draft by hand changes the feeds
+	draft.Status = models.StatusPublished
+	if err := store.UpdateEpisode(draft); err != nil {
+		t.Fatalf("Failed to publish draft: %v", err)
+	}
+	if got := publisher.Pending(); got != 1 {
+		t.Errorf("Expected publishing a draft to ping the hub, got %d pings", got)
+	}
+}
+
+// Failed pings are retried with growing waits until the hub accepts them;
+// rejected pings are dropped
+func TestWebSubRetryBackoff(t *testing.T) {
+	hub := newStubHub(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	publisher := websub.New([]string{hub.URL})
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	publisher.Now = func() time.Time { return now }
+
+	publisher.Notify("http://example.com/feed.xml")
+	steps := []struct {
+		advance   time.Duration
+		delivered int
+	}{
+		{0, 0},                // first attempt fails
+		{10 * time.Second, 0}, // not yet due
+		{20 * time.Second, 0}, // second attempt, 30s later, fails
+		{30 * time.Second, 0}, // the wait doubled to 60s: not yet due
+		{30 * time.Second, 1}, // third attempt succeeds
+	}
+	for i, step := range steps {
+		now = now.Add(step.advance)
+		if got := publisher.DeliverDue(); got != step.delivered {
+			t.Fatalf("Step %d: expected %d delivered pings, got %d", i, step.delivered, got)
+		}
+	}
+	if publisher.Pending() != 0 || len(hub.notified()) != 1 {
+		t.Errorf("Expected one delivered ping and an empty queue, got %d pending", publisher.Pending())
+	}
+
+	rejecting := newStubHub(t, http.StatusBadRequest)
+	publisher = websub.New([]string{rejecting.URL})
+	publisher.Notify("http://example.com/feed.xml")
+	publisher.DeliverDue()
+	if publisher.Pending() != 0 {
+		t.Error("Expected a rejected ping to be dropped")
+	}
+}
+
+// The background queue delivers pings as soon as a change is notified
+func TestWebSubStart(t *testing.T) {
+	hub := newStubHub(t)
+	publisher := websub.New([]string{hub.URL})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	publisher.Start(stop)
+
+	publisher.Notify("http://example.com/feed.xml")
+	select {
+	case topic := <-hub.received:
+		if topic != "http://example.com/feed.xml" {
+			t.Errorf("Expected a ping about the feed, got %q", topic)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the hub to be notified")
+	}
+}
+
+// Every feed announces the hubs, the RSS and Atom feeds next to their self
+// links
+func TestFeedsAnnounceHubs(t *testing.T) {
+	store, err := storage.LoadRSSStore(filepath.Join(t.TempDir(), "podcast.xml"), "http://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	h := handlers.NewFeedHandler(store, "http://example.com")
+	h.SetHubs([]string{"https://hub.example.com/"})
+
+	rec := httptest.NewRecorder()
+	h.HandleFeed(rec, httptest.NewRequest(http.MethodGet, "/feed.xml", nil))
+	var doc rss.RSS
+	if err := xml.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+	if doc.Channel.AtomLink("hub") != "https://hub.example.com/" || doc.Channel.AtomLink("self") != "http://example.com/feed.xml" {
+		t.Errorf("Expected hub and self links in the RSS feed, got %+v", doc.Channel.Links)
+	}
+
+	rec = httptest.NewRecorder()
+	h.HandleAtom(rec, httptest.NewRequest(http.MethodGet, "/feed.atom", nil))
+	if !strings.Contains(rec.Body.String(), `<link rel="hub" href="https://hub.example.com/"></link>`) {
+		t.Error("Expected a hub link in the Atom feed")
+	}
+
+	rec = httptest.NewRecorder()
+	h.HandleJSONFeed(rec, httptest.NewRequest(http.MethodGet, "/feed.json", nil))
+	var jsonFeed struct {
+		Hubs []struct {
+			Type string `json:"type"`
+			URL  string `json:"url"`
+		} `json:"hubs"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &jsonFeed); err != nil {
+		t.Fatalf("Failed to parse JSON feed: %v", err)
+	}
+	if len(jsonFeed.Hubs) != 1 || jsonFeed.Hubs[0].Type != "WebSub" || jsonFeed.Hubs[0].URL != "https://hub.example.com/" {
+		t.Errorf("Expected a WebSub hub in the JSON feed, got %+v", jsonFeed.Hubs)
+	}
+
+	rec = httptest.NewRecorder()
+	h.HandleArchive(rec, httptest.NewRequest(http.MethodGet, "/feed-archive.xml", nil))
+	var archive rss.RSS
+	if err := xml.Unmarshal(rec.Body.Bytes(), &archive); err != nil {
+		t.Fatalf("Failed to parse archive: %v", err)
+	}
+	if archive.Channel.AtomLink("hub") != "https://hub.example.com/" {
+		t.Errorf("Expected a hub link in the archive feed, got %+v", archive.Channel.Links)
+	}
+
+	// Subscribers of the paged feed subscribe to its first page
+	h.SetPaging(1, 0)
+	store.AddEpisode(models.Episode{ID: "ep-1", Title: "t", Description: "d", PubDate: time.Now(), AudioURL: "/audio/ep-1.mp3"})
+	store.AddEpisode(models.Episode{ID: "ep-2", Title: "t", Description: "d", PubDate: time.Now(), AudioURL: "/audio/ep-2.mp3"})
+	for page, wantHub := range map[string]bool{"/feed.xml": true, "/feed.xml?page=2": false} {
+		rec := httptest.NewRecorder()
+		h.HandleFeed(rec, httptest.NewRequest(http.MethodGet, page, nil))
+		var doc rss.RSS
+		xml.Unmarshal(rec.Body.Bytes(), &doc)
+		if got := doc.Channel.AtomLink("hub") != ""; got != wantHub {
+			t.Errorf("%s: expected hub link %v, got %v", page, wantHub, got)
+		}
+	}
+}
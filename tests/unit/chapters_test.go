@@ -0,0 +1,164 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/models"
+)
+
+// ID3 chapters are imported at upload, with chapter images stored as artwork
+func TestUploadImportsChapters(t *testing.T) {
+	store := newMemStore()
+	artwork := newMemBlobStore()
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), artwork, 10, nil, nil)
+
+	tag := id3v23(
+		chapFrame("ch1", 0, 30000,
+			id3Frame("TIT2", []byte("\x00Cold Open")),
+			id3Frame("APIC", append([]byte("\x00image/jpeg\x00\x00\x00"), 0xFF, 0xD8, 0xFF, 0xE0)),
+		),
+		chapFrame("ch2", 30000, 0, id3Frame("WXXX", []byte("\x00\x00https://example.com/notes"))),
+	)
+	audio := append(tag, buildMP3(10, 0)...)
+
+	req := newUploadRequest(t, "show.mp3", audio, map[string]string{"title": "Ep", "description": "Desc"})
+	rec := httptest.NewRecorder()
+	handler.HandleUpload(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	chapters := store.GetPodcast().Episodes[0].Chapters
+	if len(chapters) != 2 {
+		t.Fatalf("Expected 2 chapters, got %+v", chapters)
+	}
+	if chapters[0].Title != "Cold Open" || chapters[0].EndTime != 30 {
+		t.Errorf("Unexpected first chapter: %+v", chapters[0])
+	}
+	if img := path.Base(chapters[0].Img); chapters[0].Img == "" || !artwork.has(img) {
+		t.Errorf("Expected chapter image in artwork store, got %q", chapters[0].Img)
+	}
+	if chapters[1].Title != "Chapter 2" || chapters[1].StartTime != 30 || chapters[1].URL != "https://example.com/notes" {
+		t.Errorf("Unexpected second chapter: %+v", chapters[1])
+	}
+}
+
+// Chapters are replaced through the API, validated and sorted
+func TestChaptersAPI(t *testing.T) {
+	store := newMemStore()
+	store.AddEpisode(models.Episode{ID: "ep-1", Title: "Ep"})
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+
+	put := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/episodes/"+id+"/chapters", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.HandleUpdateChapters(rec, req)
+		return rec
+	}
+
+	rec := put("ep-1", `{"chapters": [
+		{"startTime": 120.5, "title": "Part Two", "url": "https://example.com/two"},
+		{"startTime": 0, "title": "Part One", "img": "/static/artwork/one.jpg"}
+	]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/episodes/ep-1/chapters", nil)
+	rec = httptest.NewRecorder()
+	handler.HandleGetChapters(rec, req)
+
+	var body struct {
+		Chapters []models.Chapter `json:"chapters"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode chapters: %v", err)
+	}
+	if len(body.Chapters) != 2 || body.Chapters[0].Title != "Part One" || body.Chapters[1].StartTime != 120.5 {
+		t.Errorf("Expected chapters sorted by start time, got %+v", body.Chapters)
+	}
+
+	tests := []struct {
+		name string
+		id   string
+		body string
+		code int
+	}{
+		{"missing title", "ep-1", `{"chapters": [{"startTime": 0}]}`, http.StatusBadRequest},
+		{"negative start", "ep-1", `{"chapters": [{"startTime": -1, "title": "x"}]}`, http.StatusBadRequest},
+		{"end before start", "ep-1", `{"chapters": [{"startTime": 10, "endTime": 5, "title": "x"}]}`, http.StatusBadRequest},
+		{"relative link", "ep-1", `{"chapters": [{"startTime": 0, "title": "x", "url": "notes.html"}]}`, http.StatusBadRequest},
+		{"unknown field", "ep-1", `{"chapters": [{"start": 0, "title": "x"}]}`, http.StatusBadRequest},
+		{"unknown episode", "nope", `{"chapters": []}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := put(tt.id, tt.body); rec.Code != tt.code {
+				t.Errorf("Expected status %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+// The public chapters document uses absolute URLs and the chapters MIME type
+func TestChaptersDocument(t *testing.T) {
+	store := newMemStore()
+	store.AddEpisode(models.Episode{ID: "ep-1", Chapters: []models.Chapter{
+		{StartTime: 0, Title: "Intro", Img: "/static/artwork/intro.jpg"},
+		{StartTime: 95, Title: "Outro", URL: "https://example.com/outro"},
+	}})
+	store.AddEpisode(models.Episode{ID: "ep-2"})
+	store.AddEpisode(models.Episode{ID: "ep-3", Status: models.StatusScheduled, Chapters: []models.Chapter{{StartTime: 0, Title: "Spoiler"}}})
+	handler := handlers.NewChaptersHandler(store, "https://podcast.example.com")
+
+	rec := httptest.NewRecorder()
+	handler.HandleChapters(rec, httptest.NewRequest(http.MethodGet, "/episodes/ep-1/chapters.json", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json+chapters" {
+		t.Errorf("Expected chapters content type, got %q", ct)
+	}
+
+	var doc struct {
+		Version  string           `json:"version"`
+		Chapters []models.Chapter `json:"chapters"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+	if doc.Version != "1.2.0" || len(doc.Chapters) != 2 {
+		t.Fatalf("Unexpected document: %s", rec.Body.String())
+	}
+	if doc.Chapters[0].Img != "https://podcast.example.com/static/artwork/intro.jpg" {
+		t.Errorf("Expected absolute image URL, got %q", doc.Chapters[0].Img)
+	}
+	if bytes.Contains(rec.Body.Bytes(), []byte(`"endTime"`)) {
+		t.Error("Expected endTime to be omitted when unset")
+	}
+
+	handler.SetPreviewToken(previewToken)
+	for _, p := range []string{"/episodes/ep-2/chapters.json", "/episodes/ep-3/chapters.json", "/episodes/ep-3/chapters.json?token=wrong", "/episodes/missing/chapters.json", "/episodes/ep-1/other.json"} {
+		rec := httptest.NewRecorder()
+		handler.HandleChapters(rec, httptest.NewRequest(http.MethodGet, p, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %s, got %d", p, rec.Code)
+		}
+	}
+
+	// Preview subscribers see the chapters of unreleased episodes
+	rec = httptest.NewRecorder()
+	handler.HandleChapters(rec, httptest.NewRequest(http.MethodGet, "/episodes/ep-3/chapters.json?token="+previewToken, nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with the preview token, got %d", rec.Code)
+	}
+}
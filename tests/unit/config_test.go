@@ -170,3 +170,107 @@ paths:
 		t.Errorf("Expected base URL 'http://localhost:8080', got: %s", cfg.GetBaseURL())
 	}
 }
+
+// Unknown storage backend returns error
+func TestInvalidStorageBackend(t *testing.T) {
+	cfg := &config.Config{
+		BaseURL: "http://example.com",
+	}
+	cfg.Storage.Backend = "postgres"
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for unknown storage backend, got nil")
+	}
+}
+
+// SQLite backend requires a database path
+func TestSQLiteBackendRequiresDatabase(t *testing.T) {
+	cfg := &config.Config{
+		BaseURL: "http://example.com",
+	}
+	cfg.Storage.Backend = config.StorageBackendSQLite
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for sqlite backend without database path, got nil")
+	}
+
+	cfg.Paths.Database = "./data/podcast.db"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected sqlite backend with database path to be valid, got: %v", err)
+	}
+}
+
+// Allowed extensions are normalized and must be supported audio types
+func TestAllowedExtensionsValidation(t *testing.T) {
+	cfg := &config.Config{
+		BaseURL: "http://example.com",
+	}
+	cfg.Upload.AllowedExtensions = []string{"MP3", ".Flac"}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected supported extensions to be valid, got: %v", err)
+	}
+	if got := cfg.Upload.AllowedExtensions; got[0] != ".mp3" || got[1] != ".flac" {
+		t.Errorf("Expected normalized extensions [.mp3 .flac], got %v", got)
+	}
+
+	cfg.Upload.AllowedExtensions = []string{".mp3", ".wav"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for unsupported extension .wav, got nil")
+	}
+}
+
+// Preview feed tokens must be long enough not to be guessed
+func TestPreviewTokenValidation(t *testing.T) {
+	cfg := &config.Config{
+		BaseURL: "http://example.com",
+	}
+	cfg.Feed.PreviewToken = "short"
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for a short preview token, got nil")
+	}
+
+	cfg.Feed.PreviewToken = "0123456789abcdef"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected 16 character preview token to be valid, got: %v", err)
+	}
+}
+
+// Feed paging and the episode cap are exclusive and never negative
+func TestFeedPagingValidation(t *testing.T) {
+	cfg := &config.Config{
+		BaseURL: "http://example.com",
+	}
+	cfg.Feed.PageSize = 50
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected page size to be valid, got: %v", err)
+	}
+
+	cfg.Feed.MaxEpisodes = 100
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error when both page_size and max_episodes are set, got nil")
+	}
+
+	cfg.Feed.PageSize = 0
+	cfg.Feed.MaxEpisodes = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for a negative max_episodes, got nil")
+	}
+}
+
+// WebSub hubs must be http or https URLs
+func TestWebSubHubsValidation(t *testing.T) {
+	cfg := &config.Config{
+		BaseURL: "http://example.com",
+	}
+	cfg.Feed.WebSubHubs = []string{"https://pubsubhubbub.appspot.com/"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected hub URL to be valid, got: %v", err)
+	}
+
+	cfg.Feed.WebSubHubs = []string{"ftp://hub.example.com/"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for a non-HTTP hub URL, got nil")
+	}
+}
@@ -0,0 +1,272 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/models"
+)
+
+func newEditStore() *memStore {
+	store := newMemStore()
+	store.AddEpisode(models.Episode{
+		ID: "ep-1", GUID: "ep-1", Title: "Pilto", Description: "The first one",
+		PubDate: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), Explicit: "no", EpisodeNum: 1, EpisodeType: "full",
+	})
+	return store
+}
+
+func TestGetEpisode(t *testing.T) {
+	handler := handlers.NewEpisodesHandler(newEditStore(), newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+
+	rec := httptest.NewRecorder()
+	handler.HandleGet(rec, httptest.NewRequest(http.MethodGet, "/api/episodes/ep-1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ep models.Episode
+	if err := json.NewDecoder(rec.Body).Decode(&ep); err != nil || ep.Title != "Pilto" {
+		t.Errorf("Unexpected episode %+v (%v)", ep, err)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.HandleGet(rec, httptest.NewRequest(http.MethodGet, "/api/episodes/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+}
+
+// A JSON patch changes only the fields sent and keeps the ID and GUID
+func TestUpdateEpisodeJSON(t *testing.T) {
+	store := newEditStore()
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+
+	body := `{"title": "Pilot", "pubDate": "2024-03-02T10:30:00Z", "seasonNum": 2}`
+	req := httptest.NewRequest(http.MethodPatch, "/api/episodes/ep-1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.HandleUpdate(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	ep := store.GetPodcast().Episodes[0]
+	if ep.Title != "Pilot" || ep.SeasonNum != 2 || !ep.PubDate.Equal(time.Date(2024, 3, 2, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected patched fields, got %+v", ep)
+	}
+	if ep.ID != "ep-1" || ep.GUID != "ep-1" || ep.Description != "The first one" || ep.EpisodeNum != 1 {
+		t.Errorf("Expected other fields unchanged, got %+v", ep)
+	}
+
+	tests := []struct {
+		name string
+		id   string
+		body string
+		code int
+	}{
+		{"empty title", "ep-1", `{"title": ""}`, http.StatusBadRequest},
+		{"bad explicit", "ep-1", `{"explicit": "maybe"}`, http.StatusBadRequest},
+		{"bad type", "ep-1", `{"episodeType": "teaser"}`, http.StatusBadRequest},
+		{"negative number", "ep-1", `{"episodeNum": -1}`, http.StatusBadRequest},
+		{"guid is read-only", "ep-1", `{"guid": "new"}`, http.StatusBadRequest},
+		{"unknown episode", "nope", `{"title": "x"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/episodes/"+tt.id, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			handler.HandleUpdate(rec, req)
+			if rec.Code != tt.code {
+				t.Errorf("Expected status %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+// The dashboard's edit panel posts form fields and gets the row back
+func TestUpdateEpisodeForm(t *testing.T) {
+	tmpl, err := template.ParseGlob("../../web/templates/components/*.html")
+	if err != nil {
+		t.Fatalf("Failed to parse templates: %v", err)
+	}
+
+	store := newEditStore()
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, tmpl)
+
+	form := url.Values{
+		"title":         {"Pilot"},
+		"description":   {"Fixed description"},
+		"pubDate":       {"2024-03-05T08:15"},
+		"episodeNumber": {""},
+		"explicit":      {"clean"},
+		"episodeType":   {"bonus"},
+	}
+	req := httptest.NewRequest(http.MethodPatch, "/api/episodes/ep-1", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	handler.HandleUpdate(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `class="episode-row"`) || !strings.Contains(rec.Body.String(), "Fixed description") {
+		t.Errorf("Expected the updated episode row, got %s", rec.Body.String())
+	}
+
+	ep := store.GetPodcast().Episodes[0]
+	if ep.Explicit != "clean" || ep.EpisodeType != "bonus" || ep.EpisodeNum != 0 || ep.PubDate.Day() != 5 {
+		t.Errorf("Unexpected episode after form update: %+v", ep)
+	}
+	if ep.GUID != "ep-1" {
+		t.Errorf("Expected GUID to be preserved, got %q", ep.GUID)
+	}
+}
+
+// People, location and license can be corrected after upload, with the
+// same validation as the upload form, and removed again
+func TestUpdateEpisodePodcastNamespace(t *testing.T) {
+	store := newEditStore()
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/episodes/ep-1", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		handler.HandleUpdate(rec, req)
+		return rec
+	}
+
+	rec := patch("application/json", `{
+		"persons": [{"name": " Ada ", "role": "Guest", "href": "https://ada.example.com"}, {"name": ""}],
+		"location": {"name": "Austin, TX", "geo": "geo:30.2672,97.7431"},
+		"license": {"name": "cc-by-4.0"}
+	}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	ep := store.GetPodcast().Episodes[0]
+	if len(ep.Persons) != 1 || ep.Persons[0].Name != "Ada" || ep.Persons[0].Role != "guest" {
+		t.Errorf("Expected the named person, normalized, got %+v", ep.Persons)
+	}
+	if ep.Location == nil || ep.Location.Geo != "geo:30.2672,97.7431" || ep.License == nil || ep.License.Name != "cc-by-4.0" {
+		t.Errorf("Expected location and license, got %+v, %+v", ep.Location, ep.License)
+	}
+	if ep.Description != "The first one" {
+		t.Errorf("Expected other fields unchanged, got %+v", ep)
+	}
+
+	for _, body := range []string{
+		`{"persons": [{"name": "Ada", "img": "ftp://ada"}]}`,
+		`{"location": {"name": "Austin", "geo": "30,97"}}`,
+		`{"license": {"url": "https://example.com/license"}}`,
+	} {
+		if rec := patch("application/json", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, rec.Code)
+		}
+	}
+	if ep := store.GetPodcast().Episodes[0]; len(ep.Persons) != 1 || ep.Location == nil {
+		t.Errorf("Expected rejected patches to change nothing, got %+v", ep)
+	}
+
+	// The edit panel clears them by emptying the names
+	form := url.Values{"personName": {""}, "personRole": {""}, "locationName": {""}, "licenseName": {""}}
+	if rec := patch("application/x-www-form-urlencoded", form.Encode()); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ep := store.GetPodcast().Episodes[0]; ep.Persons != nil || ep.Location != nil || ep.License != nil {
+		t.Errorf("Expected people, location and license removed, got %+v", ep)
+	}
+}
+
+func newReplaceAudioRequest(t *testing.T, id string, audio []byte) *http.Request {
+	t.Helper()
+
+	req := newUploadRequest(t, "remastered.mp3", audio, nil)
+	req.Method = http.MethodPut
+	req.URL.Path = "/api/episodes/" + id + "/audio"
+	return req
+}
+
+// Replacing the audio updates the enclosure, keeps the GUID and removes
+// the old file
+func TestReplaceEpisodeAudio(t *testing.T) {
+	store := newEditStore()
+	audio := newMemBlobStore()
+	audio.Put("pilot.mp3", strings.NewReader("old audio"))
+	ep := store.GetPodcast().Episodes[0]
+	ep.Filename, ep.AudioURL, ep.AudioLength, ep.Duration = "pilot.mp3", "/audio/pilot.mp3", 9, "00:00:01"
+	store.UpdateEpisode(ep)
+
+	handler := handlers.NewEpisodesHandler(store, audio, newMemBlobStore(), 10, nil, nil)
+	newAudio := buildMP3(100, 0)
+
+	rec := httptest.NewRecorder()
+	handler.HandleReplaceAudio(rec, newReplaceAudioRequest(t, "ep-1", newAudio))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	got := store.GetPodcast().Episodes[0]
+	if got.ID != "ep-1" || got.GUID != "ep-1" || got.Title != "Pilto" {
+		t.Errorf("Expected identity and metadata unchanged, got %+v", got)
+	}
+	if got.Filename == "pilot.mp3" || !audio.has(got.Filename) || got.AudioURL != "/audio/"+got.Filename {
+		t.Errorf("Expected the new file to be referenced, got %+v", got)
+	}
+	if got.AudioLength != int64(len(newAudio)) || got.AudioType != "audio/mpeg" || got.Duration == "00:00:01" {
+		t.Errorf("Expected recomputed enclosure, got length %d type %q duration %q", got.AudioLength, got.AudioType, got.Duration)
+	}
+	if audio.has("pilot.mp3") {
+		t.Error("Expected the old audio file to be deleted")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.HandleReplaceAudio(rec, newReplaceAudioRequest(t, "missing", newAudio))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+}
+
+// failingUpdateStore rejects every episode update
+type failingUpdateStore struct {
+	*memStore
+}
+
+func (s failingUpdateStore) UpdateEpisode(models.Episode) error {
+	return fmt.Errorf("disk full")
+}
+
+func (s failingUpdateStore) UpdateEpisodeFunc(string, func(*models.Episode) error) (*models.Episode, error) {
+	return nil, fmt.Errorf("disk full")
+}
+
+// When the store update fails the old file is kept and the new one removed
+func TestReplaceEpisodeAudioKeepsOldFileOnFailure(t *testing.T) {
+	store := newEditStore()
+	audio := newMemBlobStore()
+	audio.Put("pilot.mp3", strings.NewReader("old audio"))
+	ep := store.GetPodcast().Episodes[0]
+	ep.Filename = "pilot.mp3"
+	store.UpdateEpisode(ep)
+
+	handler := handlers.NewEpisodesHandler(failingUpdateStore{store}, audio, newMemBlobStore(), 10, nil, nil)
+
+	rec := httptest.NewRecorder()
+	handler.HandleReplaceAudio(rec, newReplaceAudioRequest(t, "ep-1", buildMP3(10, 0)))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !audio.has("pilot.mp3") || len(audio.blobs) != 1 {
+		t.Errorf("Expected only the old audio file to remain, got %v", audio.blobs)
+	}
+}
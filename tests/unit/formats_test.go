@@ -0,0 +1,205 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/media"
+)
+
+// buildFLAC builds a FLAC stream whose STREAMINFO declares the given length
+func buildFLAC(sampleRate int, totalSamples int64) []byte {
+	info := make([]byte, 34)
+	channels, bps := 2, 16
+	info[10] = byte(sampleRate >> 12)
+	info[11] = byte(sampleRate >> 4)
+	info[12] = byte(sampleRate&0x0F)<<4 | byte(channels-1)<<1 | byte(bps-1)>>4
+	info[13] = byte(bps-1)<<4 | byte(totalSamples>>32)
+	binary.BigEndian.PutUint32(info[14:], uint32(totalSamples))
+
+	data := []byte("fLaC")
+	data = append(data, 0x80, 0, 0, 34) // last block, STREAMINFO, length
+	data = append(data, info...)
+	return append(data, make([]byte, 4096)...)
+}
+
+// oggPage builds a single-packet Ogg page (packets must be under 255 bytes)
+func oggPage(serial uint32, granule int64, packet []byte) []byte {
+	page := []byte("OggS")
+	page = append(page, 0, 0)
+	page = binary.LittleEndian.AppendUint64(page, uint64(granule))
+	page = binary.LittleEndian.AppendUint32(page, serial)
+	page = append(page, 0, 0, 0, 0, 0, 0, 0, 0) // sequence, CRC
+	page = append(page, 1, byte(len(packet)))
+	return append(page, packet...)
+}
+
+// buildOgg builds an Ogg stream with an identification header, some audio
+// pages and a final page at the given granule position
+func buildOgg(header []byte, finalGranule int64) []byte {
+	const serial = 0x1234
+	var buf bytes.Buffer
+	buf.Write(oggPage(serial, 0, header))
+	for i := int64(1); i <= 10; i++ {
+		buf.Write(oggPage(serial, finalGranule*i/11, make([]byte, 200)))
+	}
+	buf.Write(oggPage(serial, finalGranule, make([]byte, 100)))
+	return buf.Bytes()
+}
+
+// opusHead builds an OpusHead identification packet
+func opusHead(preSkip uint16) []byte {
+	head := []byte("OpusHead")
+	head = append(head, 1, 2)
+	head = binary.LittleEndian.AppendUint16(head, preSkip)
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	return append(head, 0, 0, 0)
+}
+
+// vorbisHead builds a Vorbis identification packet
+func vorbisHead(sampleRate uint32) []byte {
+	head := []byte("\x01vorbis")
+	head = append(head, 0, 0, 0, 0, 2)
+	head = binary.LittleEndian.AppendUint32(head, sampleRate)
+	return append(head, make([]byte, 14)...)
+}
+
+// mp4Box builds an ISO base media box
+func mp4Box(kind string, body ...[]byte) []byte {
+	content := bytes.Join(body, nil)
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(content)))
+	return append(append(box, kind...), content...)
+}
+
+// mp4Header builds a version 0 mvhd/mdhd body
+func mp4Header(timescale, duration uint32) []byte {
+	body := make([]byte, 12)
+	body = binary.BigEndian.AppendUint32(body, timescale)
+	body = binary.BigEndian.AppendUint32(body, duration)
+	return append(body, make([]byte, 4)...)
+}
+
+// buildM4A builds an M4A file with one sound track of the given length
+func buildM4A(sampleRate uint32, seconds uint32) []byte {
+	hdlr := append(make([]byte, 8), "soun"...)
+	hdlr = append(hdlr, make([]byte, 13)...)
+
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00M4A isom")),
+		mp4Box("moov",
+			mp4Box("mvhd", mp4Header(1000, seconds*1000)),
+			mp4Box("trak",
+				mp4Box("mdia",
+					mp4Box("mdhd", mp4Header(sampleRate, seconds*sampleRate)),
+					mp4Box("hdlr", hdlr),
+				),
+			),
+		),
+		mp4Box("mdat", make([]byte, 4096)),
+	}, nil)
+}
+
+// buildADTS builds n 44.1 kHz AAC-LC frames of 200 bytes each
+func buildADTS(n int) []byte {
+	const frameLen = 200
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		frame := make([]byte, frameLen)
+		frame[0] = 0xFF
+		frame[1] = 0xF1
+		frame[2] = 0x40 | 4<<2 // AAC-LC, 44.1 kHz
+		frame[3] = 0x80 | byte(frameLen>>11)&0x03
+		frame[4] = byte(frameLen >> 3)
+		frame[5] = byte(frameLen&0x07)<<5 | 0x1F
+		frame[6] = 0xFC
+		buf.Write(frame)
+	}
+	return buf.Bytes()
+}
+
+// Each container is recognised by its magic bytes
+func TestDetectFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected *media.Format
+	}{
+		{"mp3", buildMP3(10, 0), media.MP3},
+		{"mp3 with ID3", append(id3v2Tag(300), buildMP3(10, 0)...), media.MP3},
+		{"aac", buildADTS(10), media.AAC},
+		{"m4a", buildM4A(44100, 12), media.M4A},
+		{"opus", buildOgg(opusHead(312), 48000), media.Opus},
+		{"vorbis", buildOgg(vorbisHead(44100), 44100), media.Vorbis},
+		{"flac", buildFLAC(44100, 441000), media.FLAC},
+		{"flac with ID3", append(id3v2Tag(100), buildFLAC(44100, 441000)...), media.FLAC},
+	}
+
+	for _, tt := range tests {
+		format, err := media.Detect(bytes.NewReader(tt.data))
+		if err != nil {
+			t.Errorf("%s: failed to detect format: %v", tt.name, err)
+			continue
+		}
+		if format != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected.Name, format.Name)
+		}
+	}
+}
+
+// Data that is not a supported container is rejected
+func TestDetectUnknownFormat(t *testing.T) {
+	data := bytes.Repeat([]byte("RIFF not audio "), 100)
+
+	if _, err := media.Detect(bytes.NewReader(data)); !errors.Is(err, media.ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+// Durations are read from each container's own metadata
+func TestProbeFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		format   *media.Format
+		expected time.Duration
+	}{
+		{"aac", buildADTS(100), media.AAC, 100 * 1024 * time.Second / 44100},
+		{"m4a", buildM4A(44100, 12), media.M4A, 12 * time.Second},
+		{"opus", buildOgg(opusHead(312), 312+5*48000), media.Opus, 5 * time.Second},
+		{"vorbis", buildOgg(vorbisHead(44100), 7*44100), media.Vorbis, 7 * time.Second},
+		{"flac", buildFLAC(44100, 441000), media.FLAC, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		info, err := tt.format.Probe(bytes.NewReader(tt.data), int64(len(tt.data)))
+		if err != nil {
+			t.Errorf("%s: failed to probe: %v", tt.name, err)
+			continue
+		}
+		if diff := info.Duration - tt.expected; diff < -time.Millisecond || diff > time.Millisecond {
+			t.Errorf("%s: expected duration %v, got %v", tt.name, tt.expected, info.Duration)
+		}
+		if info.Bitrate <= 0 {
+			t.Errorf("%s: expected a bitrate, got %d", tt.name, info.Bitrate)
+		}
+	}
+}
+
+// Extensions map to formats and MIME types case-insensitively
+func TestFormatForExtension(t *testing.T) {
+	if f := media.FormatForExtension(".M4A"); f != media.M4A {
+		t.Errorf("Expected .M4A to map to M4A, got %v", f)
+	}
+	if f := media.FormatForExtension(".wav"); f != nil {
+		t.Errorf("Expected .wav to be unsupported, got %s", f.Name)
+	}
+	if ct := media.ContentType("episode.opus"); ct != "audio/ogg" {
+		t.Errorf("Expected audio/ogg for .opus, got %s", ct)
+	}
+	if ct := media.ContentType("episode.flac"); ct != "audio/flac" {
+		t.Errorf("Expected audio/flac for .flac, got %s", ct)
+	}
+}
@@ -0,0 +1,742 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/storage"
+)
+
+// memStore is an in-memory storage.Store fake.
+// Methods not overridden here panic through the nil embedded interface.
+type memStore struct {
+	storage.Store
+
+	mu      sync.Mutex
+	podcast models.Podcast
+	trash   []models.TrashedEpisode
+}
+
+func newMemStore() *memStore {
+	return &memStore{podcast: *models.NewDefaultPodcast()}
+}
+
+func (s *memStore) GetPodcast() *models.Podcast {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.podcast
+	p.Episodes = append([]models.Episode(nil), s.podcast.Episodes...)
+	return &p
+}
+
+func (s *memStore) AddEpisode(ep models.Episode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.podcast.Episodes = append(s.podcast.Episodes, ep)
+	return nil
+}
+
+func (s *memStore) UpdatePodcast(p *models.Podcast) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.podcast = *p
+	return nil
+}
+
+func (s *memStore) UpdateEpisode(ep models.Episode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.podcast.Episodes {
+		if s.podcast.Episodes[i].ID == ep.ID {
+			ep.GUID = s.podcast.Episodes[i].GUID
+			s.podcast.Episodes[i] = ep
+			return nil
+		}
+	}
+	return fmt.Errorf("episode not found: %s", ep.ID)
+}
+
+func (s *memStore) UpdateEpisodeFunc(episodeID string, update func(*models.Episode) error) (*models.Episode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, ep := range s.podcast.Episodes {
+		if ep.ID == episodeID {
+			if err := update(&ep); err != nil {
+				return nil, err
+			}
+			ep.ID, ep.GUID = episodeID, s.podcast.Episodes[i].GUID
+			s.podcast.Episodes[i] = ep
+			return &ep, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", storage.ErrEpisodeNotFound, episodeID)
+}
+
+func (s *memStore) PublishIfDue(episodeID string, now time.Time) (*models.Episode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, ep := range s.podcast.Episodes {
+		if ep.ID == episodeID && ep.Scheduled() && !ep.PubDate.After(now) {
+			s.podcast.Episodes[i].Status = models.StatusPublished
+			ep.Status = models.StatusPublished
+			return &ep, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memStore) DeleteEpisode(episodeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, ep := range s.podcast.Episodes {
+		if ep.ID == episodeID {
+			s.podcast.Episodes = append(s.podcast.Episodes[:i], s.podcast.Episodes[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("episode not found: %s", episodeID)
+}
+
+func (s *memStore) TrashEpisode(episodeID string, deletedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, ep := range s.podcast.Episodes {
+		if ep.ID == episodeID {
+			s.podcast.Episodes = append(s.podcast.Episodes[:i], s.podcast.Episodes[i+1:]...)
+			s.trash = append([]models.TrashedEpisode{{Episode: ep, DeletedAt: deletedAt}}, s.trash...)
+			return nil
+		}
+	}
+	return fmt.Errorf("episode not found: %s", episodeID)
+}
+
+func (s *memStore) GetTrash() []models.TrashedEpisode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.TrashedEpisode(nil), s.trash...)
+}
+
+func (s *memStore) RestoreEpisode(episodeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, t := range s.trash {
+		if t.ID == episodeID {
+			s.trash = append(s.trash[:i], s.trash[i+1:]...)
+			s.podcast.Episodes = append(s.podcast.Episodes, t.Episode)
+			return nil
+		}
+	}
+	return fmt.Errorf("episode not found in trash: %s", episodeID)
+}
+
+func (s *memStore) PurgeEpisode(episodeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, t := range s.trash {
+		if t.ID == episodeID {
+			s.trash = append(s.trash[:i], s.trash[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("episode not found in trash: %s", episodeID)
+}
+
+// memBlobStore is an in-memory storage.BlobStore fake
+type memBlobStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func newMemBlobStore() *memBlobStore {
+	return &memBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (s *memBlobStore) Put(name string, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[name] = data
+	return int64(len(data)), nil
+}
+
+func (s *memBlobStore) Open(name string) (storage.Blob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.blobs[name]
+	if !ok {
+		return nil, storage.ErrBlobNotFound
+	}
+	return &memBlob{Reader: bytes.NewReader(data)}, nil
+}
+
+func (s *memBlobStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.blobs[name]; !ok {
+		return storage.ErrBlobNotFound
+	}
+	delete(s.blobs, name)
+	return nil
+}
+
+func (s *memBlobStore) URL(name string) string {
+	return "/audio/" + name
+}
+
+func (s *memBlobStore) has(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.blobs[name]
+	return ok
+}
+
+type memBlob struct {
+	*bytes.Reader
+}
+
+func (b *memBlob) Close() error       { return nil }
+func (b *memBlob) ModTime() time.Time { return time.Time{} }
+
+// newUploadRequest builds a multipart episode upload request
+func newUploadRequest(t *testing.T, filename string, audio []byte, fields map[string]string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			t.Fatalf("Failed to write field: %v", err)
+		}
+	}
+	part, err := mw.CreateFormFile("audio", filename)
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(audio)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/episodes", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// Upload stores the audio blob and adds the episode through the Store interface
+func TestUploadWithInMemoryStores(t *testing.T) {
+	store := newMemStore()
+	audio := newMemBlobStore()
+	handler := handlers.NewEpisodesHandler(store, audio, newMemBlobStore(), 10, nil, nil)
+
+	req := newUploadRequest(t, "episode.mp3", buildMP3(10, 0), map[string]string{
+		"title":       "Test Episode",
+		"description": "A test episode",
+	})
+	rec := httptest.NewRecorder()
+	handler.HandleUpload(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var episode models.Episode
+	if err := json.NewDecoder(rec.Body).Decode(&episode); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if !audio.has(episode.Filename) {
+		t.Errorf("Expected audio blob %q to be stored", episode.Filename)
+	}
+	if episode.AudioURL != "/audio/"+episode.Filename {
+		t.Errorf("Expected audio URL from blob store, got %q", episode.AudioURL)
+	}
+	if len(store.GetPodcast().Episodes) != 1 {
+		t.Errorf("Expected 1 episode in store, got %d", len(store.GetPodcast().Episodes))
+	}
+}
+
+// Blank form fields are prefilled from the MP3's ID3 tags
+func TestUploadPrefillsFromID3Tags(t *testing.T) {
+	store := newMemStore()
+	artwork := newMemBlobStore()
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), artwork, 10, nil, nil)
+
+	tag := id3v23(
+		id3Frame("TIT2", []byte("\x00Tagged Title")),
+		id3Frame("COMM", []byte("\x00eng\x00Tagged description")),
+		id3Frame("TRCK", []byte("\x0012")),
+		id3Frame("APIC", []byte("\x00image/png\x00\x03\x00PNGDATA")),
+	)
+	audio := append(tag, buildMP3(10, 0)...)
+
+	req := newUploadRequest(t, "tagged.mp3", audio, map[string]string{})
+	rec := httptest.NewRecorder()
+	handler.HandleUpload(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var episode models.Episode
+	if err := json.NewDecoder(rec.Body).Decode(&episode); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if episode.Title != "Tagged Title" || episode.Description != "Tagged description" {
+		t.Errorf("Expected title/description from tags, got %q / %q", episode.Title, episode.Description)
+	}
+	if episode.EpisodeNum != 12 {
+		t.Errorf("Expected episode number 12 from track tag, got %d", episode.EpisodeNum)
+	}
+	if episode.ImageURL == "" {
+		t.Fatal("Expected episode artwork from embedded picture")
+	}
+	if len(artwork.blobs) != 1 {
+		t.Errorf("Expected 1 artwork blob, got %d", len(artwork.blobs))
+	}
+	if episode.Duration == "" {
+		t.Error("Expected duration to be computed")
+	}
+}
+
+// failingAddStore rejects every new episode
+type failingAddStore struct {
+	*memStore
+}
+
+func (s failingAddStore) AddEpisode(models.Episode) error {
+	return fmt.Errorf("disk full")
+}
+
+// When the episode cannot be added, its audio and artwork blobs are removed
+func TestUploadCleansUpWhenAddFails(t *testing.T) {
+	audio := newMemBlobStore()
+	artwork := newMemBlobStore()
+	handler := handlers.NewEpisodesHandler(failingAddStore{newMemStore()}, audio, artwork, 10, nil, nil)
+
+	tag := id3v23(id3Frame("APIC", []byte("\x00image/png\x00\x03\x00PNGDATA")))
+	req := newUploadRequest(t, "tagged.mp3", append(tag, buildMP3(10, 0)...), map[string]string{
+		"title":       "Test Episode",
+		"description": "A test episode",
+	})
+	rec := httptest.NewRecorder()
+	handler.HandleUpload(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(audio.blobs) != 0 || len(artwork.blobs) != 0 {
+		t.Errorf("Expected no blobs left behind, got %d audio and %d artwork", len(audio.blobs), len(artwork.blobs))
+	}
+}
+
+// Non-MP3 uploads store the sniffed MIME type and duration
+func TestUploadFLACEpisode(t *testing.T) {
+	audio := newMemBlobStore()
+	handler := handlers.NewEpisodesHandler(newMemStore(), audio, newMemBlobStore(), 10, nil, nil)
+
+	req := newUploadRequest(t, "episode.flac", buildFLAC(44100, 441000), map[string]string{
+		"title":       "Lossless",
+		"description": "A FLAC episode",
+	})
+	rec := httptest.NewRecorder()
+	handler.HandleUpload(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var episode models.Episode
+	if err := json.NewDecoder(rec.Body).Decode(&episode); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if episode.AudioType != "audio/flac" {
+		t.Errorf("Expected audio type audio/flac, got %s", episode.AudioType)
+	}
+	if episode.Duration != "00:00:10" {
+		t.Errorf("Expected duration 00:00:10, got %s", episode.Duration)
+	}
+}
+
+// The sniffed format wins over a misleading extension
+func TestUploadMislabelledAudio(t *testing.T) {
+	audio := newMemBlobStore()
+	handler := handlers.NewEpisodesHandler(newMemStore(), audio, newMemBlobStore(), 10, nil, nil)
+
+	req := newUploadRequest(t, "episode.mp3", buildM4A(44100, 12), map[string]string{
+		"title":       "Mislabelled",
+		"description": "Really an M4A",
+	})
+	rec := httptest.NewRecorder()
+	handler.HandleUpload(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var episode models.Episode
+	if err := json.NewDecoder(rec.Body).Decode(&episode); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if episode.AudioType != "audio/x-m4a" {
+		t.Errorf("Expected audio type audio/x-m4a, got %s", episode.AudioType)
+	}
+	if !strings.HasSuffix(episode.Filename, ".m4a") {
+		t.Errorf("Expected stored filename with .m4a extension, got %s", episode.Filename)
+	}
+}
+
+// Uploads outside the configured extensions are rejected, including files
+// whose contents are a disallowed format
+func TestUploadRejectsDisallowedFormats(t *testing.T) {
+	tests := map[string][]byte{
+		"episode.flac": buildFLAC(44100, 441000),
+		"episode.mp3":  buildFLAC(44100, 441000),
+	}
+
+	for filename, data := range tests {
+		audio := newMemBlobStore()
+		handler := handlers.NewEpisodesHandler(newMemStore(), audio, newMemBlobStore(), 10, []string{".mp3"}, nil)
+
+		req := newUploadRequest(t, filename, data, map[string]string{
+			"title":       "Rejected",
+			"description": "Not allowed",
+		})
+		rec := httptest.NewRecorder()
+		handler.HandleUpload(rec, req)
+
+		if rec.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("%s: expected status 415, got %d", filename, rec.Code)
+		}
+		if len(audio.blobs) != 0 {
+			t.Errorf("%s: expected no audio to be stored", filename)
+		}
+	}
+}
+
+// Streams that are not recognised audio are rejected whatever their name
+func TestUploadRejectsUnrecognisedAudio(t *testing.T) {
+	for _, filename := range []string{"episode.mp3", "episode.m4a"} {
+		store := newMemStore()
+		audio := newMemBlobStore()
+		handler := handlers.NewEpisodesHandler(store, audio, newMemBlobStore(), 10, nil, nil)
+
+		req := newUploadRequest(t, filename, []byte("not really audio"), map[string]string{
+			"title":       "Rejected",
+			"description": "Not audio",
+		})
+		rec := httptest.NewRecorder()
+		handler.HandleUpload(rec, req)
+
+		if rec.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("%s: expected status 415, got %d", filename, rec.Code)
+		}
+		if len(audio.blobs) != 0 || len(store.GetPodcast().Episodes) != 0 {
+			t.Errorf("%s: expected nothing to be stored", filename)
+		}
+	}
+}
+
+// Audio is served with the content type of its format
+func TestServeAudioContentType(t *testing.T) {
+	audio := newMemBlobStore()
+	audio.Put("episode.opus", bytes.NewReader([]byte("data")))
+	handler := handlers.NewStaticHandler(newMemStore(), audio, newMemBlobStore())
+
+	req := httptest.NewRequest(http.MethodGet, "/audio/episode.opus", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleAudio(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "audio/ogg" {
+		t.Errorf("Expected Content-Type audio/ogg, got %s", ct)
+	}
+}
+
+// newSettingsRequest builds a settings form post with the required fields
+// filled in and overrides applied
+func newSettingsRequest(t *testing.T, overrides map[string]string) *http.Request {
+	t.Helper()
+
+	fields := map[string]string{
+		"title":       "My Podcast",
+		"link":        "https://example.com",
+		"description": "About things",
+		"language":    "en-us",
+		"author":      "Jane Doe",
+		"category":    "Technology",
+	}
+	for k, v := range overrides {
+		fields[k] = v
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			t.Fatalf("Failed to write field: %v", err)
+		}
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/podcast/settings", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// Owner, type, listing flags and subcategory are saved from the settings form
+func TestUpdateSettingsDirectoryMetadata(t *testing.T) {
+	store := newMemStore()
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+
+	rec := httptest.NewRecorder()
+	handler.HandleUpdateSettings(rec, newSettingsRequest(t, map[string]string{
+		"category":    "Science",
+		"subcategory": "Astronomy",
+		"ownerName":   "Jane Doe",
+		"ownerEmail":  "jane@example.com",
+		"type":        "serial",
+		"copyright":   "© 2024 Jane Doe",
+		"block":       "yes",
+		"complete":    "yes",
+		"newFeedURL":  "https://new.example.com/feed.xml",
+	}))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	p := store.GetPodcast()
+	if p.Subcategory != "Astronomy" || p.OwnerName != "Jane Doe" || p.OwnerEmail != "jane@example.com" {
+		t.Errorf("Expected subcategory and owner to be saved, got %+v", p)
+	}
+	if p.Type != "serial" || p.Copyright != "© 2024 Jane Doe" || p.NewFeedURL != "https://new.example.com/feed.xml" {
+		t.Errorf("Expected type, copyright and new feed URL to be saved, got %+v", p)
+	}
+	if !p.Block || !p.Complete {
+		t.Errorf("Expected block and complete to be set, got block=%v complete=%v", p.Block, p.Complete)
+	}
+}
+
+// Podcasting 2.0 fields are saved from the settings form, including
+// repeated rows, and an existing GUID is kept when the field is blank
+func TestUpdateSettingsPodcastNamespace(t *testing.T) {
+	store := newMemStore()
+	p := store.GetPodcast()
+	p.GUID = "917393e3-1b1e-5cef-ace4-edaa54e1f810"
+	store.UpdatePodcast(p)
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+
+	req := newSettingsRequest(t, map[string]string{
+		"locked":         "yes",
+		"locationName":   "Austin, TX",
+		"locationGeo":    "geo:30.2672,97.7431",
+		"licenseName":    "cc-by-4.0",
+		"trailerTitle":   "Coming soon",
+		"trailerURL":     "https://example.com/trailer.mp3",
+		"trailerPubDate": "2024-03-01T09:00",
+	})
+	// Two funding rows, the second left blank as the form renders it
+	req.ParseMultipartForm(1 << 20)
+	req.Form["fundingURL"] = []string{"https://example.com/donate", ""}
+	req.Form["fundingLabel"] = []string{"Support us", ""}
+	req.Form["personName"] = []string{"Jane Doe", "Sam Guest"}
+	req.Form["personRole"] = []string{"Host", "guest"}
+
+	rec := httptest.NewRecorder()
+	handler.HandleUpdateSettings(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	p = store.GetPodcast()
+	if p.GUID != "917393e3-1b1e-5cef-ace4-edaa54e1f810" {
+		t.Errorf("Expected GUID to be kept, got %q", p.GUID)
+	}
+	if !p.Locked {
+		t.Error("Expected feed to be locked")
+	}
+	if len(p.Funding) != 1 || p.Funding[0].Label != "Support us" {
+		t.Errorf("Expected one funding link, got %+v", p.Funding)
+	}
+	if len(p.Persons) != 2 || p.Persons[0].Role != "host" || p.Persons[1].Name != "Sam Guest" {
+		t.Errorf("Expected two people, got %+v", p.Persons)
+	}
+	if p.Location == nil || p.Location.Geo != "geo:30.2672,97.7431" {
+		t.Errorf("Expected location, got %+v", p.Location)
+	}
+	if len(p.Trailers) != 1 || p.Trailers[0].Type != "audio/mpeg" || p.Trailers[0].PubDate.IsZero() {
+		t.Errorf("Expected trailer with type from its extension, got %+v", p.Trailers)
+	}
+	if p.License == nil || p.License.Name != "cc-by-4.0" {
+		t.Errorf("Expected license, got %+v", p.License)
+	}
+}
+
+// Episode uploads accept a guest, location and license
+func TestUploadPodcastNamespace(t *testing.T) {
+	store := newMemStore()
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+
+	req := newUploadRequest(t, "episode.mp3", buildMP3(10, 0), map[string]string{
+		"title":        "Interview",
+		"description":  "With a guest",
+		"personName":   "Sam Guest",
+		"personRole":   "guest",
+		"locationName": "Mars",
+		"licenseName":  "cc-by-4.0",
+	})
+	rec := httptest.NewRecorder()
+	handler.HandleUpload(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	ep := store.GetPodcast().Episodes[0]
+	if len(ep.Persons) != 1 || ep.Persons[0].Role != "guest" {
+		t.Errorf("Expected guest, got %+v", ep.Persons)
+	}
+	if ep.Location == nil || ep.Location.Name != "Mars" || ep.License == nil {
+		t.Errorf("Expected location and license, got %+v, %+v", ep.Location, ep.License)
+	}
+
+	req = newUploadRequest(t, "episode.mp3", buildMP3(10, 0), map[string]string{
+		"title":       "Interview",
+		"description": "With a guest",
+		"personName":  "Sam Guest",
+		"personHref":  "not a url",
+	})
+	rec = httptest.NewRecorder()
+	handler.HandleUpload(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid person URL, got %d", rec.Code)
+	}
+}
+
+// Invalid directory metadata is rejected
+func TestUpdateSettingsValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]string
+	}{
+		{"subcategory of another category", map[string]string{"category": "Technology", "subcategory": "Astronomy"}},
+		{"unknown type", map[string]string{"type": "anthology"}},
+		{"invalid owner email", map[string]string{"ownerEmail": "not an email"}},
+		{"owner email with display name", map[string]string{"ownerEmail": "Jane <jane@example.com>"}},
+		{"non-HTTP new feed URL", map[string]string{"newFeedURL": "ftp://example.com/feed.xml"}},
+		{"malformed GUID", map[string]string{"guid": "not-a-uuid"}},
+		{"relative funding URL", map[string]string{"fundingURL": "/donate"}},
+		{"malformed geo URI", map[string]string{"locationName": "Austin", "locationGeo": "30.2,97.7"}},
+		{"location without name", map[string]string{"locationOSM": "R113314"}},
+		{"trailer without date", map[string]string{"trailerTitle": "Soon", "trailerURL": "https://example.com/t.mp3"}},
+		{"overlong license", map[string]string{"licenseName": strings.Repeat("x", 129)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+
+			rec := httptest.NewRecorder()
+			handler.HandleUpdateSettings(rec, newSettingsRequest(t, tt.fields))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+// The settings form renders the category taxonomy and current values
+func TestSettingsFormRendersDirectoryFields(t *testing.T) {
+	tmpl, err := template.ParseGlob("../../web/templates/components/*.html")
+	if err != nil {
+		t.Fatalf("Failed to parse templates: %v", err)
+	}
+
+	store := newMemStore()
+	p := store.GetPodcast()
+	p.Category = "Science"
+	p.Subcategory = "Astronomy"
+	p.OwnerEmail = "jane@example.com"
+	p.Type = "serial"
+	store.UpdatePodcast(p)
+
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, tmpl)
+	rec := httptest.NewRecorder()
+	handler.HandleGetSettings(rec, httptest.NewRequest(http.MethodGet, "/api/podcast/settings", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<option value="Science" selected>`,
+		`<option value="Astronomy" selected>`,
+		`<option value="serial" selected>`,
+		`value="jane@example.com"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected settings form to contain %s", want)
+		}
+	}
+}
+
+// The settings form of another show posts to the show's routes, and saving
+// links back to the show's dashboard
+func TestSettingsFormLinksToShow(t *testing.T) {
+	tmpl, err := template.ParseGlob("../../web/templates/components/*.html")
+	if err != nil {
+		t.Fatalf("Failed to parse templates: %v", err)
+	}
+
+	handler := handlers.NewEpisodesHandler(newMemStore(), newMemBlobStore(), newMemBlobStore(), 10, nil, tmpl)
+	handler.SetShow("cooking")
+
+	rec := httptest.NewRecorder()
+	handler.HandleGetSettings(rec, httptest.NewRequest(http.MethodGet, "/api/shows/cooking/podcast/settings", nil))
+	body := rec.Body.String()
+	for _, want := range []string{`hx-post="/api/shows/cooking/podcast/settings"`, `href="/shows/cooking/"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected settings form to contain %s", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.HandleUpdateSettings(rec, newSettingsRequest(t, map[string]string{"category": "Science"}))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `<a href="/shows/cooking/">`) {
+		t.Errorf("Expected the success message to link to the show's dashboard, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
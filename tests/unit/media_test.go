@@ -0,0 +1,259 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/media"
+)
+
+// MPEG-1 Layer III, 128 kbps, 44.1 kHz, stereo, no padding: 417-byte frames
+var cbrHeader = []byte{0xFF, 0xFB, 0x90, 0x00}
+
+const cbrFrameSize = 417
+
+// buildMP3 builds a CBR stream of n silent frames; when xingFrames > 0 the
+// first frame carries a Xing header claiming that many frames
+func buildMP3(n int, xingFrames uint32) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		frame := make([]byte, cbrFrameSize)
+		copy(frame, cbrHeader)
+		if i == 0 && xingFrames > 0 {
+			// Xing header starts after the 32-byte stereo side info
+			copy(frame[36:], "Xing")
+			binary.BigEndian.PutUint32(frame[40:], 0x1) // frames field present
+			binary.BigEndian.PutUint32(frame[44:], xingFrames)
+		}
+		buf.Write(frame)
+	}
+	return buf.Bytes()
+}
+
+// id3v2Tag returns an empty ID3v2.4 tag with the given body size
+func id3v2Tag(size int) []byte {
+	tag := []byte{'I', 'D', '3', 4, 0, 0,
+		byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(tag, make([]byte, size)...)
+}
+
+// CBR files without a VBR header are measured by counting frames
+func TestProbeMP3FrameCounting(t *testing.T) {
+	data := append(id3v2Tag(300), buildMP3(1000, 0)...)
+
+	info, err := media.ProbeMP3(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to probe MP3: %v", err)
+	}
+
+	// 1000 frames * 1152 samples / 44100 Hz = 26.12s
+	expected := 1000 * 1152 * time.Second / 44100
+	if diff := info.Duration - expected; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("Expected duration %v, got %v", expected, info.Duration)
+	}
+	if info.Bitrate < 127 || info.Bitrate > 129 {
+		t.Errorf("Expected bitrate ~128 kbps, got %d", info.Bitrate)
+	}
+	if info.SampleRate != 44100 {
+		t.Errorf("Expected sample rate 44100, got %d", info.SampleRate)
+	}
+}
+
+// VBR files use the frame count from the Xing header
+func TestProbeMP3XingHeader(t *testing.T) {
+	data := buildMP3(10, 50000)
+
+	info, err := media.ProbeMP3(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to probe MP3: %v", err)
+	}
+
+	// 50000 frames * 1152 / 44100 = 1306.12s
+	if got := media.FormatDuration(info.Duration); got != "00:21:46" {
+		t.Errorf("Expected duration 00:21:46 from Xing header, got %s", got)
+	}
+}
+
+// Data without MPEG frames is rejected
+func TestProbeMP3NoFrames(t *testing.T) {
+	data := bytes.Repeat([]byte("not audio "), 100)
+
+	if _, err := media.ProbeMP3(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("Expected error for data without MP3 frames")
+	}
+}
+
+// Durations are formatted as HH:MM:SS
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                                     "00:00:00",
+		59*time.Second + 600*time.Millisecond: "00:01:00",
+		2*time.Hour + 3*time.Minute + 4*time.Second: "02:03:04",
+	}
+
+	for d, expected := range tests {
+		if got := media.FormatDuration(d); got != expected {
+			t.Errorf("FormatDuration(%v) = %s, expected %s", d, got, expected)
+		}
+	}
+}
+
+// id3Frame builds an ID3v2.3 frame
+func id3Frame(id string, data []byte) []byte {
+	frame := []byte(id)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(data)))
+	frame = append(frame, 0, 0)
+	return append(frame, data...)
+}
+
+// id3v23 builds an ID3v2.3 tag from frames
+func id3v23(frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	size := len(body)
+	tag := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(tag, body...)
+}
+
+// utf16Text encodes s as an ID3 UTF-16 text frame body with a BOM
+func utf16Text(s string) []byte {
+	data := []byte{1, 0xFF, 0xFE}
+	for _, r := range s {
+		data = append(data, byte(r), byte(r>>8))
+	}
+	return data
+}
+
+// ID3v2.3 text, comment, track, year and picture frames are read
+func TestReadTagsID3v2(t *testing.T) {
+	picture := []byte{0xFF, 0xD8, 0xFF, 0xE0, 1, 2, 3}
+	tag := id3v23(
+		id3Frame("TIT2", utf16Text("Pilot Episode")),
+		id3Frame("COMM", append([]byte("\x00eng\x00"), "Show notes here"...)),
+		id3Frame("TRCK", []byte("\x007/12")),
+		id3Frame("TYER", []byte("\x002024")),
+		id3Frame("APIC", append([]byte("\x00image/jpeg\x00\x03cover\x00"), picture...)),
+	)
+	data := append(tag, buildMP3(10, 0)...)
+
+	tags, err := media.ReadTags(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to read tags: %v", err)
+	}
+
+	if tags.Title != "Pilot Episode" {
+		t.Errorf("Expected title 'Pilot Episode', got %q", tags.Title)
+	}
+	if tags.Comment != "Show notes here" {
+		t.Errorf("Expected comment 'Show notes here', got %q", tags.Comment)
+	}
+	if tags.Track != 7 {
+		t.Errorf("Expected track 7, got %d", tags.Track)
+	}
+	if tags.Year != "2024" {
+		t.Errorf("Expected year 2024, got %q", tags.Year)
+	}
+	if tags.Picture == nil || !bytes.Equal(tags.Picture.Data, picture) {
+		t.Fatalf("Expected embedded picture, got %+v", tags.Picture)
+	}
+	if tags.Picture.Ext() != ".jpg" {
+		t.Errorf("Expected .jpg extension, got %s", tags.Picture.Ext())
+	}
+}
+
+// ID3v1.1 tags fill fields missing from ID3v2
+func TestReadTagsID3v1Fallback(t *testing.T) {
+	v1 := make([]byte, 128)
+	copy(v1, "TAG")
+	copy(v1[3:], "Old Title")
+	copy(v1[93:], "1999")
+	copy(v1[97:], "Old comment")
+	v1[126] = 4 // track number (ID3v1.1)
+
+	data := append(buildMP3(10, 0), v1...)
+
+	tags, err := media.ReadTags(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to read tags: %v", err)
+	}
+
+	if tags.Title != "Old Title" || tags.Comment != "Old comment" || tags.Track != 4 || tags.Year != "1999" {
+		t.Errorf("Unexpected ID3v1 tags: %+v", tags)
+	}
+}
+
+// chapFrame builds a CHAP frame with embedded frames
+func chapFrame(id string, startMS, endMS uint32, sub ...[]byte) []byte {
+	data := append([]byte(id), 0)
+	data = binary.BigEndian.AppendUint32(data, startMS)
+	data = binary.BigEndian.AppendUint32(data, endMS)
+	data = append(data, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF) // offsets unused
+	return id3Frame("CHAP", append(data, bytes.Join(sub, nil)...))
+}
+
+// ctocFrame builds a top-level, ordered CTOC frame
+func ctocFrame(id string, children ...string) []byte {
+	data := append([]byte(id), 0, 0x03, byte(len(children)))
+	for _, c := range children {
+		data = append(append(data, c...), 0)
+	}
+	return id3Frame("CTOC", data)
+}
+
+// CHAP frames referenced by the top-level CTOC become chapters, with their
+// embedded title, link and image, ordered by start time
+func TestReadTagsChapters(t *testing.T) {
+	image := []byte{0x89, 'P', 'N', 'G', 1, 2, 3}
+	tag := id3v23(
+		id3Frame("TIT2", []byte("\x00Episode")),
+		chapFrame("ch2", 90000, 180000,
+			id3Frame("TIT2", utf16Text("Main Topic")),
+			id3Frame("WXXX", []byte("\x00link\x00https://example.com/topic")),
+		),
+		chapFrame("ch1", 0, 90000,
+			id3Frame("TIT2", []byte("\x00Intro")),
+			id3Frame("APIC", append([]byte("\x00image/png\x00\x00\x00"), image...)),
+		),
+		chapFrame("orphan", 200000, 210000, id3Frame("TIT2", []byte("\x00Not in TOC"))),
+		ctocFrame("toc", "ch2", "ch1"),
+	)
+	data := append(tag, buildMP3(10, 0)...)
+
+	tags, err := media.ReadTags(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to read tags: %v", err)
+	}
+
+	if len(tags.Chapters) != 2 {
+		t.Fatalf("Expected 2 chapters from the table of contents, got %+v", tags.Chapters)
+	}
+	intro, topic := tags.Chapters[0], tags.Chapters[1]
+	if intro.Title != "Intro" || intro.Start != 0 || intro.End != 90*time.Second {
+		t.Errorf("Unexpected first chapter: %+v", intro)
+	}
+	if intro.Image == nil || !bytes.Equal(intro.Image.Data, image) || intro.Image.Ext() != ".png" {
+		t.Errorf("Expected chapter image, got %+v", intro.Image)
+	}
+	if topic.Title != "Main Topic" || topic.Start != 90*time.Second || topic.URL != "https://example.com/topic" {
+		t.Errorf("Unexpected second chapter: %+v", topic)
+	}
+}
+
+// Without a table of contents every CHAP frame is a chapter
+func TestReadTagsChaptersWithoutTOC(t *testing.T) {
+	tag := id3v23(
+		chapFrame("b", 5000, 0, id3Frame("TIT2", []byte("\x00Second"))),
+		chapFrame("a", 0, 0, id3Frame("TIT2", []byte("\x00First"))),
+	)
+
+	tags, err := media.ReadTags(bytes.NewReader(tag), int64(len(tag)))
+	if err != nil {
+		t.Fatalf("Failed to read tags: %v", err)
+	}
+
+	if len(tags.Chapters) != 2 || tags.Chapters[0].Title != "First" || tags.Chapters[1].Start != 5*time.Second {
+		t.Errorf("Unexpected chapters: %+v", tags.Chapters)
+	}
+}
@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/rss"
+)
+
+const previewToken = "preview-0123456789"
+
+func newDraftStore() *memStore {
+	store := newMemStore()
+	pubDate := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	store.AddEpisode(models.Episode{ID: "live", Title: "Live Episode", Description: "Out", PubDate: pubDate, AudioURL: "/audio/live.mp3", Status: models.StatusPublished})
+	store.AddEpisode(models.Episode{ID: "wip", Title: "Draft Episode", Description: "In review", PubDate: pubDate, AudioURL: "/audio/wip.mp3", Status: models.StatusDraft})
+	return store
+}
+
+// The preview feed includes drafts and requires the token
+func TestPreviewFeed(t *testing.T) {
+	store := newDraftStore()
+	handler := handlers.NewPreviewFeedHandler(store, "http://example.com", previewToken)
+
+	get := func(target, bearer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		rec := httptest.NewRecorder()
+		handler.HandlePreviewFeed(rec, req)
+		return rec
+	}
+
+	rec := get("/feed-preview.xml?token="+previewToken, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"Live Episode", "Draft Episode", "<itunes:block>Yes</itunes:block>", "[Preview]"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected preview feed to contain %q", want)
+		}
+	}
+	if cc := rec.Header().Get("Cache-Control"); !strings.Contains(cc, "no-store") {
+		t.Errorf("Expected the preview feed not to be cached, got %q", cc)
+	}
+
+	if rec := get("/feed-preview.xml", previewToken); rec.Code != http.StatusOK {
+		t.Errorf("Expected bearer token to be accepted, got %d", rec.Code)
+	}
+	for _, target := range []string{"/feed-preview.xml", "/feed-preview.xml?token=wrong"} {
+		if rec := get(target, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for %s, got %d", target, rec.Code)
+		}
+	}
+
+	disabled := handlers.NewPreviewFeedHandler(store, "http://example.com", "")
+	rec = httptest.NewRecorder()
+	disabled.HandlePreviewFeed(rec, httptest.NewRequest(http.MethodGet, "/feed-preview.xml?token=", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without a configured token, got %d", rec.Code)
+	}
+}
+
+// The preview feed links the files of unreleased episodes with the token,
+// and only requests carrying it are served them
+func TestPreviewFeedFiles(t *testing.T) {
+	store := newDraftStore()
+	wip := store.GetPodcast().Episodes[1]
+	wip.Filename = "wip.mp3"
+	wip.Chapters = []models.Chapter{{StartTime: 0, Title: "Intro"}}
+	wip.Transcript = &models.Transcript{Filename: "wip.srt", Format: "srt"}
+	store.UpdateEpisode(wip)
+
+	feed, err := rss.GeneratePreviewFeed(store.GetPodcast(), "http://example.com", previewToken)
+	if err != nil {
+		t.Fatalf("Failed to generate preview feed: %v", err)
+	}
+	for _, want := range []string{
+		`url="http://example.com/audio/wip.mp3?token=` + previewToken + `"`,
+		`url="http://example.com/episodes/wip/chapters.json?token=` + previewToken + `"`,
+		`url="http://example.com/episodes/wip/transcript.srt?token=` + previewToken + `"`,
+		`url="http://example.com/audio/live.mp3"`,
+	} {
+		if !strings.Contains(string(feed), want) {
+			t.Errorf("Expected preview feed to contain %s", want)
+		}
+	}
+
+	audio := newMemBlobStore()
+	for _, name := range []string{"wip.mp3", "wip.srt"} {
+		audio.Put(name, strings.NewReader("data"))
+	}
+	handler := handlers.NewStaticHandler(store, audio, newMemBlobStore())
+	handler.SetPreviewToken(previewToken)
+	serve := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.HandleAudio(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+	for _, name := range []string{"wip.mp3", "wip.srt"} {
+		if rec := serve("/audio/" + name); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected the draft's %s to be hidden, got %d", name, rec.Code)
+		}
+		rec := serve("/audio/" + name + "?token=" + previewToken)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Cache-Control"), "no-store") {
+			t.Errorf("Expected the draft's %s to be served uncached with the token, got %d %q", name, rec.Code, rec.Header().Get("Cache-Control"))
+		}
+	}
+}
+
+// Drafts are published from the dashboard and only then reach the feed
+func TestPublishDraft(t *testing.T) {
+	store := newDraftStore()
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+
+	feed, _ := rss.GenerateFeed(store.GetPodcast(), "http://example.com")
+	if strings.Contains(string(feed), "Draft Episode") {
+		t.Fatal("Expected the draft to be left out of the public feed")
+	}
+
+	publish := func(id string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.HandlePublish(rec, httptest.NewRequest(http.MethodPost, "/api/episodes/"+id+"/publish", nil))
+		return rec
+	}
+
+	before := time.Now()
+	if rec := publish("wip"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	ep := store.GetPodcast().Episodes[1]
+	if ep.Status != models.StatusPublished || ep.PubDate.Before(before) {
+		t.Errorf("Expected the draft published and dated now, got %q at %v", ep.Status, ep.PubDate)
+	}
+	if feed, _ := rss.GenerateFeed(store.GetPodcast(), "http://example.com"); !strings.Contains(string(feed), "Draft Episode") {
+		t.Error("Expected the published draft in the public feed")
+	}
+
+	if rec := publish("wip"); rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 publishing a published episode, got %d", rec.Code)
+	}
+	if rec := publish("missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+
+	// A draft dated in the future is scheduled rather than published
+	later := time.Now().Add(24 * time.Hour)
+	store.AddEpisode(models.Episode{ID: "future", Title: "Future", Description: "Later", PubDate: later, Status: models.StatusDraft})
+	publish("future")
+	if ep := store.GetPodcast().Episodes[2]; ep.Status != models.StatusScheduled || !ep.PubDate.Equal(later) {
+		t.Errorf("Expected the future draft to be scheduled for its date, got %q at %v", ep.Status, ep.PubDate)
+	}
+}
+
+// Uploading with the draft box ticked keeps the episode out of the feed
+func TestUploadAsDraft(t *testing.T) {
+	store := newMemStore()
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+
+	rec := httptest.NewRecorder()
+	handler.HandleUpload(rec, newUploadRequest(t, "ep.mp3", buildMP3(10, 0), map[string]string{"title": "Rough Cut", "description": "Review me", "draft": "yes"}))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ep := store.GetPodcast().Episodes[0]; ep.Status != models.StatusDraft {
+		t.Errorf("Expected draft status, got %q", ep.Status)
+	}
+}
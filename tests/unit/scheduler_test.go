@@ -0,0 +1,139 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/models"
+	"github.com/example/rss-server/internal/scheduler"
+)
+
+func TestSchedulerPublishDue(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := newMemStore()
+	store.AddEpisode(models.Episode{ID: "due", Status: models.StatusScheduled, PubDate: now.Add(-time.Minute)})
+	store.AddEpisode(models.Episode{ID: "later", Status: models.StatusScheduled, PubDate: now.Add(time.Hour)})
+	store.AddEpisode(models.Episode{ID: "draft", Status: models.StatusDraft, PubDate: now.Add(-time.Hour)})
+
+	s := scheduler.New(store)
+	s.Now = func() time.Time { return now }
+
+	var hooked []string
+	s.OnPublish(func(ep models.Episode) { hooked = append(hooked, ep.ID) })
+	s.OnPublish(func(models.Episode) { panic("broken hook") })
+
+	if n := s.PublishDue(); n != 1 {
+		t.Fatalf("Expected 1 episode published, got %d", n)
+	}
+	if len(hooked) != 1 || hooked[0] != "due" {
+		t.Errorf("Expected the hook to run for the published episode, got %v", hooked)
+	}
+
+	status := map[string]string{}
+	for _, ep := range store.GetPodcast().Episodes {
+		status[ep.ID] = ep.Status
+	}
+	if status["due"] != models.StatusPublished || status["later"] != models.StatusScheduled || status["draft"] != models.StatusDraft {
+		t.Errorf("Unexpected statuses %v", status)
+	}
+
+	if next, ok := s.NextDue(); !ok || !next.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected next due time %v, got %v (%v)", now.Add(time.Hour), next, ok)
+	}
+	if n := s.PublishDue(); n != 0 {
+		t.Errorf("Expected nothing left to publish, got %d", n)
+	}
+}
+
+// staleStore lists the episodes as they were before later edits
+type staleStore struct {
+	*memStore
+	snapshot *models.Podcast
+}
+
+func (s staleStore) GetPodcast() *models.Podcast {
+	return s.snapshot
+}
+
+// An episode edited after the scheduler listed it is not overwritten with
+// the stale copy
+func TestSchedulerPublishDueKeepsConcurrentEdits(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := newMemStore()
+	store.AddEpisode(models.Episode{ID: "redrafted", Title: "Old", Status: models.StatusScheduled, PubDate: now.Add(-time.Minute)})
+	store.AddEpisode(models.Episode{ID: "renamed", Title: "Old", Status: models.StatusScheduled, PubDate: now.Add(-time.Minute)})
+	snapshot := store.GetPodcast()
+
+	// Edits land between the listing and the publishing
+	store.UpdateEpisode(models.Episode{ID: "redrafted", Title: "Old", Status: models.StatusDraft, PubDate: now.Add(-time.Minute)})
+	store.UpdateEpisode(models.Episode{ID: "renamed", Title: "New", Status: models.StatusScheduled, PubDate: now.Add(-time.Minute)})
+
+	s := scheduler.New(staleStore{store, snapshot})
+	s.Now = func() time.Time { return now }
+	if n := s.PublishDue(); n != 1 {
+		t.Fatalf("Expected 1 episode published, got %d", n)
+	}
+
+	for _, ep := range store.GetPodcast().Episodes {
+		switch {
+		case ep.ID == "redrafted" && ep.Status != models.StatusDraft:
+			t.Errorf("Expected the episode moved back to draft to stay a draft, got %q", ep.Status)
+		case ep.ID == "renamed" && (ep.Status != models.StatusPublished || ep.Title != "New"):
+			t.Errorf("Expected the edited episode published with its edit, got %+v", ep)
+		}
+	}
+}
+
+// The background publisher runs until stopped
+func TestSchedulerStart(t *testing.T) {
+	store := newMemStore()
+	store.AddEpisode(models.Episode{ID: "due", Status: models.StatusScheduled, PubDate: time.Now().Add(-time.Second)})
+
+	published := make(chan string, 1)
+	s := scheduler.New(store)
+	s.OnPublish(func(ep models.Episode) { published <- ep.ID })
+
+	stop := make(chan struct{})
+	defer close(stop)
+	s.Start(time.Hour, stop)
+
+	select {
+	case id := <-published:
+		if id != "due" {
+			t.Errorf("Expected episode 'due' to be published, got %q", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the scheduler")
+	}
+}
+
+// A future publication date schedules the upload instead of publishing it
+func TestUploadWithFuturePubDateIsScheduled(t *testing.T) {
+	store := newMemStore()
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+	upload := func(fields map[string]string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.HandleUpload(rec, newUploadRequest(t, "ep.mp3", buildMP3(10, 0), fields))
+		return rec
+	}
+
+	pubDate := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	if rec := upload(map[string]string{"title": "Later", "description": "Soon", "pubDate": pubDate}); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ep := store.GetPodcast().Episodes[0]; ep.Status != models.StatusScheduled {
+		t.Errorf("Expected scheduled status, got %q", ep.Status)
+	}
+
+	upload(map[string]string{"title": "Now", "description": "Today"})
+	if ep := store.GetPodcast().Episodes[1]; ep.Status != models.StatusPublished {
+		t.Errorf("Expected published status, got %q", ep.Status)
+	}
+
+	if rec := upload(map[string]string{"title": "Bad", "description": "Date", "pubDate": "next tuesday"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid date, got %d", rec.Code)
+	}
+}
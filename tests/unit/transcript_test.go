@@ -0,0 +1,222 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/media"
+	"github.com/example/rss-server/internal/models"
+)
+
+const sampleSRT = "\xEF\xBB\xBF7\r\n0:00:01,5 --> 0:00:04,000\r\n<v Host>Hello and welcome.\r\n\r\n8\r\n00:00:04,000 --> 00:01:02,250\r\nToday's topic.\r\n"
+
+func TestDetectTranscriptFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"notes.vtt", "WEBVTT\n\n00:01.000 --> 00:02.000\nHi\n", media.TranscriptVTT},
+		{"upload.bin", "WEBVTT\n", media.TranscriptVTT},
+		{"captions.SRT", "anything", media.TranscriptSRT},
+		{"transcript.txt", "Hello", media.TranscriptText},
+		{"upload", sampleSRT, media.TranscriptSRT},
+		{"upload", "Just words", ""},
+	}
+	for _, tt := range tests {
+		if got := media.DetectTranscriptFormat(tt.name, []byte(tt.data)); got != tt.want {
+			t.Errorf("DetectTranscriptFormat(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Normalization renumbers cues, pads timestamps and strips the BOM and CRs
+func TestNormalizeTranscript(t *testing.T) {
+	got, err := media.NormalizeTranscript(media.TranscriptSRT, []byte(sampleSRT))
+	if err != nil {
+		t.Fatalf("NormalizeTranscript failed: %v", err)
+	}
+	want := "1\n00:00:01,500 --> 00:00:04,000\n<v Host>Hello and welcome.\n\n2\n00:00:04,000 --> 00:01:02,250\nToday's topic.\n\n"
+	if string(got) != want {
+		t.Errorf("Unexpected SRT:\n%q\nwant\n%q", got, want)
+	}
+
+	vtt := "WEBVTT - episode 1\n\nNOTE written by hand\n\nintro\n01:02.000 --> 01:03.500 align:start\nHi\n"
+	got, err = media.NormalizeTranscript(media.TranscriptVTT, []byte(vtt))
+	if err != nil {
+		t.Fatalf("NormalizeTranscript failed: %v", err)
+	}
+	if want := "WEBVTT\n\n00:01:02.000 --> 00:01:03.500\nHi\n\n"; string(got) != want {
+		t.Errorf("Unexpected WebVTT:\n%q\nwant\n%q", got, want)
+	}
+
+	invalid := []struct {
+		format string
+		data   string
+	}{
+		{media.TranscriptSRT, "1\nHello\n"},
+		{media.TranscriptSRT, "1\n00:00:05,000 --> 00:00:01,000\nBackwards\n"},
+		{media.TranscriptSRT, "1\n00:00:61,000 --> 00:01:01,000\nBad seconds\n"},
+		{media.TranscriptVTT, "00:01.000 --> 00:02.000\nNo header\n"},
+		{media.TranscriptText, " \n "},
+		{media.TranscriptText, "\xff\xfe"},
+	}
+	for _, tt := range invalid {
+		if _, err := media.NormalizeTranscript(tt.format, []byte(tt.data)); !errors.Is(err, media.ErrInvalidTranscript) {
+			t.Errorf("Expected ErrInvalidTranscript for %s %q, got %v", tt.format, tt.data, err)
+		}
+	}
+}
+
+func TestConvertTranscript(t *testing.T) {
+	srt, err := media.NormalizeTranscript(media.TranscriptSRT, []byte(sampleSRT))
+	if err != nil {
+		t.Fatalf("NormalizeTranscript failed: %v", err)
+	}
+
+	vtt, err := media.ConvertTranscript(srt, media.TranscriptSRT, media.TranscriptVTT)
+	if err != nil {
+		t.Fatalf("ConvertTranscript failed: %v", err)
+	}
+	if !strings.HasPrefix(string(vtt), "WEBVTT\n\n00:00:01.500 --> 00:00:04.000\n") {
+		t.Errorf("Unexpected WebVTT:\n%s", vtt)
+	}
+
+	back, err := media.ConvertTranscript(vtt, media.TranscriptVTT, media.TranscriptSRT)
+	if err != nil || !bytes.Equal(back, srt) {
+		t.Errorf("Expected SRT round trip, got %q (%v)", back, err)
+	}
+
+	text, err := media.ConvertTranscript(srt, media.TranscriptSRT, media.TranscriptText)
+	if err != nil || string(text) != "Hello and welcome.\nToday's topic.\n" {
+		t.Errorf("Unexpected plain text %q (%v)", text, err)
+	}
+
+	if _, err := media.ConvertTranscript([]byte("Words\n"), media.TranscriptText, media.TranscriptSRT); err == nil {
+		t.Error("Expected plain text to SRT conversion to fail")
+	}
+}
+
+func newTranscriptRequest(t *testing.T, id, filename, content, language string) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if language != "" {
+		writer.WriteField("language", language)
+	}
+	part, err := writer.CreateFormFile("transcript", filename)
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write([]byte(content))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPut, "/api/episodes/"+id+"/transcript", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// Uploaded transcripts are stored beside the audio and served in every
+// timed format under a stable URL
+func TestTranscriptUploadAndServe(t *testing.T) {
+	store := newMemStore()
+	store.AddEpisode(models.Episode{ID: "ep-1", Filename: "show-20240101.mp3"})
+	audio := newMemBlobStore()
+	handler := handlers.NewEpisodesHandler(store, audio, newMemBlobStore(), 10, nil, nil)
+
+	rec := httptest.NewRecorder()
+	handler.HandleUploadTranscript(rec, newTranscriptRequest(t, "ep-1", "captions.srt", sampleSRT, "en"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	tr := store.GetPodcast().Episodes[0].Transcript
+	if tr == nil || tr.Filename != "show-20240101.srt" || tr.Format != media.TranscriptSRT || tr.Language != "en" {
+		t.Fatalf("Unexpected transcript: %+v", tr)
+	}
+	if !audio.has("show-20240101.srt") {
+		t.Error("Expected transcript stored beside the audio")
+	}
+
+	public := handlers.NewTranscriptsHandler(store, audio)
+	serve := func(p string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		public.HandleTranscript(rec, httptest.NewRequest(http.MethodGet, p, nil))
+		return rec
+	}
+
+	rec = serve("/episodes/ep-1/transcript.vtt")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "WEBVTT") {
+		t.Fatalf("Expected WebVTT, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/vtt; charset=utf-8" {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	if rec := serve("/episodes/ep-1/transcript.srt"); rec.Header().Get("Content-Type") != "application/x-subrip; charset=utf-8" {
+		t.Errorf("Unexpected SRT content type %q", rec.Header().Get("Content-Type"))
+	}
+	// Transcripts of unreleased episodes stay hidden
+	store.AddEpisode(models.Episode{ID: "ep-2", Status: models.StatusDraft, Transcript: tr})
+	public.SetPreviewToken(previewToken)
+	for _, p := range []string{"/episodes/ep-1/transcript.pdf", "/episodes/missing/transcript.srt", "/episodes/ep-2/transcript.srt"} {
+		if rec := serve(p); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %s, got %d", p, rec.Code)
+		}
+	}
+	if rec := serve("/episodes/ep-2/transcript.srt?token=" + previewToken); rec.Code != http.StatusOK {
+		t.Errorf("Expected the preview token to reveal the draft's transcript, got %d", rec.Code)
+	}
+
+	// Replacing with a different format removes the old file
+	rec = httptest.NewRecorder()
+	handler.HandleUploadTranscript(rec, newTranscriptRequest(t, "ep-1", "notes.txt", "Hello and welcome.", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if audio.has("show-20240101.srt") || !audio.has("show-20240101.txt") {
+		t.Error("Expected the SRT transcript to be replaced by the text one")
+	}
+	if rec := serve("/episodes/ep-1/transcript.srt"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no SRT for a plain text transcript, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.HandleDeleteTranscript(rec, httptest.NewRequest(http.MethodDelete, "/api/episodes/ep-1/transcript", nil))
+	if rec.Code != http.StatusOK || store.GetPodcast().Episodes[0].Transcript != nil || audio.has("show-20240101.txt") {
+		t.Errorf("Expected transcript deleted, got %d", rec.Code)
+	}
+}
+
+func TestTranscriptUploadRejectsInvalid(t *testing.T) {
+	store := newMemStore()
+	store.AddEpisode(models.Episode{ID: "ep-1", Filename: "show.mp3"})
+	handler := handlers.NewEpisodesHandler(store, newMemBlobStore(), newMemBlobStore(), 10, nil, nil)
+
+	tests := []struct {
+		name     string
+		id       string
+		filename string
+		content  string
+		code     int
+	}{
+		{"unknown format", "ep-1", "notes.doc", "words", http.StatusBadRequest},
+		{"bad timing", "ep-1", "captions.srt", "1\n00:00:01 --> 00:00:02\nHi\n", http.StatusBadRequest},
+		{"unknown episode", "nope", "notes.txt", "words", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.HandleUploadTranscript(rec, newTranscriptRequest(t, tt.id, tt.filename, tt.content, ""))
+			if rec.Code != tt.code {
+				t.Errorf("Expected status %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
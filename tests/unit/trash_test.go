@@ -0,0 +1,215 @@
+package unit
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/rss-server/internal/handlers"
+	"github.com/example/rss-server/internal/models"
+)
+
+const trashRetention = 30 * 24 * time.Hour
+
+func newTrashFixture() (*memStore, *memBlobStore, *memBlobStore, *handlers.TrashHandler) {
+	store := newMemStore()
+	audio := newMemBlobStore()
+	trash := newMemBlobStore()
+
+	store.AddEpisode(models.Episode{
+		ID: "ep-1", GUID: "guid-1", Title: "Episode 1", Description: "d",
+		PubDate: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), AudioURL: "/audio/ep-1.mp3", Filename: "ep-1.mp3",
+		Transcript: &models.Transcript{Filename: "ep-1.srt", Format: "srt"},
+	})
+	audio.blobs["ep-1.mp3"] = []byte("audio")
+	audio.blobs["ep-1.srt"] = []byte("transcript")
+
+	handler := handlers.NewTrashHandler(store, audio, newMemBlobStore(), trash, trashRetention, nil)
+	return store, audio, trash, handler
+}
+
+func serveTrash(fn http.HandlerFunc, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	fn(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+// Deleting moves the episode and its files to the trash; restoring brings
+// both back
+func TestTrashAndRestore(t *testing.T) {
+	store, audio, trash, handler := newTrashFixture()
+
+	if rec := serveTrash(handler.HandleTrash, http.MethodDelete, "/api/episodes/ep-1"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(store.GetPodcast().Episodes) != 0 {
+		t.Error("Expected episode to leave the podcast")
+	}
+	if audio.has("ep-1.mp3") || audio.has("ep-1.srt") {
+		t.Error("Expected files to leave the audio store")
+	}
+	if !trash.has("ep-1.mp3") || !trash.has("ep-1.srt") {
+		t.Error("Expected files in the trash store")
+	}
+
+	rec := serveTrash(handler.HandleList, http.MethodGet, "/api/trash")
+	var items []struct {
+		ID        string    `json:"id"`
+		DeletedAt time.Time `json:"deletedAt"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&items); err != nil {
+		t.Fatalf("Failed to decode trash list: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != "ep-1" {
+		t.Fatalf("Expected ep-1 in the trash, got %+v", items)
+	}
+	if got := items[0].ExpiresAt.Sub(items[0].DeletedAt); got != trashRetention {
+		t.Errorf("Expected expiry after the retention period, got %v", got)
+	}
+
+	if rec := serveTrash(handler.HandleRestore, http.MethodPost, "/api/trash/ep-1/restore"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	episodes := store.GetPodcast().Episodes
+	if len(episodes) != 1 || episodes[0].GUID != "guid-1" {
+		t.Fatalf("Expected episode restored with its GUID, got %+v", episodes)
+	}
+	if !audio.has("ep-1.mp3") || !audio.has("ep-1.srt") || trash.has("ep-1.mp3") {
+		t.Error("Expected files moved back to the audio store")
+	}
+
+	if rec := serveTrash(handler.HandleRestore, http.MethodPost, "/api/trash/ep-1/restore"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 restoring twice, got %d", rec.Code)
+	}
+}
+
+// Restoring over a live episode with the same ID is refused
+func TestRestoreConflict(t *testing.T) {
+	store, _, trash, handler := newTrashFixture()
+
+	serveTrash(handler.HandleTrash, http.MethodDelete, "/api/episodes/ep-1")
+	store.AddEpisode(models.Episode{ID: "ep-1", Title: "Replacement"})
+
+	if rec := serveTrash(handler.HandleRestore, http.MethodPost, "/api/trash/ep-1/restore"); rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", rec.Code)
+	}
+	if len(store.GetTrash()) != 1 || !trash.has("ep-1.mp3") {
+		t.Error("Expected the episode and its files to stay in the trash")
+	}
+}
+
+// Purging deletes the episode and its files for good
+func TestPurgeTrashedEpisode(t *testing.T) {
+	store, _, trash, handler := newTrashFixture()
+
+	serveTrash(handler.HandleTrash, http.MethodDelete, "/api/episodes/ep-1")
+	if rec := serveTrash(handler.HandlePurge, http.MethodDelete, "/api/trash/ep-1"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(store.GetTrash()) != 0 || trash.has("ep-1.mp3") || trash.has("ep-1.srt") {
+		t.Error("Expected the episode and its files to be purged")
+	}
+
+	if rec := serveTrash(handler.HandlePurge, http.MethodDelete, "/api/trash/ep-1"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 purging twice, got %d", rec.Code)
+	}
+	if rec := serveTrash(handler.HandleTrash, http.MethodDelete, "/api/episodes/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 deleting a missing episode, got %d", rec.Code)
+	}
+}
+
+// Purging deletes the episode's artwork and chapter images, keeping images
+// the podcast or other episodes still use
+func TestPurgeDeletesArtwork(t *testing.T) {
+	store := newMemStore()
+	artwork := newMemBlobStore()
+	for _, name := range []string{"ep-1.jpg", "ep-1-chapter-1.jpg", "shared.jpg", "show.jpg"} {
+		artwork.blobs[name] = []byte("image")
+	}
+
+	podcast := store.GetPodcast()
+	podcast.ImageURL = artwork.URL("show.jpg")
+	store.UpdatePodcast(podcast)
+	store.AddEpisode(models.Episode{ID: "ep-1", ImageURL: artwork.URL("ep-1.jpg"), Chapters: []models.Chapter{
+		{Title: "Intro", Img: artwork.URL("ep-1-chapter-1.jpg")},
+		{Title: "Guest", Img: artwork.URL("shared.jpg")},
+		{Title: "Show", Img: artwork.URL("show.jpg")},
+		{Title: "Elsewhere", Img: "https://cdn.example.com/ep-1.jpg"},
+	}})
+	store.AddEpisode(models.Episode{ID: "ep-2", ImageURL: artwork.URL("shared.jpg")})
+
+	handler := handlers.NewTrashHandler(store, newMemBlobStore(), artwork, newMemBlobStore(), trashRetention, nil)
+	serveTrash(handler.HandleTrash, http.MethodDelete, "/api/episodes/ep-1")
+	if !artwork.has("ep-1.jpg") || !artwork.has("ep-1-chapter-1.jpg") {
+		t.Fatal("Expected artwork to be kept while the episode can be restored")
+	}
+
+	if rec := serveTrash(handler.HandlePurge, http.MethodDelete, "/api/trash/ep-1"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if artwork.has("ep-1.jpg") || artwork.has("ep-1-chapter-1.jpg") {
+		t.Error("Expected the episode's artwork and chapter images to be deleted")
+	}
+	if !artwork.has("shared.jpg") || !artwork.has("show.jpg") {
+		t.Error("Expected artwork still in use to be kept")
+	}
+}
+
+// Only episodes older than the retention period are purged automatically
+func TestPurgeExpiredTrash(t *testing.T) {
+	store, _, trash, handler := newTrashFixture()
+	store.AddEpisode(models.Episode{ID: "ep-2", Title: "Episode 2", Filename: "ep-2.mp3"})
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	handler.Now = func() time.Time { return now }
+	serveTrash(handler.HandleTrash, http.MethodDelete, "/api/episodes/ep-1")
+
+	handler.Now = func() time.Time { return now.Add(10 * 24 * time.Hour) }
+	serveTrash(handler.HandleTrash, http.MethodDelete, "/api/episodes/ep-2")
+
+	if n := handler.PurgeExpired(); n != 0 {
+		t.Errorf("Expected nothing purged within retention, got %d", n)
+	}
+
+	handler.Now = func() time.Time { return now.Add(trashRetention + time.Hour) }
+	if n := handler.PurgeExpired(); n != 1 {
+		t.Fatalf("Expected 1 episode purged, got %d", n)
+	}
+
+	remaining := store.GetTrash()
+	if len(remaining) != 1 || remaining[0].ID != "ep-2" {
+		t.Errorf("Expected ep-2 to remain in the trash, got %+v", remaining)
+	}
+	if trash.has("ep-1.mp3") {
+		t.Error("Expected expired episode's files to be deleted")
+	}
+}
+
+// The dashboard trash view lists episodes with restore and purge actions
+func TestTrashListHTML(t *testing.T) {
+	store, audio, trash, _ := newTrashFixture()
+	tmpl, err := template.ParseGlob("../../web/templates/components/*.html")
+	if err != nil {
+		t.Fatalf("Failed to parse templates: %v", err)
+	}
+	handler := handlers.NewTrashHandler(store, audio, newMemBlobStore(), trash, trashRetention, tmpl)
+
+	serveTrash(handler.HandleTrash, http.MethodDelete, "/api/episodes/ep-1")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/trash", nil)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	handler.HandleList(rec, req)
+
+	body := rec.Body.String()
+	for _, want := range []string{"Episode 1", `hx-post="/api/trash/ep-1/restore"`, `hx-delete="/api/trash/ep-1"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected trash view to contain %q, got:\n%s", want, body)
+		}
+	}
+}